@@ -32,6 +32,9 @@ const (
 	TRAIT_STATEMENT                NODE_TYPE = "trait statement"
 	STRUCT_STATEMENT               NODE_TYPE = "struct statement"
 	IMPLEMENTS_STATEMENT           NODE_TYPE = "implements statement"
+	RECOVER_STATEMENT              NODE_TYPE = "recover statement"
+	DEFER_STATEMENT                NODE_TYPE = "defer statement"
+	DESTRUCTURING_STATEMENT        NODE_TYPE = "destructuring statement"
 
 	// Literals
 	NUMERIC_LITERAL   NODE_TYPE = "NUMERIC_LITERAL"
@@ -44,6 +47,8 @@ const (
 	VOID_LITERAL      NODE_TYPE = "void literal"
 	ARRAY_LITERALS    NODE_TYPE = "array literals"
 	STRUCT_LITERAL    NODE_TYPE = "struct literal"
+	MAP_LITERAL       NODE_TYPE = "map literal"
+	SET_LITERAL       NODE_TYPE = "set literal"
 
 	STRUCT_PROPERTY NODE_TYPE = "struct property"
 
@@ -59,7 +64,24 @@ const (
 	FUNCTION_CALL_EXPRESSION NODE_TYPE = "function call expression"
 
 	// Unary Operations
-	UNARY_EXPRESSION NODE_TYPE = "unary expression"
+	UNARY_EXPRESSION   NODE_TYPE = "unary expression"
+	POSTFIX_EXPRESSION NODE_TYPE = "postfix expression"
+
+	ARRAY_INDEX_ACCESS NODE_TYPE = "array index access"
+
+	TYPE_CAST_EXPRESSION NODE_TYPE = "type cast expression"
+
+	TERNARY_EXPRESSION NODE_TYPE = "ternary expression"
+
+	TRY_EXPRESSION   NODE_TYPE = "try expression"
+	CATCH_EXPRESSION NODE_TYPE = "catch expression"
+
+	TUPLE_EXPRESSION NODE_TYPE = "tuple expression"
+	RANGE_EXPRESSION NODE_TYPE = "range expression"
+
+	METHOD_CALL_EXPRESSION NODE_TYPE = "method call expression"
+
+	TYPEOF_EXPRESSION NODE_TYPE = "typeof expression"
 )
 
 type Node interface {
@@ -38,6 +38,23 @@ func (u UnaryExpr) iExpression() {
 	// empty method implements the Expression interface
 }
 
+// PostfixExpr is x++ or x--. Its value is the value of the operand before the update
+type PostfixExpr struct {
+	BaseStmt
+	Operator lexer.Token
+	Argument Expression
+}
+
+func (p PostfixExpr) INodeType() NODE_TYPE {
+	return p.Kind
+}
+func (p PostfixExpr) GetPos() (lexer.Position, lexer.Position) {
+	return p.StartPos, p.EndPos
+}
+func (p PostfixExpr) iExpression() {
+	// empty method implements the Expression interface
+}
+
 type IdentifierExpr struct {
 	BaseStmt
 	Identifier string
@@ -55,8 +72,12 @@ func (i IdentifierExpr) iExpression() {
 
 type NumericLiteral struct {
 	BaseStmt
-	Value   string
-	BitSize uint8
+	// decimal digits of the value, without separators, base prefix or suffix
+	Value    string
+	BitSize  uint8
+	IsSigned bool
+	// type suffix written after the literal (255u8, 3.0f64), empty if none
+	Suffix string
 }
 
 func (n NumericLiteral) INodeType() NODE_TYPE {
@@ -196,6 +217,8 @@ type StructPropertyExpr struct {
 	BaseStmt
 	Object   Expression
 	Property IdentifierExpr
+	// accessed with ?., the result is null if the object is null
+	Optional bool
 }
 
 func (s StructPropertyExpr) INodeType() NODE_TYPE {
@@ -223,3 +246,193 @@ func (a ArrayLiterals) GetPos() (lexer.Position, lexer.Position) {
 func (a ArrayLiterals) iExpression() {
 	// empty method implements the Expression interface
 }
+
+type ArrayIndexAccess struct {
+	BaseStmt
+	Array Expression
+	Index Expression
+}
+
+func (a ArrayIndexAccess) INodeType() NODE_TYPE {
+	return a.Kind
+}
+func (a ArrayIndexAccess) GetPos() (lexer.Position, lexer.Position) {
+	return a.StartPos, a.EndPos
+}
+func (a ArrayIndexAccess) iExpression() {
+	// empty method implements the Expression interface
+}
+
+type TypeCastExpr struct {
+	BaseStmt
+	Expression Expression
+	TargetType Type
+}
+
+func (t TypeCastExpr) INodeType() NODE_TYPE {
+	return t.Kind
+}
+func (t TypeCastExpr) GetPos() (lexer.Position, lexer.Position) {
+	return t.StartPos, t.EndPos
+}
+func (t TypeCastExpr) iExpression() {
+	// empty method implements the Expression interface
+}
+
+// TernaryExpr is condition ? consequent : alternate. Only the selected branch is evaluated
+type TernaryExpr struct {
+	BaseStmt
+	Condition  Expression
+	Consequent Expression
+	Alternate  Expression
+}
+
+func (t TernaryExpr) INodeType() NODE_TYPE {
+	return t.Kind
+}
+func (t TernaryExpr) GetPos() (lexer.Position, lexer.Position) {
+	return t.StartPos, t.EndPos
+}
+func (t TernaryExpr) iExpression() {
+	// empty method implements the Expression interface
+}
+
+// TryExpr is try expr. If the value is an error, the enclosing function returns it
+type TryExpr struct {
+	BaseStmt
+	Expression Expression
+}
+
+func (t TryExpr) INodeType() NODE_TYPE {
+	return t.Kind
+}
+func (t TryExpr) GetPos() (lexer.Position, lexer.Position) {
+	return t.StartPos, t.EndPos
+}
+func (t TryExpr) iExpression() {
+	// empty method implements the Expression interface
+}
+
+// CatchExpr is expr catch fallback or expr catch |e| fallback. The fallback is evaluated only if the value is an error
+type CatchExpr struct {
+	BaseStmt
+	Expression Expression
+	// ErrorName is the variable that holds the error in the fallback. It is empty if the error is not bound
+	ErrorName string
+	Fallback  Expression
+}
+
+func (c CatchExpr) INodeType() NODE_TYPE {
+	return c.Kind
+}
+func (c CatchExpr) GetPos() (lexer.Position, lexer.Position) {
+	return c.StartPos, c.EndPos
+}
+func (c CatchExpr) iExpression() {
+	// empty method implements the Expression interface
+}
+
+// TupleExpr is (a, b, ...), or the values of ret a, b;
+type TupleExpr struct {
+	BaseStmt
+	Elements []Expression
+}
+
+func (t TupleExpr) INodeType() NODE_TYPE {
+	return t.Kind
+}
+func (t TupleExpr) GetPos() (lexer.Position, lexer.Position) {
+	return t.StartPos, t.EndPos
+}
+func (t TupleExpr) iExpression() {
+	// empty method implements the Expression interface
+}
+
+// MapLiteral is map[K]V{ key: value, ... }
+type MapLiteral struct {
+	BaseStmt
+	Type   MapType
+	Keys   []Expression
+	Values []Expression
+}
+
+func (m MapLiteral) INodeType() NODE_TYPE {
+	return m.Kind
+}
+func (m MapLiteral) GetPos() (lexer.Position, lexer.Position) {
+	return m.StartPos, m.EndPos
+}
+func (m MapLiteral) iExpression() {
+	// empty method implements the Expression interface
+}
+
+// SetLiteral is set[T]{ element, ... }
+type SetLiteral struct {
+	BaseStmt
+	Type     SetType
+	Elements []Expression
+}
+
+func (s SetLiteral) INodeType() NODE_TYPE {
+	return s.Kind
+}
+func (s SetLiteral) GetPos() (lexer.Position, lexer.Position) {
+	return s.StartPos, s.EndPos
+}
+func (s SetLiteral) iExpression() {
+	// empty method implements the Expression interface
+}
+
+// RangeExpr is start..end, or start..=end if Inclusive. Step is nil without a step clause
+type RangeExpr struct {
+	BaseStmt
+	Start     Expression
+	End       Expression
+	Inclusive bool
+	Step      Expression
+}
+
+func (r RangeExpr) INodeType() NODE_TYPE {
+	return r.Kind
+}
+func (r RangeExpr) GetPos() (lexer.Position, lexer.Position) {
+	return r.StartPos, r.EndPos
+}
+func (r RangeExpr) iExpression() {
+	// empty method implements the Expression interface
+}
+
+// MethodCallExpr is object.method(args). With ?. the call gives null if the object is null
+type MethodCallExpr struct {
+	BaseStmt
+	Object   Expression
+	Method   IdentifierExpr
+	Args     []Expression
+	Optional bool
+}
+
+func (m MethodCallExpr) INodeType() NODE_TYPE {
+	return m.Kind
+}
+func (m MethodCallExpr) GetPos() (lexer.Position, lexer.Position) {
+	return m.StartPos, m.EndPos
+}
+func (m MethodCallExpr) iExpression() {
+	// empty method implements the Expression interface
+}
+
+// TypeofExpr is typeof(expr), the type of the value of the expression
+type TypeofExpr struct {
+	BaseStmt
+	Expression Expression
+}
+
+func (t TypeofExpr) INodeType() NODE_TYPE {
+	return t.Kind
+}
+func (t TypeofExpr) GetPos() (lexer.Position, lexer.Position) {
+	return t.StartPos, t.EndPos
+}
+func (t TypeofExpr) iExpression() {
+	// empty method implements the Expression interface
+}
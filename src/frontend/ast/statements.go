@@ -78,6 +78,8 @@ type VariableDclStml struct {
 	Identifier   IdentifierExpr
 	Value        Expression
 	ExplicitType Type
+	// declared with export, the modules that import this one can use it
+	IsExported bool
 }
 
 func (v VariableDclStml) INodeType() NODE_TYPE {
@@ -90,6 +92,27 @@ func (v VariableDclStml) iStatement() {
 	// empty method implements the Statement interface
 }
 
+// DestructuringDclStmt is let (a, b) := value; for the elements of a tuple,
+// or let { name, score } := value; for the fields of a struct. The name _ discards a tuple element
+type DestructuringDclStmt struct {
+	BaseStmt
+	IsConstant bool
+	IsStruct   bool
+	Names      []IdentifierExpr
+	Value      Expression
+	IsExported bool
+}
+
+func (d DestructuringDclStmt) INodeType() NODE_TYPE {
+	return d.Kind
+}
+func (d DestructuringDclStmt) GetPos() (lexer.Position, lexer.Position) {
+	return d.StartPos, d.EndPos
+}
+func (d DestructuringDclStmt) iStatement() {
+	// empty method implements the Statement interface
+}
+
 type FunctionParameter struct {
 	BaseStmt
 	IsVariadic bool
@@ -108,7 +131,8 @@ type FunctionPrototype struct {
 type FunctionDeclStmt struct {
 	BaseStmt
 	FunctionPrototype
-	Block BlockStmt
+	Block      BlockStmt
+	IsExported bool
 }
 
 func (f FunctionDeclStmt) INodeType() NODE_TYPE {
@@ -136,8 +160,10 @@ func (r ReturnStmt) iStatement() {
 	// empty method implements the Statement interface
 }
 
+// BreakStmt leaves the loop with the label, or the innermost loop if it has no label
 type BreakStmt struct {
 	BaseStmt
+	Label string
 }
 
 func (b BreakStmt) INodeType() NODE_TYPE {
@@ -150,8 +176,10 @@ func (b BreakStmt) iStatement() {
 	// empty method implements the Statement interface
 }
 
+// ContinueStmt starts the next iteration of the loop with the label, or of the innermost loop if it has no label
 type ContinueStmt struct {
 	BaseStmt
+	Label string
 }
 
 func (c ContinueStmt) INodeType() NODE_TYPE {
@@ -179,6 +207,7 @@ type StructDeclStatement struct {
 	Properties map[string]Property
 	Methods    map[string]FunctionType
 	Embeds     []string
+	IsExported bool
 }
 
 func (s StructDeclStatement) INodeType() NODE_TYPE {
@@ -199,8 +228,9 @@ type Method struct {
 }
 type TraitDeclStatement struct {
 	BaseStmt
-	TraitName string
-	Methods   map[string]Method
+	TraitName  string
+	Methods    map[string]Method
+	IsExported bool
 }
 
 func (t TraitDeclStatement) INodeType() NODE_TYPE {
@@ -262,6 +292,9 @@ type ForStmt struct {
 	Condition Expression
 	Post      Expression
 	Block     BlockStmt
+	Label     string
+	// Else runs when the loop ends without break. It is nil if the loop has no els block
+	Else *BlockStmt
 }
 
 func (f ForStmt) INodeType() NODE_TYPE {
@@ -276,11 +309,16 @@ func (f ForStmt) iStatement() {
 
 type ForeachStmt struct {
 	BaseStmt
-	Variable      string
+	Variable string
+	// Variables are the names of foreach (k, v) in pairs, which destructures tuple elements. Variable is empty then
+	Variables     []IdentifierExpr
 	IndexVariable string
 	Iterable      Expression
 	WhereClause   Expression
 	Block         BlockStmt
+	Label         string
+	// Else runs when the loop ends without break. It is nil if the loop has no els block
+	Else *BlockStmt
 }
 
 func (f ForeachStmt) INodeType() NODE_TYPE {
@@ -297,6 +335,9 @@ type WhileLoopStmt struct {
 	BaseStmt
 	Condition Expression
 	Block     BlockStmt
+	Label     string
+	// Else runs when the loop ends without break. It is nil if the loop has no els block
+	Else *BlockStmt
 }
 
 func (w WhileLoopStmt) INodeType() NODE_TYPE {
@@ -330,3 +371,38 @@ func (s SwitchStmt) GetPos() (lexer.Position, lexer.Position) {
 func (s SwitchStmt) iStatement() {
 	// empty method implements the Statement interface
 }
+
+// RecoverStmt is recover { ... } catch |e| { ... }. The handler runs if a runtime error stops the first block
+type RecoverStmt struct {
+	BaseStmt
+	Block BlockStmt
+	// ErrorName is the variable that holds the error in the handler. It is empty if the error is not bound
+	ErrorName string
+	Handler   BlockStmt
+}
+
+func (r RecoverStmt) INodeType() NODE_TYPE {
+	return r.Kind
+}
+func (r RecoverStmt) GetPos() (lexer.Position, lexer.Position) {
+	return r.StartPos, r.EndPos
+}
+func (r RecoverStmt) iStatement() {
+	// empty method implements the Statement interface
+}
+
+// DeferStmt is defer call;. The call is made when the enclosing function returns, with the arguments it had at the defer
+type DeferStmt struct {
+	BaseStmt
+	Call FunctionCallExpr
+}
+
+func (d DeferStmt) INodeType() NODE_TYPE {
+	return d.Kind
+}
+func (d DeferStmt) GetPos() (lexer.Position, lexer.Position) {
+	return d.StartPos, d.EndPos
+}
+func (d DeferStmt) iStatement() {
+	// empty method implements the Statement interface
+}
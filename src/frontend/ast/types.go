@@ -6,16 +6,21 @@ type DATA_TYPE string
 
 const (
 	// Primitive Types
-	T_VOID      DATA_TYPE = "void"
-	T_INTEGER8  DATA_TYPE = "i8"
-	T_INTEGER16 DATA_TYPE = "i16"
-	T_INTEGER32 DATA_TYPE = "i32"
-	T_INTEGER64 DATA_TYPE = "i64"
-
-	T_UNSIGNED8  DATA_TYPE = "u8"
-	T_UNSIGNED16 DATA_TYPE = "u16"
-	T_UNSIGNED32 DATA_TYPE = "u32"
-	T_UNSIGNED64 DATA_TYPE = "u64"
+	T_VOID       DATA_TYPE = "void"
+	T_INTEGER8   DATA_TYPE = "i8"
+	T_INTEGER16  DATA_TYPE = "i16"
+	T_INTEGER32  DATA_TYPE = "i32"
+	T_INTEGER64  DATA_TYPE = "i64"
+	T_INTEGER128 DATA_TYPE = "i128"
+
+	T_UNSIGNED8   DATA_TYPE = "u8"
+	T_UNSIGNED16  DATA_TYPE = "u16"
+	T_UNSIGNED32  DATA_TYPE = "u32"
+	T_UNSIGNED64  DATA_TYPE = "u64"
+	T_UNSIGNED128 DATA_TYPE = "u128"
+
+	// unbounded integer
+	T_BIGINT DATA_TYPE = "bigint"
 
 	T_FLOAT32   DATA_TYPE = "f32"
 	T_FLOAT64   DATA_TYPE = "f64"
@@ -23,9 +28,16 @@ const (
 	T_STRING    DATA_TYPE = "str"
 	T_CHARACTER DATA_TYPE = "chr"
 	T_NULL      DATA_TYPE = "null"
+	T_ERROR     DATA_TYPE = "error"
 
 	// Derived Types
-	T_ARRAY DATA_TYPE = "array"
+	T_ARRAY    DATA_TYPE = "array"
+	T_OPTIONAL DATA_TYPE = "optional"
+	T_RESULT   DATA_TYPE = "result"
+	T_TUPLE    DATA_TYPE = "tuple"
+	T_MAP      DATA_TYPE = "map"
+	T_SET      DATA_TYPE = "set"
+	T_RANGE    DATA_TYPE = "range"
 
 	T_STRUCT   DATA_TYPE = "struct"
 	T_TRAIT    DATA_TYPE = "trait"
@@ -33,6 +45,9 @@ const (
 	T_FUNCTION DATA_TYPE = "function"
 	T_NATIVE_FN DATA_TYPE = "native_fn"
 
+	// the type of typeof(x)
+	T_TYPE DATA_TYPE = "type"
+
 	//User Defined Types
 	T_USER_DEFINED DATA_TYPE = "user_defined"
 )
@@ -44,6 +59,9 @@ type IntegerType struct {
 }
 
 func (i IntegerType) IType() DATA_TYPE {
+	if i.BitSize == 0 {
+		return T_BIGINT
+	}
 	if i.IsSigned {
 		return DATA_TYPE(("i" + strconv.Itoa(int(i.BitSize))))
 	} else {
@@ -110,6 +128,75 @@ func (a ArrayType) IType() DATA_TYPE {
 	return a.Kind
 }
 
+// OptionalType is T?, a value of type T or null. Other types never hold null
+type OptionalType struct {
+	Kind  DATA_TYPE
+	Inner Type
+}
+
+func (o OptionalType) IType() DATA_TYPE {
+	return o.Kind
+}
+
+type ErrorType struct {
+	Kind DATA_TYPE
+}
+
+func (e ErrorType) IType() DATA_TYPE {
+	return e.Kind
+}
+
+// ResultType is T!, a value of type T or an error. The error must be handled before the value is used
+type ResultType struct {
+	Kind  DATA_TYPE
+	Value Type
+}
+
+func (r ResultType) IType() DATA_TYPE {
+	return r.Kind
+}
+
+// TupleType is (T1, T2, ...), a fixed number of values of possibly different types
+type TupleType struct {
+	Kind     DATA_TYPE
+	Elements []Type
+}
+
+func (t TupleType) IType() DATA_TYPE {
+	return t.Kind
+}
+
+// MapType is map[K]V. The keys are kept in the order they were inserted
+type MapType struct {
+	Kind  DATA_TYPE
+	Key   Type
+	Value Type
+}
+
+func (m MapType) IType() DATA_TYPE {
+	return m.Kind
+}
+
+// SetType is set[T]. The elements are kept in the order they were inserted
+type SetType struct {
+	Kind    DATA_TYPE
+	Element Type
+}
+
+func (s SetType) IType() DATA_TYPE {
+	return s.Kind
+}
+
+// RangeType is range[T], the type of 0..10. T is an integer type
+type RangeType struct {
+	Kind    DATA_TYPE
+	Element Type
+}
+
+func (r RangeType) IType() DATA_TYPE {
+	return r.Kind
+}
+
 type StructType struct {
 	Kind DATA_TYPE
 	Name string
@@ -155,3 +242,12 @@ type NativeFnType struct {
 func (n NativeFnType) IType() DATA_TYPE {
 	return n.Kind
 }
+
+// TypeType is the type of the values made by typeof(x)
+type TypeType struct {
+	Kind DATA_TYPE
+}
+
+func (t TypeType) IType() DATA_TYPE {
+	return t.Kind
+}
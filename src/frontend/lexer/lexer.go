@@ -5,6 +5,7 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 	"walrus/utils"
 )
 
@@ -15,6 +16,7 @@ type regexPattern struct {
 	handler regexHandler
 }
 
+// Position is a place in the source. Column counts characters from 1, Index is the byte offset in the source
 type Position struct {
 	Line   int
 	Column int
@@ -30,9 +32,11 @@ func (p *Position) advance(toSkip string) *Position {
 		} else {
 			p.Column++
 		}
-		p.Index++
 	}
 
+	// a character of UTF-8 source can take several bytes
+	p.Index += len(toSkip)
+
 	return p
 }
 
@@ -67,17 +71,7 @@ func Tokenize(source, file string, debug bool) ([]Token, *[]string) {
 		}
 
 		if !matched {
-
-			//line is from lex.Pos.Index to
-			padding := fmt.Sprintf("%d | ", lex.Pos.Line)
-
-			errStr := fmt.Sprintf("\n%s:%d:%d\n", lex.FilePath, lex.Pos.Line, lex.Pos.Column)
-			errStr += utils.Colorize(utils.GREY, padding) + Highlight(lex.Lines[lex.Pos.Line]) + "\n"
-			errStr += utils.Colorize(utils.BOLD_RED, (strings.Repeat(" ", (lex.Pos.Column-1)+len(padding)) + "^\n"))
-			errStr += fmt.Sprintf("At line %d: Unexpected character: '%c'", lex.Pos.Line, lex.at())
-			fmt.Println(errStr)
-
-			os.Exit(-1)
+			lex.reportError(lex.Pos, fmt.Sprintf("Unexpected character: '%c'", lex.at()))
 		}
 	}
 
@@ -93,6 +87,20 @@ func Tokenize(source, file string, debug bool) ([]Token, *[]string) {
 	return lex.Tokens, &lex.Lines
 }
 
+func (lex *Lexer) reportError(pos Position, message string) {
+
+	//line is from lex.Pos.Index to
+	padding := fmt.Sprintf("%d | ", pos.Line)
+
+	errStr := fmt.Sprintf("\n%s:%d:%d\n", lex.FilePath, pos.Line, pos.Column)
+	errStr += utils.Colorize(utils.GREY, padding) + Highlight(lex.Lines[pos.Line-1]) + "\n"
+	errStr += utils.Colorize(utils.BOLD_RED, (strings.Repeat(" ", (pos.Column-1)+len(padding)) + "^\n"))
+	errStr += fmt.Sprintf("At line %d: %s", pos.Line, message)
+	fmt.Println(errStr)
+
+	os.Exit(-1)
+}
+
 func (lex *Lexer) advanceN(match string) {
 	//ascii value of match-
 	lex.Pos.advance(match)
@@ -102,8 +110,9 @@ func (lex *Lexer) push(token Token) {
 	lex.Tokens = append(lex.Tokens, token)
 }
 
-func (lex *Lexer) at() byte {
-	return (*(lex.source))[lex.Pos.Index]
+func (lex *Lexer) at() rune {
+	char, _ := utf8.DecodeRuneInString(lex.remainder())
+	return char
 }
 
 func (lex *Lexer) remainder() string {
@@ -142,7 +151,7 @@ func createLexer(source *string) *Lexer {
 			{regexp.MustCompile(`\/\*[\s\S]*?\*\/`), skipHandler},             // multi line comments
 			{regexp.MustCompile(`"[^"]*"`), stringHandler},                    // string literals
 			{regexp.MustCompile(`'[^']'`), characterHandler},                  // character literals
-			{regexp.MustCompile(`[0-9]+(?:\.[0-9]+)?`), numberHandler},        // decimal numbers
+			{regexp.MustCompile(numberPattern), numberHandler},                // numbers
 			{regexp.MustCompile(`[a-zA-Z_][a-zA-Z0-9_]*`), identifierHandler}, // identifiers
 			{regexp.MustCompile(`\[`), defaultHandler(OPEN_BRACKET_TOKEN, "[")},
 			{regexp.MustCompile(`\]`), defaultHandler(CLOSE_BRACKET_TOKEN, "]")},
@@ -155,17 +164,29 @@ func createLexer(source *string) *Lexer {
 			{regexp.MustCompile(`=`), defaultHandler(ASSIGNMENT_TOKEN, "=")},
 			{regexp.MustCompile(`:=`), defaultHandler(WALRUS_TOKEN, ":=")},
 			{regexp.MustCompile(`!`), defaultHandler(NOT_TOKEN, "!")},
+			{regexp.MustCompile(`<<=`), defaultHandler(SHIFT_LEFT_EQUALS_TOKEN, "<<=")},
+			{regexp.MustCompile(`>>=`), defaultHandler(SHIFT_RIGHT_EQUALS_TOKEN, ">>=")},
+			{regexp.MustCompile(`<<`), defaultHandler(SHIFT_LEFT_TOKEN, "<<")},
+			{regexp.MustCompile(`>>`), defaultHandler(SHIFT_RIGHT_TOKEN, ">>")},
 			{regexp.MustCompile(`<=`), defaultHandler(LESS_EQUALS_TOKEN, "<=")},
 			{regexp.MustCompile(`<`), defaultHandler(LESS_TOKEN, "<")},
 			{regexp.MustCompile(`>=`), defaultHandler(GREATER_EQUALS_TOKEN, ">=")},
 			{regexp.MustCompile(`>`), defaultHandler(GREATER_TOKEN, ">")},
 			{regexp.MustCompile(`\|\|`), defaultHandler(OR_TOKEN, "||")},
 			{regexp.MustCompile(`&&`), defaultHandler(AND_TOKEN, "&&")},
+			{regexp.MustCompile(`&=`), defaultHandler(BIT_AND_EQUALS_TOKEN, "&=")},
+			{regexp.MustCompile(`\|=`), defaultHandler(BIT_OR_EQUALS_TOKEN, "|=")},
+			{regexp.MustCompile(`&`), defaultHandler(BIT_AND_TOKEN, "&")},
+			{regexp.MustCompile(`\|`), defaultHandler(BIT_OR_TOKEN, "|")},
+			{regexp.MustCompile(`~`), defaultHandler(BIT_NOT_TOKEN, "~")},
+			{regexp.MustCompile(`\.\.=`), defaultHandler(DOT_DOT_EQUALS_TOKEN, "..=")},
 			{regexp.MustCompile(`\.\.`), defaultHandler(DOT_DOT_TOKEN, "..")},
 			{regexp.MustCompile(`\.`), defaultHandler(DOT_TOKEN, ".")},
 			{regexp.MustCompile(`;`), defaultHandler(SEMI_COLON_TOKEN, ";")},
 			{regexp.MustCompile(`:`), defaultHandler(COLON_TOKEN, ":")},
-			//{regexp.MustCompile(`\?\?=`), defaultHandler(NULLISH_ASSIGNMENT, "??=")},
+			{regexp.MustCompile(`\?\?=`), defaultHandler(NULLISH_ASSIGNMENT_TOKEN, "??=")},
+			{regexp.MustCompile(`\?\?`), defaultHandler(NULLISH_TOKEN, "??")},
+			{regexp.MustCompile(`\?\.`), defaultHandler(OPTIONAL_CHAIN_TOKEN, "?.")},
 			{regexp.MustCompile(`->`), defaultHandler(ARROW_TOKEN, "->")},
 			{regexp.MustCompile(`\?`), defaultHandler(QUESTION_TOKEN, "?")},
 			{regexp.MustCompile(`,`), defaultHandler(COMMA_TOKEN, ",")},
@@ -173,16 +194,18 @@ func createLexer(source *string) *Lexer {
 			{regexp.MustCompile(`--`), defaultHandler(MINUS_MINUS_TOKEN, "--")},
 			{regexp.MustCompile(`\+=`), defaultHandler(PLUS_EQUALS_TOKEN, "+=")},
 			{regexp.MustCompile(`-=`), defaultHandler(MINUS_EQUALS_TOKEN, "-=")},
+			{regexp.MustCompile(`\*\*=`), defaultHandler(POWER_EQUALS_TOKEN, "**=")},
+			{regexp.MustCompile(`\*\*`), defaultHandler(POWER_TOKEN, "**")},
 			{regexp.MustCompile(`\*=`), defaultHandler(TIMES_EQUALS_TOKEN, "*=")},
 			{regexp.MustCompile(`/=`), defaultHandler(DIVIDE_EQUALS_TOKEN, "/=")},
 			{regexp.MustCompile(`%=`), defaultHandler(MODULO_EQUALS_TOKEN, "%=")},
-			{regexp.MustCompile(`\^=`), defaultHandler(POWER_EQUALS_TOKEN, "^=")},
+			{regexp.MustCompile(`\^=`), defaultHandler(XOR_EQUALS_TOKEN, "^=")},
 			{regexp.MustCompile(`\+`), defaultHandler(PLUS_TOKEN, "+")},
 			{regexp.MustCompile(`-`), defaultHandler(MINUS_TOKEN, "-")},
 			{regexp.MustCompile(`/`), defaultHandler(DIVIDE_TOKEN, "/")},
 			{regexp.MustCompile(`\*`), defaultHandler(TIMES_TOKEN, "*")},
 			{regexp.MustCompile(`%`), defaultHandler(MODULO_TOKEN, "%")},
-			{regexp.MustCompile(`^`), defaultHandler(POWER_TOKEN, "^")},
+			{regexp.MustCompile(`\^`), defaultHandler(XOR_TOKEN, "^")},
 		},
 	}
 
@@ -205,6 +228,9 @@ func identifierHandler(lex *Lexer, regex *regexp.Regexp) {
 
 }
 
+// hexadecimal, octal, binary or decimal digits with _ separators, an optional fraction and exponent and an optional type suffix
+const numberPattern = `(?:0[xX][0-9a-fA-F_]+|0[oO][0-7_]+|0[bB][01_]+|[0-9][0-9_]*(?:\.[0-9][0-9_]*)?(?:[eE][+-]?[0-9_]+)?)(?:i128|i16|i32|i64|i8|u128|u16|u32|u64|u8|f32|f64)?`
+
 func numberHandler(lex *Lexer, regex *regexp.Regexp) {
 	match := regex.FindString(lex.remainder())
 
@@ -214,14 +240,26 @@ func numberHandler(lex *Lexer, regex *regexp.Regexp) {
 
 	end := lex.Pos
 
+	// a number directly followed by letters or digits (0x, 12abc, 0b102) is not a valid literal
+	if rest := lex.remainder(); len(rest) > 0 && isIdentifierChar(rest[0]) {
+		invalid := match + regexp.MustCompile(`^[a-zA-Z0-9_]*`).FindString(rest)
+		lex.reportError(start, fmt.Sprintf("Invalid numeric literal '%s'", invalid))
+	}
+
 	//find the number is a float or an integer
-	if strings.Contains(match, ".") {
+	isDecimal := !strings.HasPrefix(strings.ToLower(match), "0x") && !strings.HasPrefix(strings.ToLower(match), "0o") && !strings.HasPrefix(strings.ToLower(match), "0b")
+
+	if strings.HasSuffix(match, "f32") || strings.HasSuffix(match, "f64") || (isDecimal && strings.ContainsAny(match, ".eE")) {
 		lex.push(NewToken(FLOATING_TOKEN, match, start, end))
 	} else {
 		lex.push(NewToken(INTEGER_TOKEN, match, start, end))
 	}
 }
 
+func isIdentifierChar(char byte) bool {
+	return char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9')
+}
+
 func stringHandler(lex *Lexer, regex *regexp.Regexp) {
 
 	match := regex.FindString(lex.remainder())
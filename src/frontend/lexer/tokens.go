@@ -53,8 +53,15 @@ const (
 	SEMI_COLON_TOKEN TOKEN_KIND = ";"
 	COLON_TOKEN      TOKEN_KIND = ":"
 	QUESTION_TOKEN   TOKEN_KIND = "?"
+	NULLISH_TOKEN    TOKEN_KIND = "??"
 	COMMA_TOKEN      TOKEN_KIND = ","
 
+	// inclusive range, 1..=3 is 1, 2 and 3
+	DOT_DOT_EQUALS_TOKEN TOKEN_KIND = "..="
+
+	// optional chaining, a?.b is null when a is null
+	OPTIONAL_CHAIN_TOKEN TOKEN_KIND = "?."
+
 	// Unary operators
 	NOT_TOKEN           TOKEN_KIND = "!"
 	PLUS_PLUS_TOKEN     TOKEN_KIND = "++"
@@ -64,7 +71,17 @@ const (
 	TIMES_EQUALS_TOKEN  TOKEN_KIND = "*="
 	DIVIDE_EQUALS_TOKEN TOKEN_KIND = "/="
 	MODULO_EQUALS_TOKEN TOKEN_KIND = "%="
-	POWER_EQUALS_TOKEN  TOKEN_KIND = "^="
+	POWER_EQUALS_TOKEN  TOKEN_KIND = "**="
+
+	// assigns only if the variable is null
+	NULLISH_ASSIGNMENT_TOKEN TOKEN_KIND = "??="
+
+	// Bitwise assignment operators
+	BIT_AND_EQUALS_TOKEN     TOKEN_KIND = "&="
+	BIT_OR_EQUALS_TOKEN      TOKEN_KIND = "|="
+	XOR_EQUALS_TOKEN         TOKEN_KIND = "^="
+	SHIFT_LEFT_EQUALS_TOKEN  TOKEN_KIND = "<<="
+	SHIFT_RIGHT_EQUALS_TOKEN TOKEN_KIND = ">>="
 
 	// Binary operators
 	PLUS_TOKEN   TOKEN_KIND = "+"
@@ -72,7 +89,15 @@ const (
 	TIMES_TOKEN  TOKEN_KIND = "*"
 	DIVIDE_TOKEN TOKEN_KIND = "/"
 	MODULO_TOKEN TOKEN_KIND = "%"
-	POWER_TOKEN  TOKEN_KIND = "^"
+	POWER_TOKEN  TOKEN_KIND = "**"
+
+	// Bitwise operators
+	BIT_AND_TOKEN     TOKEN_KIND = "&"
+	BIT_OR_TOKEN      TOKEN_KIND = "|"
+	XOR_TOKEN         TOKEN_KIND = "^"
+	BIT_NOT_TOKEN     TOKEN_KIND = "~"
+	SHIFT_LEFT_TOKEN  TOKEN_KIND = "<<"
+	SHIFT_RIGHT_TOKEN TOKEN_KIND = ">>"
 
 	// Keywords
 	LET_TOKEN      TOKEN_KIND = "let"
@@ -90,6 +115,11 @@ const (
 	BREAK_TOKEN    TOKEN_KIND = "break"
 	CONTINUE_TOKEN TOKEN_KIND = "continue"
 
+	TRY_TOKEN     TOKEN_KIND = "try"
+	CATCH_TOKEN   TOKEN_KIND = "catch"
+	RECOVER_TOKEN TOKEN_KIND = "recover"
+	DEFER_TOKEN   TOKEN_KIND = "defer"
+
 	IF_TOKEN      TOKEN_KIND = "if"
 	ELSEIF_TOKEN  TOKEN_KIND = "elf"
 	ELSE_TOKEN    TOKEN_KIND = "els"
@@ -100,6 +130,7 @@ const (
 	EXPORT_TOKEN  TOKEN_KIND = "export"
 	TYPEOF_TOKEN  TOKEN_KIND = "typeof"
 	IN_TOKEN      TOKEN_KIND = "in"
+	AS_TOKEN      TOKEN_KIND = "as"
 
 	// Special constants
 	NULL_TOKEN  TOKEN_KIND = "null"
@@ -131,6 +162,10 @@ var reservedLookup map[string]TOKEN_KIND = map[string]TOKEN_KIND{
 	"default":  DEFAULT_TOKEN,
 	"break":    BREAK_TOKEN,
 	"continue": CONTINUE_TOKEN,
+	"try":      TRY_TOKEN,
+	"catch":    CATCH_TOKEN,
+	"recover":  RECOVER_TOKEN,
+	"defer":    DEFER_TOKEN,
 	"if":       IF_TOKEN,
 	"elf":      ELSEIF_TOKEN,
 	"els":      ELSE_TOKEN,
@@ -141,6 +176,7 @@ var reservedLookup map[string]TOKEN_KIND = map[string]TOKEN_KIND{
 	"export":   EXPORT_TOKEN,
 	"typeof":   TYPEOF_TOKEN,
 	"in":       IN_TOKEN,
+	"as":       AS_TOKEN,
 	"null":     NULL_TOKEN,
 	"true":     TRUE_TOKEN,
 	"false":    FALSE_TOKEN,
@@ -167,7 +203,7 @@ func IsNumber(tokenKind TOKEN_KIND) bool {
 }
 
 func IsBuiltInType(tokenKind TOKEN_KIND) bool {
-	regexp := regexp.MustCompile(`i8|i16|i32|i64|i128|f32|f64|bool|str|chr`)
+	regexp := regexp.MustCompile(`i8|i16|i32|i64|i128|u8|u16|u32|u64|u128|bigint|f32|f64|bool|str|chr|error`)
 	return regexp.MatchString(string(tokenKind))
 }
 
@@ -4,12 +4,16 @@ import (
 	"fmt"
 	//"os"
 	"strings"
+	"unicode/utf8"
 	"walrus/frontend/lexer"
 	"walrus/utils"
 )
 
 type htype string
 
+// HintKind is the kind of a hint, TEXT_HINT or CODE_HINT
+type HintKind = htype
+
 const (
 	TEXT_HINT htype = "text_hint"
 	CODE_HINT htype = "code_hint"
@@ -34,22 +38,26 @@ func (e *ErrorMessage) AddHint(htext string, htype htype) *ErrorMessage {
 }
 
 func (e *ErrorMessage) Display() {
-	fmt.Print(e.Message)
+	fmt.Println(e.String())
+	panic("Error")
+	//os.Exit(1)
+}
+
+// String returns the message with its hints, as Display prints it
+func (e *ErrorMessage) String() string {
+	text := e.Message
 	// hints
 	for i, hint := range e.hints {
 		if i == 0 {
-			fmt.Print(utils.Colorize(utils.ORANGE, "Hint: "))
+			text += utils.Colorize(utils.ORANGE, "Hint: ")
 		}
-		//fmt.Print(utils.Colorize( utils.ORANGE, (fmt.Sprintf("Hint: %s\n", hint.HText))))
 		if hint.HType == TEXT_HINT {
-			fmt.Print(utils.Colorize(utils.ORANGE, hint.HText))
+			text += utils.Colorize(utils.ORANGE, hint.HText)
 		} else {
-			fmt.Print(lexer.Highlight(hint.HText))
+			text += lexer.Highlight(hint.HText)
 		}
 	}
-	fmt.Println("")
-	panic("Error")
-	//os.Exit(1)
+	return text
 }
 
 func makePadding(width, line int) string {
@@ -58,6 +66,27 @@ func makePadding(width, line int) string {
 
 func MakeError(p *Parser, lineNo int, filePath string, startPos lexer.Position, endPos lexer.Position, errMsg string) *ErrorMessage {
 
+	errStr := sourceSnippet(p, lineNo, filePath, startPos, endPos)
+	errStr += fmt.Sprint(utils.Colorize(utils.RED, fmt.Sprintf("Error: %s\n", errMsg)))
+
+	return &ErrorMessage{
+		Message: errStr,
+	}
+}
+
+// MakeTrace shows a call site of a stack trace like MakeError shows an error
+func MakeTrace(p *Parser, startPos lexer.Position, endPos lexer.Position, note string) string {
+	// a call written on several lines is marked up to the end of its first line
+	if endPos.Line != startPos.Line {
+		endPos = startPos
+		endPos.Column = utf8.RuneCountInString((*p.Lines)[startPos.Line-1]) + 1
+	}
+	return sourceSnippet(p, startPos.Line, p.FilePath, startPos, endPos) + utils.Colorize(utils.GREY, note) + "\n"
+}
+
+// sourceSnippet returns the source line of the position and the line before it, with ~~~~~ under the range
+func sourceSnippet(p *Parser, lineNo int, filePath string, startPos lexer.Position, endPos lexer.Position) string {
+
 	// decorate the error with ~~~~~ under the error line
 
 	var errStr string
@@ -68,7 +97,8 @@ func MakeError(p *Parser, lineNo int, filePath string, startPos lexer.Position,
 
 	if lineNo-1 > 0 {
 		// add the padding to each line
-		prvLines = (*p.Lines)[lineNo-2 : lineNo-1]
+		// copied, so the lines of the parser are not changed
+		prvLines = append(prvLines, (*p.Lines)[lineNo-2:lineNo-1]...)
 
 		for i, l := range prvLines {
 			prvLines[i] = utils.Colorize(utils.GREY, makePadding(maxWidth, lineNo-1+i) + lexer.Highlight(l))
@@ -80,12 +110,21 @@ func MakeError(p *Parser, lineNo int, filePath string, startPos lexer.Position,
 	padding := makePadding(maxWidth, startPos.Line)
 
 	errStr += strings.Join(prvLines, "\n") + "\n"
-	errStr += utils.Colorize(utils.GREY, padding) + lexer.Highlight(line[0:startPos.Column-1]) + utils.Colorize(utils.RED, line[startPos.Column-1 : endPos.Column-1]) + lexer.Highlight(line[endPos.Column-1 : ]) + "\n"
+	startByte, endByte := columnOffset(line, startPos.Column), columnOffset(line, endPos.Column)
+	errStr += utils.Colorize(utils.GREY, padding) + lexer.Highlight(line[0:startByte]) + utils.Colorize(utils.RED, line[startByte:endByte]) + lexer.Highlight(line[endByte:]) + "\n"
 	errStr += strings.Repeat(" ", (startPos.Column-1)+len(padding))
 	errStr += fmt.Sprint(utils.Colorize(utils.BOLD_RED, fmt.Sprintf("%s%s\n", "^", strings.Repeat("~", ((endPos.Column - 1) - (startPos.Column - 1))))))
-	errStr += fmt.Sprint(utils.Colorize(utils.RED, fmt.Sprintf("Error: %s\n", errMsg)))
 
-	return &ErrorMessage{
-		Message: errStr,
+	return errStr
+}
+
+// columnOffset returns the byte offset of a column in a line. Columns count characters, so they differ
+// from byte offsets after non-ASCII text
+func columnOffset(line string, column int) int {
+	offset := 0
+	for i := 1; i < column && offset < len(line); i++ {
+		_, size := utf8.DecodeRuneInString(line[offset:])
+		offset += size
 	}
+	return offset
 }
@@ -2,7 +2,6 @@ package parser
 
 import (
 	"fmt"
-	"strconv"
 	"walrus/frontend/ast"
 	"walrus/frontend/lexer"
 	"walrus/helpers"
@@ -34,12 +33,155 @@ func parseBinaryExpr(p *Parser, left ast.Expression, bp BINDING_POWER) ast.Expre
 	}
 }
 
+// parseRightAssociativeExpr parses the right-hand side with a lower binding power than its own,
+// so a following ** is part of the right-hand side.
+func parseRightAssociativeExpr(p *Parser, left ast.Expression, bp BINDING_POWER) ast.Expression {
+	return parseBinaryExpr(p, left, bp-1)
+}
+
+// parseRangeExpr parses start..end and start..=end, with an optional step clause: 0..10 step 2.
+// step is not a keyword, so it can still be used as a name
+func parseRangeExpr(p *Parser, left ast.Expression, bp BINDING_POWER) ast.Expression {
+
+	start, _ := left.GetPos()
+
+	inclusive := p.advance().Kind == lexer.DOT_DOT_EQUALS_TOKEN
+
+	end := parseExpr(p, bp)
+
+	_, endPos := end.GetPos()
+
+	var step ast.Expression
+
+	if p.currentTokenKind() == lexer.IDENTIFIER_TOKEN && p.currentToken().Value == "step" {
+		p.advance()
+		step = parseExpr(p, bp)
+		_, endPos = step.GetPos()
+	}
+
+	return ast.RangeExpr{
+		BaseStmt: ast.BaseStmt{
+			Kind:     ast.RANGE_EXPRESSION,
+			StartPos: start,
+			EndPos:   endPos,
+		},
+		Start:     left,
+		End:       end,
+		Inclusive: inclusive,
+		Step:      step,
+	}
+}
+
+// parseTernaryExpr parses condition ? consequent : alternate. The alternate is parsed with a lower
+// binding power so a ? b : c ? d : e groups as a ? b : (c ? d : e)
+func parseTernaryExpr(p *Parser, left ast.Expression, bp BINDING_POWER) ast.Expression {
+
+	start, _ := left.GetPos()
+
+	p.expect(lexer.QUESTION_TOKEN)
+
+	consequent := parseExpr(p, ASSIGNMENT)
+
+	p.expectError(lexer.COLON_TOKEN, "expected ':' after the first branch of the conditional expression")
+
+	alternate := parseExpr(p, bp-1)
+
+	_, end := alternate.GetPos()
+
+	return ast.TernaryExpr{
+		BaseStmt: ast.BaseStmt{
+			Kind:     ast.TERNARY_EXPRESSION,
+			StartPos: start,
+			EndPos:   end,
+		},
+		Condition:  left,
+		Consequent: consequent,
+		Alternate:  alternate,
+	}
+}
+
+// parseTryExpr parses try expr. It binds like a unary operator, so try a.read() + 1 is (try a.read()) + 1
+func parseTryExpr(p *Parser) ast.Expression {
+
+	start := p.advance().StartPos
+
+	expr := parseExpr(p, UNARY)
+
+	_, end := expr.GetPos()
+
+	return ast.TryExpr{
+		BaseStmt: ast.BaseStmt{
+			Kind:     ast.TRY_EXPRESSION,
+			StartPos: start,
+			EndPos:   end,
+		},
+		Expression: expr,
+	}
+}
+
+// parseTypeofExpr parses typeof(expr)
+func parseTypeofExpr(p *Parser) ast.Expression {
+
+	start := p.advance().StartPos
+
+	p.expect(lexer.OPEN_PAREN_TOKEN)
+
+	expr := parseExpr(p, DEFAULT_BP)
+
+	end := p.expect(lexer.CLOSE_PAREN_TOKEN).EndPos
+
+	return ast.TypeofExpr{
+		BaseStmt: ast.BaseStmt{
+			Kind:     ast.TYPEOF_EXPRESSION,
+			StartPos: start,
+			EndPos:   end,
+		},
+		Expression: expr,
+	}
+}
+
+// parseCatchExpr parses expr catch fallback and expr catch |e| fallback
+func parseCatchExpr(p *Parser, left ast.Expression, bp BINDING_POWER) ast.Expression {
+
+	start, _ := left.GetPos()
+
+	p.expect(lexer.CATCH_TOKEN)
+
+	errorName := ""
+
+	if p.currentTokenKind() == lexer.BIT_OR_TOKEN {
+		p.advance()
+		errorName = p.expectError(lexer.IDENTIFIER_TOKEN, "expected the name of the error after '|'").Value
+		p.expectError(lexer.BIT_OR_TOKEN, "expected '|' after the name of the error")
+	}
+
+	fallback := parseExpr(p, bp-1)
+
+	_, end := fallback.GetPos()
+
+	return ast.CatchExpr{
+		BaseStmt: ast.BaseStmt{
+			Kind:     ast.CATCH_EXPRESSION,
+			StartPos: start,
+			EndPos:   end,
+		},
+		Expression: left,
+		ErrorName:  errorName,
+		Fallback:   fallback,
+	}
+}
+
 // parses a function call expression, including the function name and its arguments.
 // It expects the current token to be an opening parenthesis, and it will parse the arguments
 // until it encounters a closing parenthesis. The function returns an ast.FunctionCallExpr
 // representing the parsed function call.
 func parseCallExpr(p *Parser, left ast.Expression, bp BINDING_POWER) ast.Expression {
 
+	// object.method(args)
+	if property, isProperty := left.(ast.StructPropertyExpr); isProperty {
+		return parseMethodCallExpr(p, property)
+	}
+
 	//try to convert the left expression to a function
 
 	if left.INodeType() != ast.IDENTIFIER {
@@ -49,6 +191,42 @@ func parseCallExpr(p *Parser, left ast.Expression, bp BINDING_POWER) ast.Express
 
 	start := p.currentToken().StartPos
 
+	arguments, end := parseCallArguments(p)
+
+	return ast.FunctionCallExpr{
+		BaseStmt: ast.BaseStmt{
+			Kind:     ast.FUNCTION_CALL_EXPRESSION,
+			StartPos: start,
+			EndPos:   end,
+		},
+		Caller: left.(ast.IdentifierExpr),
+		Args:   arguments,
+	}
+}
+
+// parseMethodCallExpr turns object.method followed by the arguments into a method call
+func parseMethodCallExpr(p *Parser, property ast.StructPropertyExpr) ast.Expression {
+
+	start, _ := property.Object.GetPos()
+
+	arguments, end := parseCallArguments(p)
+
+	return ast.MethodCallExpr{
+		BaseStmt: ast.BaseStmt{
+			Kind:     ast.METHOD_CALL_EXPRESSION,
+			StartPos: start,
+			EndPos:   end,
+		},
+		Object:   property.Object,
+		Method:   property.Property,
+		Args:     arguments,
+		Optional: property.Optional,
+	}
+}
+
+// parseCallArguments parses (arg, ...) and returns the arguments and the end of the closing parenthesis
+func parseCallArguments(p *Parser) ([]ast.Expression, lexer.Position) {
+
 	p.expect(lexer.OPEN_PAREN_TOKEN)
 
 	var arguments []ast.Expression
@@ -65,20 +243,13 @@ func parseCallExpr(p *Parser, left ast.Expression, bp BINDING_POWER) ast.Express
 
 	end := p.expect(lexer.CLOSE_PAREN_TOKEN).EndPos
 
-	return ast.FunctionCallExpr{
-		BaseStmt: ast.BaseStmt{
-			Kind:     ast.FUNCTION_CALL_EXPRESSION,
-			StartPos: start,
-			EndPos:   end,
-		},
-		Caller: left.(ast.IdentifierExpr),
-		Args:   arguments,
-	}
+	return arguments, end
 }
 
 func parsePropertyExpr(p *Parser, left ast.Expression, bp BINDING_POWER) ast.Expression {
 
-	p.expect(lexer.DOT_TOKEN)
+	// . or ?.
+	operator := p.advance()
 
 	identifier := p.expect(lexer.IDENTIFIER_TOKEN)
 
@@ -101,6 +272,30 @@ func parsePropertyExpr(p *Parser, left ast.Expression, bp BINDING_POWER) ast.Exp
 		},
 		Object:   left,
 		Property: property,
+		Optional: operator.Kind == lexer.OPTIONAL_CHAIN_TOKEN,
+	}
+}
+
+// parseTypeCastExpr parses an explicit conversion of the left-hand side expression
+// to the type following the 'as' keyword, like x as u8.
+func parseTypeCastExpr(p *Parser, left ast.Expression, bp BINDING_POWER) ast.Expression {
+
+	start, _ := left.GetPos()
+
+	p.expect(lexer.AS_TOKEN)
+
+	targetType := parseType(p, DEFAULT_BP)
+
+	end := p.previousToken().EndPos
+
+	return ast.TypeCastExpr{
+		BaseStmt: ast.BaseStmt{
+			Kind:     ast.TYPE_CAST_EXPRESSION,
+			StartPos: start,
+			EndPos:   end,
+		},
+		Expression: left,
+		TargetType: targetType,
 	}
 }
 
@@ -122,6 +317,10 @@ func parseExpr(p *Parser, bp BINDING_POWER) ast.Expression {
 		return parseStructInstantiationExpr(p, parsePrimaryExpr(p))
 	}
 
+	if tokenKind == lexer.IDENTIFIER_TOKEN && (token.Value == "map" || token.Value == "set") && p.tokens[tokenPos+1].Kind == lexer.OPEN_BRACKET_TOKEN {
+		return parseCollectionLiteral(p)
+	}
+
 	nudFunction, exists := nudLookup[tokenKind]
 
 	if !exists {
@@ -166,71 +365,8 @@ func parsePrimaryExpr(p *Parser) ast.Expression {
 	endpos := p.currentToken().EndPos
 
 	switch p.currentTokenKind() {
-	case lexer.INTEGER_TOKEN:
-
-		// A 32-bit integer can store upto -2,147,483,648 to 2,147,483,647
-		// it is nearly 10 digits long
-		// the raw value is stored as a string
-		// so we need to convert it to a number along with the proper size from the string length and the value
-
-		rawValue := p.advance().Value
-
-		size := uint8(32)
-
-		if len(rawValue) > 10 {
-			size = 64
-		} else {
-			// if number is out of range for 32-bit integer
-			// then it is a 64-bit integer
-			// But to avoid checking both positive and negative ranges, we just check the positive range by using the absolute value
-			// if the absolute value is greater than 2,147,483,647 then it is a 64-bit integer
-			number, _ := strconv.ParseInt(rawValue, 10, 32)
-			if number < 0 {
-				number = -number
-			}
-			if number > 2147483647 {
-				size = 64
-			}
-		}
-
-		return ast.NumericLiteral{
-			BaseStmt: ast.BaseStmt{
-				Kind:     ast.INTEGER_LITERAL,
-				StartPos: startpos,
-				EndPos:   endpos,
-			},
-			Value:   rawValue,
-			BitSize: size,
-		}
-	case lexer.FLOATING_TOKEN:
-		rawValue := p.advance().Value
-
-		size := uint8(32)
-
-		number, _ := strconv.ParseFloat(rawValue, 64)
-
-		if number < 0 {
-			number = -number
-		}
-
-		// check the floating point decimal size
-
-		decimal := int64(number)
-
-		//max size of a 32-bit floating point number is 7 digits
-		if decimal > 9999999 {
-			size = 64
-		}
-
-		return ast.NumericLiteral{
-			BaseStmt: ast.BaseStmt{
-				Kind:     ast.FLOAT_LITERAL,
-				StartPos: startpos,
-				EndPos:   endpos,
-			},
-			Value:   rawValue,
-			BitSize: size,
-		}
+	case lexer.INTEGER_TOKEN, lexer.FLOATING_TOKEN:
+		return parseNumericLiteral(p)
 
 	case lexer.STRING_TOKEN:
 		return ast.StringLiteral{
@@ -298,13 +434,44 @@ func parsePrimaryExpr(p *Parser) ast.Expression {
 // parseGroupingExpr parses a grouping expression, which is an expression
 // enclosed in parentheses. It expects the opening parenthesis, parses the
 // expression inside, and then expects the closing parenthesis.
+// parseGroupingExpr parses (expr), or the tuple (a, b, ...) if there is a comma
 func parseGroupingExpr(p *Parser) ast.Expression {
-	p.expect(lexer.OPEN_PAREN_TOKEN)
+	start := p.expect(lexer.OPEN_PAREN_TOKEN).StartPos
 	expression := parseExpr(p, DEFAULT_BP)
+
+	if p.currentTokenKind() == lexer.COMMA_TOKEN {
+		tuple := parseTupleElements(p, expression)
+		tuple.StartPos = start
+		tuple.EndPos = p.expect(lexer.CLOSE_PAREN_TOKEN).EndPos
+		return tuple
+	}
+
 	p.expect(lexer.CLOSE_PAREN_TOKEN)
 	return expression
 }
 
+// parseTupleElements parses the elements after the first one, each preceded by a comma
+func parseTupleElements(p *Parser, first ast.Expression) ast.TupleExpr {
+	elements := []ast.Expression{first}
+
+	for p.currentTokenKind() == lexer.COMMA_TOKEN {
+		p.advance()
+		elements = append(elements, parseExpr(p, DEFAULT_BP))
+	}
+
+	start, _ := first.GetPos()
+	_, end := elements[len(elements)-1].GetPos()
+
+	return ast.TupleExpr{
+		BaseStmt: ast.BaseStmt{
+			Kind:     ast.TUPLE_EXPRESSION,
+			StartPos: start,
+			EndPos:   end,
+		},
+		Elements: elements,
+	}
+}
+
 // parsePrefixExpr parses a prefix expression, which consists of a unary operator
 // followed by an expression. It returns an ast.UnaryExpr representing the parsed
 // prefix expression.
@@ -332,7 +499,63 @@ func parsePrefixExpr(p *Parser) ast.Expression {
 // parseUnaryExpr parses a unary expression from the input stream.
 // It returns the parsed expression as an ast.Expression.
 func parseUnaryExpr(p *Parser) ast.Expression {
-	return parsePrefixExpr(p)
+	expr := parsePrefixExpr(p).(ast.UnaryExpr)
+	if expr.Operator.Kind == lexer.PLUS_PLUS_TOKEN || expr.Operator.Kind == lexer.MINUS_MINUS_TOKEN {
+		expectAssignable(p, expr.Argument, expr.Operator)
+	}
+	return expr
+}
+
+// parsePostfixExpr parses x++ and x--
+func parsePostfixExpr(p *Parser, left ast.Expression, bp BINDING_POWER) ast.Expression {
+
+	start, _ := left.GetPos()
+
+	operator := p.advance()
+
+	expectAssignable(p, left, operator)
+
+	return ast.PostfixExpr{
+		BaseStmt: ast.BaseStmt{
+			Kind:     ast.POSTFIX_EXPRESSION,
+			StartPos: start,
+			EndPos:   operator.EndPos,
+		},
+		Operator: operator,
+		Argument: left,
+	}
+}
+
+// expectAssignable reports an error if ++ or -- is applied to something that cannot be assigned
+func expectAssignable(p *Parser, expr ast.Expression, operator lexer.Token) {
+	switch expr.(type) {
+	case ast.IdentifierExpr, ast.StructPropertyExpr, ast.ArrayIndexAccess:
+		return
+	}
+	start, end := expr.GetPos()
+	MakeError(p, start.Line, p.FilePath, start, end, fmt.Sprintf("operator %s needs a variable, a struct field or an array element", operator.Value)).Display()
+}
+
+// parseArrayAccessExpr parses arr[index]
+func parseArrayAccessExpr(p *Parser, left ast.Expression, bp BINDING_POWER) ast.Expression {
+
+	start, _ := left.GetPos()
+
+	p.expect(lexer.OPEN_BRACKET_TOKEN)
+
+	index := parseExpr(p, DEFAULT_BP)
+
+	end := p.expect(lexer.CLOSE_BRACKET_TOKEN).EndPos
+
+	return ast.ArrayIndexAccess{
+		BaseStmt: ast.BaseStmt{
+			Kind:     ast.ARRAY_INDEX_ACCESS,
+			StartPos: start,
+			EndPos:   end,
+		},
+		Array: left,
+		Index: index,
+	}
 }
 
 // parseVarAssignmentExpr parses a variable assignment expression. It takes a Parser, a left-hand side expression, and a binding power.
@@ -347,7 +570,7 @@ func parseVarAssignmentExpr(p *Parser, left ast.Expression, bp BINDING_POWER) as
 
 	switch assignee := left.(type) {
 
-	case ast.IdentifierExpr, ast.StructPropertyExpr:
+	case ast.IdentifierExpr, ast.StructPropertyExpr, ast.ArrayIndexAccess:
 		identifier = assignee
 	default:
 		errMsg := "Cannot assign to a non-identifier\n"
@@ -356,7 +579,8 @@ func parseVarAssignmentExpr(p *Parser, left ast.Expression, bp BINDING_POWER) as
 
 	operator := p.advance()
 
-	right := parseExpr(p, bp)
+	// assignments are right associative, a = b = 5 assigns 5 to b and then to a
+	right := parseExpr(p, bp-1)
 
 	_, end := right.GetPos()
 
@@ -411,6 +635,58 @@ func parseStructInstantiationExpr(p *Parser, left ast.Expression) ast.Expression
 	}
 }
 
+// parseCollectionLiteral parses map[K]V{ key: value, ... } and set[T]{ element, ... }
+func parseCollectionLiteral(p *Parser) ast.Expression {
+
+	start := p.currentToken().StartPos
+
+	collectionType := parseType(p, DEFAULT_BP)
+
+	p.expect(lexer.OPEN_CURLY_TOKEN)
+
+	mapType, isMap := collectionType.(ast.MapType)
+
+	var keys, values []ast.Expression
+
+	for p.hasTokens() && p.currentTokenKind() != lexer.CLOSE_CURLY_TOKEN {
+		keys = append(keys, parseExpr(p, DEFAULT_BP))
+
+		if isMap {
+			p.expect(lexer.COLON_TOKEN)
+			values = append(values, parseExpr(p, DEFAULT_BP))
+		}
+
+		if p.currentTokenKind() != lexer.CLOSE_CURLY_TOKEN {
+			p.expect(lexer.COMMA_TOKEN)
+		}
+	}
+
+	end := p.expect(lexer.CLOSE_CURLY_TOKEN).EndPos
+
+	if isMap {
+		return ast.MapLiteral{
+			BaseStmt: ast.BaseStmt{
+				Kind:     ast.MAP_LITERAL,
+				StartPos: start,
+				EndPos:   end,
+			},
+			Type:   mapType,
+			Keys:   keys,
+			Values: values,
+		}
+	}
+
+	return ast.SetLiteral{
+		BaseStmt: ast.BaseStmt{
+			Kind:     ast.SET_LITERAL,
+			StartPos: start,
+			EndPos:   end,
+		},
+		Type:     collectionType.(ast.SetType),
+		Elements: keys,
+	}
+}
+
 // parseArrayExpr parses an array expression in the input stream.
 // It expects the opening '[' bracket, parses the array elements,
 // and returns an ast.ArrayLiterals INode representing the array.
@@ -423,7 +699,7 @@ func parseArrayExpr(p *Parser) ast.Expression {
 	elements := []ast.Expression{}
 
 	for p.hasTokens() && p.currentTokenKind() != lexer.CLOSE_BRACKET_TOKEN {
-		elements = append(elements, parseExpr(p, PRIMARY))
+		elements = append(elements, parseExpr(p, DEFAULT_BP))
 		if p.currentTokenKind() != lexer.CLOSE_BRACKET_TOKEN {
 			p.expect(lexer.COMMA_TOKEN)
 		}
@@ -0,0 +1,162 @@
+package parser
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"walrus/frontend/ast"
+	"walrus/frontend/lexer"
+	"walrus/utils"
+)
+
+var literalSuffixes = []string{"i128", "i16", "i32", "i64", "i8", "u128", "u16", "u32", "u64", "u8", "f32", "f64"}
+
+// parseNumericLiteral reads an integer or float token like 0xFF, 0o755, 0b1010, 1_000_000, 1.5e-3, 255u8 or 3.0f64.
+// Unsuffixed integers get the smallest of i32, i64 and i128 that holds them, or bigint.
+// Unsuffixed floats are f64, a constant is converted to f32 only if it keeps its value.
+func parseNumericLiteral(p *Parser) ast.NumericLiteral {
+
+	token := p.advance()
+
+	literal := ast.NumericLiteral{
+		BaseStmt: ast.BaseStmt{
+			Kind:     ast.INTEGER_LITERAL,
+			StartPos: token.StartPos,
+			EndPos:   token.EndPos,
+		},
+		IsSigned: true,
+	}
+
+	raw := token.Value
+
+	for _, suffix := range literalSuffixes {
+		// hexadecimal digits can end with f, so f32 and f64 are never suffixes of them
+		if strings.HasSuffix(raw, suffix) && !(suffix[0] == 'f' && strings.HasPrefix(strings.ToLower(raw), "0x")) {
+			literal.Suffix = suffix
+			raw = strings.TrimSuffix(raw, suffix)
+			break
+		}
+	}
+
+	if !validSeparators(raw, strings.HasPrefix(strings.ToLower(raw), "0x")) {
+		MakeError(p, token.StartPos.Line, p.FilePath, token.StartPos, token.EndPos, fmt.Sprintf("invalid numeric literal %s", token.Value)).AddHint("'_' can only be used between digits. e.g. ", TEXT_HINT).AddHint("1_000_000", CODE_HINT).Display()
+	}
+
+	raw = strings.ReplaceAll(raw, "_", "")
+
+	if token.Kind == lexer.FLOATING_TOKEN {
+		return parseFloatLiteral(p, literal, raw, token)
+	}
+
+	return parseIntegerLiteral(p, literal, raw, token)
+}
+
+func parseIntegerLiteral(p *Parser, literal ast.NumericLiteral, raw string, token lexer.Token) ast.NumericLiteral {
+
+	base := 10
+
+	if len(raw) > 2 {
+		switch strings.ToLower(raw[:2]) {
+		case "0x":
+			base = 16
+		case "0o":
+			base = 8
+		case "0b":
+			base = 2
+		}
+	}
+
+	if base != 10 {
+		raw = raw[2:]
+	}
+
+	number, ok := new(big.Int).SetString(raw, base)
+
+	if !ok {
+		MakeError(p, token.StartPos.Line, p.FilePath, token.StartPos, token.EndPos, fmt.Sprintf("invalid numeric literal %s", token.Value)).Display()
+	}
+
+	literal.Value = number.String()
+
+	if literal.Suffix != "" {
+		literal.BitSize = utils.BitSizeFromString(literal.Suffix)
+		literal.IsSigned = literal.Suffix[0] == 'i'
+
+		min, max := integerRange(literal.BitSize, literal.IsSigned)
+		// a minus sign in front of the literal is a separate operator, so the smallest signed value can be written
+		if number.Cmp(max) > 0 && !(literal.IsSigned && number.Cmp(new(big.Int).Neg(min)) == 0) {
+			MakeError(p, token.StartPos.Line, p.FilePath, token.StartPos, token.EndPos, fmt.Sprintf("integer literal %s is out of range for %s. the range of %s is %s to %s", token.Value, literal.Suffix, literal.Suffix, min, max)).Display()
+		}
+
+		return literal
+	}
+
+	// the literal gets the smallest of i32, i64 and i128 that holds it. larger literals are bigint
+	for _, bits := range []uint{32, 64, 128} {
+		if number.BitLen() < int(bits) {
+			literal.BitSize = uint8(bits)
+			break
+		}
+	}
+
+	return literal
+}
+
+func parseFloatLiteral(p *Parser, literal ast.NumericLiteral, raw string, token lexer.Token) ast.NumericLiteral {
+
+	literal.Kind = ast.FLOAT_LITERAL
+
+	number, err := strconv.ParseFloat(raw, 64)
+
+	if err != nil {
+		MakeError(p, token.StartPos.Line, p.FilePath, token.StartPos, token.EndPos, fmt.Sprintf("float literal %s is out of range for f64", token.Value)).Display()
+	}
+
+	literal.Value = raw
+
+	switch literal.Suffix {
+	case "f32":
+		literal.BitSize = 32
+	case "f64", "":
+		literal.BitSize = 64
+	default:
+		MakeError(p, token.StartPos.Line, p.FilePath, token.StartPos, token.EndPos, fmt.Sprintf("float literal %s cannot have the integer suffix %s", token.Value, literal.Suffix)).AddHint("use f32 or f64", TEXT_HINT).Display()
+	}
+
+	if literal.BitSize == 32 && math.Abs(number) > math.MaxFloat32 {
+		MakeError(p, token.StartPos.Line, p.FilePath, token.StartPos, token.EndPos, fmt.Sprintf("float literal %s is out of range for f32. the largest f32 is %g", token.Value, math.MaxFloat32)).Display()
+	}
+
+	return literal
+}
+
+// validSeparators reports if every '_' of a literal is between two digits
+func validSeparators(raw string, hex bool) bool {
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '_' {
+			continue
+		}
+		if i == 0 || i == len(raw)-1 || !isDigit(raw[i-1], hex) || !isDigit(raw[i+1], hex) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(char byte, hex bool) bool {
+	if hex && ((char >= 'a' && char <= 'f') || (char >= 'A' && char <= 'F')) {
+		return true
+	}
+	return char >= '0' && char <= '9'
+}
+
+func integerRange(size uint8, signed bool) (*big.Int, *big.Int) {
+	if signed {
+		max := new(big.Int).Lsh(big.NewInt(1), uint(size-1))
+		return new(big.Int).Neg(max), max.Sub(max, big.NewInt(1))
+	}
+	max := new(big.Int).Lsh(big.NewInt(1), uint(size))
+	return big.NewInt(0), max.Sub(max, big.NewInt(1))
+}
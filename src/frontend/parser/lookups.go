@@ -12,10 +12,19 @@ const (
 	DEFAULT_BP BINDING_POWER = iota
 	COMMA
 	ASSIGNMENT
+	TERNARY
+	NULLISH
 	LOGICAL
+	LOGICAL_AND
+	BITWISE_OR
+	BITWISE_XOR
+	BITWISE_AND
 	RELATIONAL
+	SHIFT
 	ADDITIVE
 	MULTIPLICATIVE
+	CAST
+	POWER
 	UNARY
 	CALL
 	MEMBER
@@ -83,6 +92,7 @@ func createTokenLookups() {
 	nud(lexer.PLUS_PLUS_TOKEN, parseUnaryExpr)
 	nud(lexer.MINUS_MINUS_TOKEN, parseUnaryExpr)
 	nud(lexer.NOT_TOKEN, parseUnaryExpr)
+	nud(lexer.BIT_NOT_TOKEN, parseUnaryExpr)
 	nud(lexer.OPEN_BRACKET_TOKEN, parseArrayExpr)
 
 	// Assignment
@@ -92,16 +102,48 @@ func createTokenLookups() {
 	led(lexer.TIMES_EQUALS_TOKEN, ASSIGNMENT, parseVarAssignmentExpr)
 	led(lexer.DIVIDE_EQUALS_TOKEN, ASSIGNMENT, parseVarAssignmentExpr)
 	led(lexer.MODULO_EQUALS_TOKEN, ASSIGNMENT, parseVarAssignmentExpr)
+	led(lexer.POWER_EQUALS_TOKEN, ASSIGNMENT, parseVarAssignmentExpr)
+	led(lexer.BIT_AND_EQUALS_TOKEN, ASSIGNMENT, parseVarAssignmentExpr)
+	led(lexer.BIT_OR_EQUALS_TOKEN, ASSIGNMENT, parseVarAssignmentExpr)
+	led(lexer.XOR_EQUALS_TOKEN, ASSIGNMENT, parseVarAssignmentExpr)
+	led(lexer.SHIFT_LEFT_EQUALS_TOKEN, ASSIGNMENT, parseVarAssignmentExpr)
+	led(lexer.SHIFT_RIGHT_EQUALS_TOKEN, ASSIGNMENT, parseVarAssignmentExpr)
+	led(lexer.NULLISH_ASSIGNMENT_TOKEN, ASSIGNMENT, parseVarAssignmentExpr)
+
+	// Conditional. Both are right associative
+	led(lexer.QUESTION_TOKEN, TERNARY, parseTernaryExpr)
+	led(lexer.NULLISH_TOKEN, NULLISH, parseRightAssociativeExpr)
+
+	// Errors. catch gives a fallback like ??
+	nud(lexer.TRY_TOKEN, parseTryExpr)
+	led(lexer.CATCH_TOKEN, NULLISH, parseCatchExpr)
+
+	// Reflection
+	nud(lexer.TYPEOF_TOKEN, parseTypeofExpr)
 
 	// Logical operations
-	led(lexer.AND_TOKEN, LOGICAL, parseBinaryExpr)
+	led(lexer.AND_TOKEN, LOGICAL_AND, parseBinaryExpr)
 	led(lexer.OR_TOKEN, LOGICAL, parseBinaryExpr)
 
+	// Bitwise
+	led(lexer.BIT_OR_TOKEN, BITWISE_OR, parseBinaryExpr)
+	led(lexer.XOR_TOKEN, BITWISE_XOR, parseBinaryExpr)
+	led(lexer.BIT_AND_TOKEN, BITWISE_AND, parseBinaryExpr)
+	led(lexer.SHIFT_LEFT_TOKEN, SHIFT, parseBinaryExpr)
+	led(lexer.SHIFT_RIGHT_TOKEN, SHIFT, parseBinaryExpr)
+
 	// Range
-	led(lexer.DOT_DOT_TOKEN, LOGICAL, parseBinaryExpr)
+	led(lexer.DOT_DOT_TOKEN, LOGICAL, parseRangeExpr)
+	led(lexer.DOT_DOT_EQUALS_TOKEN, LOGICAL, parseRangeExpr)
 
 	// Member
 	led(lexer.DOT_TOKEN, MEMBER, parsePropertyExpr)
+	led(lexer.OPTIONAL_CHAIN_TOKEN, MEMBER, parsePropertyExpr)
+	led(lexer.OPEN_BRACKET_TOKEN, MEMBER, parseArrayAccessExpr)
+
+	// Postfix
+	led(lexer.PLUS_PLUS_TOKEN, CALL, parsePostfixExpr)
+	led(lexer.MINUS_MINUS_TOKEN, CALL, parsePostfixExpr)
 
 	// Relational
 	led(lexer.LESS_TOKEN, RELATIONAL, parseBinaryExpr)
@@ -118,7 +160,12 @@ func createTokenLookups() {
 	led(lexer.TIMES_TOKEN, MULTIPLICATIVE, parseBinaryExpr)
 	led(lexer.DIVIDE_TOKEN, MULTIPLICATIVE, parseBinaryExpr)
 	led(lexer.MODULO_TOKEN, MULTIPLICATIVE, parseBinaryExpr)
-	led(lexer.POWER_TOKEN, MULTIPLICATIVE, parseBinaryExpr)
+
+	// Power is right associative, 2 ** 3 ** 2 is 2 ** 9
+	led(lexer.POWER_TOKEN, POWER, parseRightAssociativeExpr)
+
+	// Type cast
+	led(lexer.AS_TOKEN, CAST, parseTypeCastExpr)
 
 	//call
 	led(lexer.OPEN_PAREN_TOKEN, CALL, parseCallExpr)
@@ -129,6 +176,8 @@ func createTokenLookups() {
 
 	stmt(lexer.MODULE_TOKEN, parseModuleStmt)
 	stmt(lexer.IMPORT_TOKEN, parseImportStmt)
+	stmt(lexer.EXPORT_TOKEN, parseExportStmt)
+	stmt(lexer.ACCESS_TOKEN, parseExportStmt)
 	stmt(lexer.STRUCT_TOKEN, parseStructDeclStmt)
 	stmt(lexer.TRAIT_TOKEN, parseTraitDeclStmt)
 	stmt(lexer.IMPLEMENT_TOKEN, parseImplementStmt)
@@ -148,4 +197,8 @@ func createTokenLookups() {
 
 	stmt(lexer.CONTINUE_TOKEN, parseContinueStmt)
 	stmt(lexer.BREAK_TOKEN, parseBreakStmt)
+
+	//errors
+	stmt(lexer.RECOVER_TOKEN, parseRecoverStmt)
+	stmt(lexer.DEFER_TOKEN, parseDeferStmt)
 }
\ No newline at end of file
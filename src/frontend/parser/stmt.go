@@ -10,6 +10,11 @@ import (
 
 func parseNode(p *Parser) ast.Node {
 
+	// a label before a loop. e.g. outer: foreach d in dirs { ... }
+	if p.currentTokenKind() == lexer.IDENTIFIER_TOKEN && p.nextToken().Kind == lexer.COLON_TOKEN {
+		return parseLabeledStmt(p)
+	}
+
 	// can be a statement or an expression
 	stmt_fn, exists := stmtLookup[p.currentTokenKind()]
 
@@ -87,6 +92,52 @@ func parseImportStmt(p *Parser) ast.Statement {
 	}
 }
 
+// parseExportStmt parses export in front of a top-level fn, struct, trait, let or const. pub is a synonym of export.
+// The declaration is returned with IsExported set and starts at the export keyword
+func parseExportStmt(p *Parser) ast.Statement {
+
+	keyword := p.advance()
+	start := keyword.StartPos
+
+	if keyword.Value == "priv" {
+		MakeError(p, keyword.StartPos.Line, p.FilePath, keyword.StartPos, keyword.EndPos, "priv cannot be used at the top level of a file").AddHint("top-level declarations are private unless they are marked with ", TEXT_HINT).AddHint("export", CODE_HINT).AddHint(" or ", TEXT_HINT).AddHint("pub", CODE_HINT).Display()
+	}
+
+	switch p.currentTokenKind() {
+	case lexer.FUNCTION_TOKEN:
+		decl := parseFunctionDeclStmt(p).(ast.FunctionDeclStmt)
+		decl.StartPos, decl.IsExported = start, true
+		return decl
+	case lexer.STRUCT_TOKEN:
+		decl := parseStructDeclStmt(p).(ast.StructDeclStatement)
+		decl.StartPos, decl.IsExported = start, true
+		return decl
+	case lexer.TRAIT_TOKEN:
+		decl := parseTraitDeclStmt(p).(ast.TraitDeclStatement)
+		decl.StartPos, decl.IsExported = start, true
+		return decl
+	case lexer.LET_TOKEN, lexer.CONST_TOKEN:
+		switch decl := parseVarDeclStmt(p).(type) {
+		case ast.VariableDclStml:
+			decl.StartPos, decl.IsExported = start, true
+			return decl
+		case ast.DestructuringDclStmt:
+			decl.StartPos, decl.IsExported = start, true
+			return decl
+		}
+	}
+
+	token := p.currentToken()
+
+	if token.Kind == lexer.IDENTIFIER_TOKEN && token.Value == "enum" {
+		MakeError(p, token.StartPos.Line, p.FilePath, token.StartPos, token.EndPos, "enums are not supported yet, so they cannot be exported").Display()
+	}
+
+	MakeError(p, token.StartPos.Line, p.FilePath, token.StartPos, token.EndPos, fmt.Sprintf("cannot export '%s'", token.Value)).AddHint(keyword.Value+" goes in front of a declaration, e.g. ", TEXT_HINT).AddHint(keyword.Value+" fn area(w: i32, h: i32) -> i32 { ... }", CODE_HINT).Display()
+
+	return nil
+}
+
 func parseVarDeclStmt(p *Parser) ast.Statement {
 
 	start := p.currentToken().StartPos
@@ -96,6 +147,10 @@ func parseVarDeclStmt(p *Parser) ast.Statement {
 
 	isConstant := p.advance().Kind == lexer.CONST_TOKEN
 
+	if p.currentTokenKind() == lexer.OPEN_PAREN_TOKEN || p.currentTokenKind() == lexer.OPEN_CURLY_TOKEN {
+		return parseDestructuringDclStmt(p, start, isConstant)
+	}
+
 	//varName := p.expectError(lexer.IDENTIFIER, "Expected identifier after " + (isConstant ? "const" : "let")  ).Value
 	errMsg := fmt.Sprintf("Expected identifier after %s", utils.IF(isConstant, "const", "let"))
 
@@ -128,6 +183,8 @@ func parseVarDeclStmt(p *Parser) ast.Statement {
 			p.advance()
 			assignedValue = parseExpr(p, DEFAULT_BP)
 		}
+	} else if p.currentTokenKind() == lexer.SEMI_COLON_TOKEN && !isConstant {
+		// let x; the type is inferred from later assignments by the typechecker
 	} else {
 		if p.currentTokenKind() == lexer.ASSIGNMENT_TOKEN {
 			MakeError(p, p.currentToken().StartPos.Line, p.FilePath, p.currentToken().StartPos, p.currentToken().EndPos, "Invalid token").AddHint("Use ':=' instead\n", TEXT_HINT).Display()
@@ -154,6 +211,70 @@ func parseVarDeclStmt(p *Parser) ast.Statement {
 	}
 }
 
+// parseDestructuringDclStmt parses let (a, b) := value; and let { name, score } := value;
+func parseDestructuringDclStmt(p *Parser, start lexer.Position, isConstant bool) ast.Statement {
+
+	isStruct := p.currentTokenKind() == lexer.OPEN_CURLY_TOKEN
+
+	names := parseDestructuringNames(p)
+
+	if p.currentTokenKind() != lexer.WALRUS_TOKEN {
+		MakeError(p, p.currentToken().StartPos.Line, p.FilePath, p.currentToken().StartPos, p.currentToken().EndPos, "Expected := after the names to destructure").AddHint("e.g. ", TEXT_HINT).AddHint("let (name, size) := stat(path);", CODE_HINT).Display()
+	}
+	p.advance()
+
+	value := parseExpr(p, DEFAULT_BP)
+
+	end := p.expect(lexer.SEMI_COLON_TOKEN).EndPos
+
+	return ast.DestructuringDclStmt{
+		BaseStmt: ast.BaseStmt{
+			Kind:     ast.DESTRUCTURING_STATEMENT,
+			StartPos: start,
+			EndPos:   end,
+		},
+		IsConstant: isConstant,
+		IsStruct:   isStruct,
+		Names:      names,
+		Value:      value,
+	}
+}
+
+// parseDestructuringNames parses (a, b, ...) or { a, b, ... }
+func parseDestructuringNames(p *Parser) []ast.IdentifierExpr {
+
+	open := p.advance()
+	closing := lexer.CLOSE_PAREN_TOKEN
+	if open.Kind == lexer.OPEN_CURLY_TOKEN {
+		closing = lexer.CLOSE_CURLY_TOKEN
+	}
+
+	var names []ast.IdentifierExpr
+
+	for p.currentTokenKind() != closing {
+		name := p.expectError(lexer.IDENTIFIER_TOKEN, "Expected a name to destructure into")
+		names = append(names, ast.IdentifierExpr{
+			BaseStmt: ast.BaseStmt{
+				Kind:     ast.IDENTIFIER,
+				StartPos: name.StartPos,
+				EndPos:   name.EndPos,
+			},
+			Identifier: name.Value,
+		})
+		if p.currentTokenKind() != closing {
+			p.expect(lexer.COMMA_TOKEN)
+		}
+	}
+
+	end := p.expect(closing).EndPos
+
+	if len(names) == 0 {
+		MakeError(p, open.StartPos.Line, p.FilePath, open.StartPos, end, "Expected at least one name to destructure into").Display()
+	}
+
+	return names
+}
+
 func parseBlockStmt(p *Parser) ast.Statement {
 	return parseBlock(p)
 }
@@ -165,6 +286,9 @@ func parseBlock(p *Parser) ast.BlockStmt {
 	body := make([]ast.Node, 0)
 
 	for p.hasTokens() && p.currentTokenKind() != lexer.CLOSE_CURLY_TOKEN {
+		if token := p.currentToken(); token.Kind == lexer.EXPORT_TOKEN || token.Kind == lexer.ACCESS_TOKEN {
+			MakeError(p, token.StartPos.Line, p.FilePath, token.StartPos, token.EndPos, fmt.Sprintf("%s can only be used at the top level of a file", token.Value)).AddHint("a declaration inside a block is local to the block", TEXT_HINT).Display()
+		}
 		body = append(body, parseNode(p))
 	}
 
@@ -247,6 +371,10 @@ func parseReturnStmt(p *Parser) ast.Statement {
 
 	if p.currentTokenKind() != lexer.SEMI_COLON_TOKEN {
 		value = parseExpr(p, DEFAULT_BP)
+		// ret a, b; returns a tuple
+		if p.currentTokenKind() == lexer.COMMA_TOKEN {
+			value = parseTupleElements(p, value)
+		}
 	} else {
 		value = ast.VoidLiteral{
 			Kind: ast.VOID_LITERAL,
@@ -266,25 +394,92 @@ func parseReturnStmt(p *Parser) ast.Statement {
 }
 
 func parseBreakStmt(p *Parser) ast.Statement {
-	return parseBreakoutStmt(p)
+	start, end, label := parseBreakoutStmt(p)
+
+	return ast.BreakStmt{
+		BaseStmt: ast.BaseStmt{
+			Kind:     ast.BREAK_STATEMENT,
+			StartPos: start,
+			EndPos:   end,
+		},
+		Label: label,
+	}
 }
 
 func parseContinueStmt(p *Parser) ast.Statement {
-	return parseBreakoutStmt(p)
+	start, end, label := parseBreakoutStmt(p)
+
+	return ast.ContinueStmt{
+		BaseStmt: ast.BaseStmt{
+			Kind:     ast.CONTINUE_STATEMENT,
+			StartPos: start,
+			EndPos:   end,
+		},
+		Label: label,
+	}
 }
 
-func parseBreakoutStmt(p *Parser) ast.Statement {
+// parseBreakoutStmt parses break; continue; and the forms with a label, break outer;
+func parseBreakoutStmt(p *Parser) (lexer.Position, lexer.Position, string) {
 
 	start := p.advance().StartPos
+
+	label := ""
+	if p.currentTokenKind() == lexer.IDENTIFIER_TOKEN {
+		label = p.advance().Value
+	}
+
 	end := p.expect(lexer.SEMI_COLON_TOKEN).EndPos
 
-	return ast.BreakStmt{
-		BaseStmt: ast.BaseStmt{
-			Kind:     ast.BREAK_STATEMENT,
-			StartPos: start,
-			EndPos:   end,
-		},
+	return start, end, label
+}
+
+// parseLabeledStmt parses a loop with a label. Only loops can have one
+func parseLabeledStmt(p *Parser) ast.Statement {
+
+	label := p.advance()
+	p.expect(lexer.COLON_TOKEN)
+
+	var stmt ast.Statement
+
+	switch p.currentTokenKind() {
+	case lexer.FOR_TOKEN, lexer.FOREACH_TOKEN:
+		stmt = parseForLoopStmt(p)
+	case lexer.WHILE_TOKEN:
+		stmt = parseWhileLoopStmt(p)
+	default:
+		MakeError(p, label.StartPos.Line, p.FilePath, label.StartPos, label.EndPos, fmt.Sprintf("label '%s' must be followed by a loop", label.Value)).AddHint("only for, foreach and while loops can have a label", TEXT_HINT).Display()
+	}
+
+	switch loop := stmt.(type) {
+	case ast.ForStmt:
+		loop.Label = label.Value
+		loop.StartPos = label.StartPos
+		return loop
+	case ast.ForeachStmt:
+		loop.Label = label.Value
+		loop.StartPos = label.StartPos
+		return loop
+	case ast.WhileLoopStmt:
+		loop.Label = label.Value
+		loop.StartPos = label.StartPos
+		return loop
+	}
+
+	return stmt
+}
+
+// parseLoopElse parses the optional els block of a loop, which runs when the loop ends without break
+func parseLoopElse(p *Parser) *ast.BlockStmt {
+	if p.currentTokenKind() != lexer.ELSE_TOKEN {
+		return nil
 	}
+
+	p.advance()
+
+	block := parseBlock(p)
+
+	return &block
 }
 
 func parseStructDeclStmt(p *Parser) ast.Statement {
@@ -750,8 +945,15 @@ func parseForLoopStmt(p *Parser) ast.Statement {
 
 	loopKind := p.advance().Kind
 
-	//parse the init
-	identifier := p.expect(lexer.IDENTIFIER_TOKEN).Value
+	var identifier string
+	var variables []ast.IdentifierExpr
+
+	//parse the init. foreach (k, v) in pairs destructures the elements
+	if loopKind == lexer.FOREACH_TOKEN && p.currentTokenKind() == lexer.OPEN_PAREN_TOKEN {
+		variables = parseDestructuringNames(p)
+	} else {
+		identifier = p.expect(lexer.IDENTIFIER_TOKEN).Value
+	}
 
 	if loopKind == lexer.FOR_TOKEN {
 		p.expect(lexer.WALRUS_TOKEN)
@@ -772,6 +974,11 @@ func parseForLoopStmt(p *Parser) ast.Statement {
 
 		end := block.EndPos
 
+		elseBlock := parseLoopElse(p)
+		if elseBlock != nil {
+			end = elseBlock.EndPos
+		}
+
 		return ast.ForStmt{
 			BaseStmt: ast.BaseStmt{
 				Kind:     ast.FOR_LOOP_STATEMENT,
@@ -783,6 +990,7 @@ func parseForLoopStmt(p *Parser) ast.Statement {
 			Condition: condition,
 			Post:      post,
 			Block:     block,
+			Else:      elseBlock,
 		}
 
 	} else if loopKind == lexer.FOREACH_TOKEN {
@@ -819,6 +1027,11 @@ func parseForLoopStmt(p *Parser) ast.Statement {
 
 		end := block.EndPos
 
+		elseBlock := parseLoopElse(p)
+		if elseBlock != nil {
+			end = elseBlock.EndPos
+		}
+
 		return ast.ForeachStmt{
 			BaseStmt: ast.BaseStmt{
 				Kind:     ast.FOREACH_LOOP_STATEMENT,
@@ -826,10 +1039,12 @@ func parseForLoopStmt(p *Parser) ast.Statement {
 				EndPos:   end,
 			},
 			Variable:      identifier,
+			Variables:     variables,
 			IndexVariable: indexVar,
 			Iterable:      arr,
 			WhereClause:   whereCause,
 			Block:         block,
+			Else:          elseBlock,
 		}
 
 	} else {
@@ -848,6 +1063,11 @@ func parseWhileLoopStmt(p *Parser) ast.Statement {
 
 	_, end := block.GetPos()
 
+	elseBlock := parseLoopElse(p)
+	if elseBlock != nil {
+		end = elseBlock.EndPos
+	}
+
 	return ast.WhileLoopStmt{
 		BaseStmt: ast.BaseStmt{
 			Kind:     ast.WHILE_STATEMENT,
@@ -856,5 +1076,65 @@ func parseWhileLoopStmt(p *Parser) ast.Statement {
 		},
 		Condition: cond,
 		Block:     block,
+		Else:      elseBlock,
+	}
+}
+
+// parseRecoverStmt parses recover { ... } catch { ... } and recover { ... } catch |e| { ... }
+func parseRecoverStmt(p *Parser) ast.Statement {
+
+	start := p.advance().StartPos // skip the recover token
+
+	block := parseBlock(p)
+
+	p.expectError(lexer.CATCH_TOKEN, "expected catch after the recover block")
+
+	errorName := ""
+
+	if p.currentTokenKind() == lexer.BIT_OR_TOKEN {
+		p.advance()
+		errorName = p.expectError(lexer.IDENTIFIER_TOKEN, "expected the name of the error after '|'").Value
+		p.expectError(lexer.BIT_OR_TOKEN, "expected '|' after the name of the error")
+	}
+
+	handler := parseBlock(p)
+
+	_, end := handler.GetPos()
+
+	return ast.RecoverStmt{
+		BaseStmt: ast.BaseStmt{
+			Kind:     ast.RECOVER_STATEMENT,
+			StartPos: start,
+			EndPos:   end,
+		},
+		Block:     block,
+		ErrorName: errorName,
+		Handler:   handler,
+	}
+}
+
+// parseDeferStmt parses defer call;. Only a function call can be deferred
+func parseDeferStmt(p *Parser) ast.Statement {
+
+	start := p.advance().StartPos // skip the defer token
+
+	expr := parseExpr(p, ASSIGNMENT)
+
+	call, ok := expr.(ast.FunctionCallExpr)
+
+	if !ok {
+		exprStart, exprEnd := expr.GetPos()
+		MakeError(p, exprStart.Line, p.FilePath, exprStart, exprEnd, "defer needs a function call").AddHint("e.g. ", TEXT_HINT).AddHint("defer close(file);", CODE_HINT).Display()
+	}
+
+	end := p.expect(lexer.SEMI_COLON_TOKEN).EndPos
+
+	return ast.DeferStmt{
+		BaseStmt: ast.BaseStmt{
+			Kind:     ast.DEFER_STATEMENT,
+			StartPos: start,
+			EndPos:   end,
+		},
+		Call: call,
 	}
 }
@@ -26,9 +26,18 @@ func typeNUD(kind lexer.TOKEN_KIND, handleTypeNud typeNudNandlerType) {
 	typeNudLookup[kind] = handleTypeNud
 }
 
+func typeLED(kind lexer.TOKEN_KIND, bp BINDING_POWER, handleTypeLed typeLedHandlerType) {
+	typeBindindLookup[kind] = bp
+	typeLedLookup[kind] = handleTypeLed
+}
+
 func createTokenTypesLookups() {
 	typeNUD(lexer.IDENTIFIER_TOKEN, parseDataType)
 	typeNUD(lexer.OPEN_BRACKET_TOKEN, parseArrayType)
+	typeNUD(lexer.OPEN_PAREN_TOKEN, parseTupleType)
+
+	typeLED(lexer.QUESTION_TOKEN, PRIMARY, parseOptionalType)
+	typeLED(lexer.NOT_TOKEN, PRIMARY, parseResultType)
 }
 
 func parseDataType(p *Parser) ast.Type {
@@ -49,6 +58,13 @@ func parseDataType(p *Parser) ast.Type {
 			BitSize:  utils.BitSizeFromString(value),
 			IsSigned: false,
 		}
+	case "bigint":
+		// unbounded integers have no bit size
+		return ast.IntegerType{
+			Kind:     ast.T_BIGINT,
+			BitSize:  0,
+			IsSigned: true,
+		}
 	case "f32", "f64":
 		return ast.FloatType{
 			Kind:    ast.DATA_TYPE(value),
@@ -66,13 +82,30 @@ func parseDataType(p *Parser) ast.Type {
 		return ast.StringType{
 			Kind: ast.T_STRING,
 		}
+	case "error":
+		return ast.ErrorType{
+			Kind: ast.T_ERROR,
+		}
+	case "type":
+		return ast.TypeType{
+			Kind: ast.T_TYPE,
+		}
+	case "void":
+		return ast.VoidType{
+			Kind: ast.T_VOID,
+		}
+	case "map", "set", "range":
+		if p.currentTokenKind() == lexer.OPEN_BRACKET_TOKEN {
+			return parseCollectionType(p, value)
+		}
+		fallthrough
 	default:
 		return ast.StructType{
 			Kind: ast.T_STRUCT,
 			Name: value,
 		}
 		/*
-			p.MakeError(identifier.StartPos.Line, p.FilePath, identifier, fmt.Sprintf("Unknown data type '%s'\n", value)).AddHint("You can use primitives types like i8, i16, i32, i64, i128, u8, u16, u32, u64, u128, f32, f64, bool, char, str, or arrays of them").Display()
+			p.MakeError(identifier.StartPos.Line, p.FilePath, identifier, fmt.Sprintf("Unknown data type '%s'\n", value)).AddHint("You can use primitives types like i8, i16, i32, i64, i128, u8, u16, u32, u64, u128, bigint, f32, f64, bool, char, str, or arrays of them").Display()
 			panic("Error while parsing")
 		*/
 	}
@@ -86,10 +119,98 @@ func parseArrayType(p *Parser) ast.Type {
 	elemType := parseType(p, DEFAULT_BP)
 
 	return ast.ArrayType{
+		Kind:        ast.T_ARRAY,
 		ElementType: elemType,
 	}
 }
 
+// parseCollectionType parses the rest of map[K]V, set[T] or range[T], after the name
+func parseCollectionType(p *Parser, name string) ast.Type {
+
+	p.expect(lexer.OPEN_BRACKET_TOKEN)
+	key := parseType(p, DEFAULT_BP)
+	p.expect(lexer.CLOSE_BRACKET_TOKEN)
+
+	if name == "range" {
+		return ast.RangeType{
+			Kind:    ast.T_RANGE,
+			Element: key,
+		}
+	}
+
+	if name == "set" {
+		return ast.SetType{
+			Kind:    ast.T_SET,
+			Element: key,
+		}
+	}
+
+	return ast.MapType{
+		Kind:  ast.T_MAP,
+		Key:   key,
+		Value: parseType(p, DEFAULT_BP),
+	}
+}
+
+// parseTupleType parses (T1, T2, ...). A tuple has at least two elements
+func parseTupleType(p *Parser) ast.Type {
+
+	start := p.expect(lexer.OPEN_PAREN_TOKEN).StartPos
+
+	var elements []ast.Type
+
+	for p.currentTokenKind() != lexer.CLOSE_PAREN_TOKEN {
+		elements = append(elements, parseType(p, DEFAULT_BP))
+		if p.currentTokenKind() != lexer.CLOSE_PAREN_TOKEN {
+			p.expect(lexer.COMMA_TOKEN)
+		}
+	}
+
+	end := p.expect(lexer.CLOSE_PAREN_TOKEN).EndPos
+
+	if len(elements) < 2 {
+		MakeError(p, start.Line, p.FilePath, start, end, "a tuple type needs at least two elements").AddHint("e.g. ", TEXT_HINT).AddHint("(str, i64)", CODE_HINT).Display()
+	}
+
+	return ast.TupleType{
+		Kind:     ast.T_TUPLE,
+		Elements: elements,
+	}
+}
+
+// parseOptionalType parses T?, a type that also accepts null
+func parseOptionalType(p *Parser, left ast.Type, bp BINDING_POWER) ast.Type {
+
+	token := p.expect(lexer.QUESTION_TOKEN)
+
+	if _, ok := left.(ast.OptionalType); ok {
+		MakeError(p, token.StartPos.Line, p.FilePath, token.StartPos, token.EndPos, "type is already optional").Display()
+	}
+
+	return ast.OptionalType{
+		Kind:  ast.T_OPTIONAL,
+		Inner: left,
+	}
+}
+
+// parseResultType parses T!, the type of a value that may be an error
+func parseResultType(p *Parser, left ast.Type, bp BINDING_POWER) ast.Type {
+
+	token := p.expect(lexer.NOT_TOKEN)
+
+	switch left.(type) {
+	case ast.ResultType:
+		MakeError(p, token.StartPos.Line, p.FilePath, token.StartPos, token.EndPos, "type is already a result").Display()
+	case ast.ErrorType:
+		MakeError(p, token.StartPos.Line, p.FilePath, token.StartPos, token.EndPos, "error cannot be a result type").AddHint("an error is already a failure, return ", TEXT_HINT).AddHint("error", CODE_HINT).AddHint(" or ", TEXT_HINT).AddHint("T!", CODE_HINT).Display()
+	}
+
+	return ast.ResultType{
+		Kind:  ast.T_RESULT,
+		Value: left,
+	}
+}
+
 func parseType(p *Parser, bp BINDING_POWER) ast.Type {
 	// Fist parse the NUD
 	tokenKind := p.currentTokenKind()
@@ -103,7 +224,7 @@ func parseType(p *Parser, bp BINDING_POWER) ast.Type {
 		err.AddHint("let x := 10", CODE_HINT)
 		err.AddHint(" syntax or", TEXT_HINT)
 		err.AddHint("Use primitive types like ", TEXT_HINT)
-		err.AddHint("i8, i16, i32, i64, i128, u8, u16, u32, u64, u128, f32, f64, bool, char, str", CODE_HINT)
+		err.AddHint("i8, i16, i32, i64, i128, u8, u16, u32, u64, u128, bigint, f32, f64, bool, char, str", CODE_HINT)
 		err.AddHint(" or arrays of them", TEXT_HINT)
 		err.Display()
 
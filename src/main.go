@@ -11,33 +11,6 @@ import (
 	"walrus/utils"
 )
 
-func nativePrint(args ...typechecker.RuntimeValue) typechecker.RuntimeValue {
-
-	//if no arguments
-	if len(args) == 0 {
-		fmt.Println()
-		return typechecker.MakeVOID()
-	}
-
-	for _, arg := range args {
-		val, err := typechecker.CastToStringValue(arg)
-
-		if err != nil {
-			continue
-		}
-
-		//colorize
-		fmt.Print(utils.Colorize(utils.YELLOW, val.Value))
-	}
-	fmt.Println()
-	return typechecker.MakeVOID()
-}
-
-func nativeTime(args ...typechecker.RuntimeValue) typechecker.RuntimeValue {
-	t := time.Now().Unix()
-	return typechecker.MakeINT(t, 64, true)
-}
-
 func main() {
 	// time start
 
@@ -97,9 +70,6 @@ func main() {
 		env.DeclareVariable("false", typechecker.MakeBOOL(false), true)
 		env.DeclareVariable("null", typechecker.MakeNULL(), true)
 
-		env.DeclareNativeFn("print", typechecker.MakeNativeFUNCTION(nativePrint))
-		env.DeclareNativeFn("time", typechecker.MakeNativeFUNCTION(nativeTime))
-
 		fmt.Printf("Evaluating: %v\n", filename)
 
 		typechecker.Evaluate(ast, env)
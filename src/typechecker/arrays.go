@@ -0,0 +1,108 @@
+package typechecker
+
+import (
+	"fmt"
+	"walrus/frontend/ast"
+	"walrus/frontend/parser"
+)
+
+// EvaluateArrayLiteral evaluates the elements of an array literal. Every element must have the element type,
+// which is taken from the declaration if there is one, or else from the first element.
+// Numeric elements are converted to the element type like in an assignment.
+func EvaluateArrayLiteral(node ast.ArrayLiterals, elementType ast.Type, env *Environment) RuntimeValue {
+
+	elements := make([]RuntimeValue, len(node.Elements))
+
+	for i, elementExpr := range node.Elements {
+
+		element := Evaluate(elementExpr, env)
+
+		if elementType == nil {
+			elementType = typeOfValue(element)
+		}
+
+		converted, err := convertImplicitly(element, elementType, isNumericConstant(elementExpr))
+
+		if err == nil && valueTypeName(converted) != typeName(elementType) {
+			err = fmt.Errorf("array elements must be of type %s, got %s", typeName(elementType), valueTypeName(converted))
+		}
+
+		if err != nil {
+			start, end := elementExpr.GetPos()
+			runtimeError(env, start, end, err.Error()).Throw()
+		}
+
+		elements[i] = converted
+	}
+
+	if elementType == nil {
+		runtimeError(env, node.StartPos, node.EndPos, "cannot infer the element type of an empty array").AddHint("declare the type. e.g. ", parser.TEXT_HINT).AddHint("let a : []i32 = [];", parser.CODE_HINT).Throw()
+	}
+
+	return MakeARRAY(elements, elementType)
+}
+
+// EvaluateArrayIndexAccess evaluates a[i], m[key] for a map, or the slice a[1..3] of an array or a string
+func EvaluateArrayIndexAccess(expr ast.ArrayIndexAccess, env *Environment) RuntimeValue {
+
+	value := Evaluate(expr.Array, env)
+
+	if m, isMap := value.(MapValue); isMap {
+		return mapReference(m, expr, env).get()
+	}
+
+	indexValue := Evaluate(expr.Index, env)
+
+	if r, isRange := indexValue.(RangeValue); isRange {
+		return sliceValue(value, r, expr, env)
+	}
+
+	array, index := evaluateArrayElement(value, indexValue, expr, env)
+
+	return array.Elements[index]
+}
+
+// evaluateArrayElement returns the array and the checked index of an element. value and indexValue are the evaluated expr.Array and expr.Index
+func evaluateArrayElement(value RuntimeValue, indexValue RuntimeValue, expr ast.ArrayIndexAccess, env *Environment) (ArrayValue, int) {
+
+	array, ok := value.(ArrayValue)
+
+	if !ok {
+		start, end := expr.Array.GetPos()
+		runtimeError(env, start, end, fmt.Sprintf("cannot index a value of type %s", GetRuntimeType(value))).Throw()
+	}
+
+	integer, ok := indexValue.(IntegerValue)
+
+	start, end := expr.Index.GetPos()
+
+	if !ok {
+		runtimeError(env, start, end, fmt.Sprintf("array index must be an integer, got %s", GetRuntimeType(indexValue))).Throw()
+	}
+
+	index := integer.bigValue()
+
+	if index.Sign() < 0 || !index.IsInt64() || index.Int64() >= int64(len(array.Elements)) {
+		runtimeError(env, start, end, fmt.Sprintf("index %s is out of range for an array of length %d", index, len(array.Elements))).Throw()
+	}
+
+	return array, int(index.Int64())
+}
+
+// storeElement stores a value in an array element. The value is converted to the element type if no data can be lost
+func storeElement(array ArrayValue, index int, value RuntimeValue, isConstant bool) (RuntimeValue, error) {
+
+	converted, err := convertImplicitly(value, array.ElementType, isConstant)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !matchesType(converted, array.ElementType) {
+		return nil, fmt.Errorf("cannot assign a value of type %s to an element of type %s", valueTypeName(converted), typeName(array.ElementType))
+	}
+
+	array.Elements[index] = converted
+
+	return converted, nil
+}
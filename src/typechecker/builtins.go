@@ -0,0 +1,98 @@
+package typechecker
+
+import (
+	"fmt"
+	"walrus/frontend/ast"
+)
+
+// builtinFunction is a function of the language that can be called without declaring it.
+// A variable or function with the same name hides it
+type builtinFunction struct {
+	// returnType is the static type of the result, used by the flow analysis
+	returnType ast.Type
+	// resultType gives the static type of the result from the types of the arguments, if it depends on them
+	resultType func(args []ast.Type) ast.Type
+	call       func(expr ast.FunctionCallExpr, args []RuntimeValue, env *Environment) RuntimeValue
+	// analyze checks a call before the program runs. It is optional
+	analyze func(a *flowAnalyzer, expr ast.FunctionCallExpr)
+}
+
+func lookupBuiltin(name string) (builtinFunction, bool) {
+	switch name {
+	case "error":
+		return builtinFunction{returnType: ast.ErrorType{Kind: ast.T_ERROR}, call: builtinError}, true
+	case "insert":
+		return builtinFunction{returnType: ast.VoidType{Kind: ast.T_VOID}, call: builtinInsert}, true
+	case "delete":
+		return builtinFunction{returnType: ast.BoolType{Kind: ast.T_BOOLEAN}, call: builtinDelete}, true
+	case "has":
+		return builtinFunction{returnType: ast.BoolType{Kind: ast.T_BOOLEAN}, call: builtinHas}, true
+	case "keys":
+		return builtinFunction{resultType: keysResultType, call: builtinKeys}, true
+	case "values":
+		return builtinFunction{resultType: valuesResultType, call: builtinValues}, true
+	case "len":
+		return builtinFunction{returnType: ast.IntegerType{Kind: ast.T_INTEGER64, BitSize: 64, IsSigned: true}, call: builtinLen}, true
+	case "char_count":
+		return builtinFunction{returnType: ast.IntegerType{Kind: ast.T_INTEGER64, BitSize: 64, IsSigned: true}, call: builtinCharCount}, true
+	case "print":
+		return builtinFunction{returnType: ast.VoidType{Kind: ast.T_VOID}, call: builtinPrint}, true
+	case "println":
+		return builtinFunction{returnType: ast.VoidType{Kind: ast.T_VOID}, call: builtinPrintln}, true
+	case "eprint":
+		return builtinFunction{returnType: ast.VoidType{Kind: ast.T_VOID}, call: builtinEprint}, true
+	case "eprintln":
+		return builtinFunction{returnType: ast.VoidType{Kind: ast.T_VOID}, call: builtinEprintln}, true
+	case "printf":
+		return builtinFunction{returnType: ast.VoidType{Kind: ast.T_VOID}, call: builtinPrintf, analyze: (*flowAnalyzer).analyzeFormat}, true
+	case "sprintf":
+		return builtinFunction{returnType: ast.StringType{Kind: ast.T_STRING}, call: builtinSprintf, analyze: (*flowAnalyzer).analyzeFormat}, true
+	case "colorize":
+		return builtinFunction{returnType: ast.StringType{Kind: ast.T_STRING}, call: builtinColorize}, true
+	}
+	return builtinFunction{}, false
+}
+
+// builtinError creates an error from its message. e.g. error("file not found")
+func builtinError(expr ast.FunctionCallExpr, args []RuntimeValue, env *Environment) RuntimeValue {
+
+	if len(args) != 1 {
+		runtimeError(env, expr.StartPos, expr.EndPos, fmt.Sprintf("function 'error' expects 1 argument but %d were provided", len(args))).Throw()
+	}
+
+	message, ok := args[0].(StringValue)
+
+	if !ok {
+		start, end := expr.Args[0].GetPos()
+		runtimeError(env, start, end, fmt.Sprintf("the message of an error must be a str, got %s", valueTypeName(args[0]))).Throw()
+	}
+
+	return MakeERROR(message.Value)
+}
+
+// builtinLen returns the number of bytes of a string, or the number of elements of an array, a map, a set or a range
+func builtinLen(expr ast.FunctionCallExpr, args []RuntimeValue, env *Environment) RuntimeValue {
+
+	if len(args) != 1 {
+		runtimeError(env, expr.StartPos, expr.EndPos, fmt.Sprintf("function 'len' expects 1 argument but %d were provided", len(args))).Throw()
+	}
+
+	switch value := args[0].(type) {
+	case StringValue:
+		return MakeINT(int64(len(value.Value)), 64, true)
+	case ArrayValue:
+		return MakeINT(int64(len(value.Elements)), 64, true)
+	case MapValue, SetValue:
+		return MakeINT(int64(len(entriesOf(value).keys)), 64, true)
+	case RangeValue:
+		length, err := makeINTFromBig(value.length(), 64, true)
+		if err != nil {
+			runtimeError(env, expr.StartPos, expr.EndPos, err.Error()).Throw()
+		}
+		return length
+	default:
+		start, end := expr.Args[0].GetPos()
+		runtimeError(env, start, end, fmt.Sprintf("cannot get the length of a value of type %s", valueTypeName(args[0]))).Throw()
+		return nil
+	}
+}
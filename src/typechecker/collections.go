@@ -0,0 +1,383 @@
+package typechecker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"walrus/frontend/ast"
+	"walrus/frontend/parser"
+)
+
+// A map[K]V associates keys with values, and a set[T] holds distinct elements:
+//
+//	let counts := map[str]i32{ "go": 2, "rs": 1 };
+//	counts["walrus"] = 1;            // inserts or replaces
+//	let exts := set[str]{ "go", "rs" };
+//
+// Both keep the order in which the keys were inserted, so foreach and keys() are deterministic.
+// foreach (k, v) in m gives the entries of a map as (K, V) tuples. Keys must be hashable:
+// integers, bool, chr, str, or tuples of them. The flow analysis rejects any other key type.
+//
+// The builtins insert, delete, has, keys and values work on both collections.
+
+// collectionEntries holds the entries of a map, or the elements of a set, in insertion order
+type collectionEntries struct {
+	// position of each key in keys, by its hash
+	index  map[string]int
+	keys   []RuntimeValue
+	values []RuntimeValue
+}
+
+func newCollectionEntries() *collectionEntries {
+	return &collectionEntries{index: make(map[string]int)}
+}
+
+func (c *collectionEntries) get(key RuntimeValue) (RuntimeValue, bool) {
+	position, ok := c.index[hashKey(key)]
+	if !ok {
+		return nil, false
+	}
+	return c.values[position], true
+}
+
+// put inserts a new entry at the end, or replaces the value of an existing key in place
+func (c *collectionEntries) put(key RuntimeValue, value RuntimeValue) {
+	hash := hashKey(key)
+	if position, ok := c.index[hash]; ok {
+		c.values[position] = value
+		return
+	}
+	c.index[hash] = len(c.keys)
+	c.keys = append(c.keys, key)
+	c.values = append(c.values, value)
+}
+
+// remove deletes the entry of a key and reports if there was one
+func (c *collectionEntries) remove(key RuntimeValue) bool {
+	hash := hashKey(key)
+	position, ok := c.index[hash]
+	if !ok {
+		return false
+	}
+
+	c.keys = append(c.keys[:position], c.keys[position+1:]...)
+	c.values = append(c.values[:position], c.values[position+1:]...)
+
+	delete(c.index, hash)
+	for i := position; i < len(c.keys); i++ {
+		c.index[hashKey(c.keys[i])] = i
+	}
+
+	return true
+}
+
+// hashKey returns a text that identifies a key among the keys of the same type
+func hashKey(key RuntimeValue) string {
+	switch k := key.(type) {
+	case StringValue:
+		return strconv.Quote(k.Value)
+	case CharacterValue:
+		return strconv.QuoteRune(k.Value)
+	case TupleValue:
+		elements := make([]string, len(k.Elements))
+		for i, element := range k.Elements {
+			elements[i] = hashKey(element)
+		}
+		return "(" + strings.Join(elements, ",") + ")"
+	default:
+		text, _ := CastToStringValue(key)
+		return text.Value
+	}
+}
+
+// isHashableType reports if values of the type can be the keys of a map or the elements of a set
+func isHashableType(t ast.Type) bool {
+	switch t := t.(type) {
+	case ast.IntegerType, ast.BoolType, ast.CharType, ast.StringType:
+		return true
+	case ast.TupleType:
+		for _, element := range t.Elements {
+			if !isHashableType(element) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// convertEntry converts a key, value or element to the type of the collection, like an assignment.
+// what names it in the error
+func convertEntry(value RuntimeValue, t ast.Type, isConstant bool, what string) (RuntimeValue, error) {
+	converted, err := convertImplicitly(value, t, isConstant)
+	if err != nil {
+		return nil, err
+	}
+	if !matchesType(converted, t) {
+		return nil, fmt.Errorf("%s must be of type %s, got %s", what, typeName(t), valueTypeName(converted))
+	}
+	return converted, nil
+}
+
+// convertEntryAt converts like convertEntry, with a runtime error at the expression of the value
+func convertEntryAt(value RuntimeValue, t ast.Type, expr ast.Expression, what string, env *Environment) RuntimeValue {
+	converted, err := convertEntry(value, t, isNumericConstant(expr), what)
+	if err != nil {
+		start, end := expr.GetPos()
+		runtimeError(env, start, end, err.Error()).Throw()
+	}
+	return converted
+}
+
+func EvaluateMapLiteral(literal ast.MapLiteral, env *Environment) RuntimeValue {
+
+	m := MakeMAP(literal.Type.Key, literal.Type.Value)
+
+	for i, keyExpr := range literal.Keys {
+		key := convertEntryAt(Evaluate(keyExpr, env), literal.Type.Key, keyExpr, "map key", env)
+		value := convertEntryAt(Evaluate(literal.Values[i], env), literal.Type.Value, literal.Values[i], "map value", env)
+		m.Entries.put(key, value)
+	}
+
+	return m
+}
+
+func EvaluateSetLiteral(literal ast.SetLiteral, env *Environment) RuntimeValue {
+
+	s := MakeSET(literal.Type.Element)
+
+	for _, elementExpr := range literal.Elements {
+		element := convertEntryAt(Evaluate(elementExpr, env), literal.Type.Element, elementExpr, "set element", env)
+		s.Entries.put(element, nil)
+	}
+
+	return s
+}
+
+// mapReference is the place of m[key]. Reading a key that is not in the map is a runtime error, assigning it inserts it
+func mapReference(m MapValue, expr ast.ArrayIndexAccess, env *Environment) reference {
+
+	mapType := m.Type.(ast.MapType)
+
+	key := convertEntryAt(Evaluate(expr.Index, env), mapType.Key, expr.Index, "map key", env)
+
+	return reference{
+		get: func() RuntimeValue {
+			value, ok := m.Entries.get(key)
+			if !ok {
+				start, end := expr.Index.GetPos()
+				runtimeError(env, start, end, fmt.Sprintf("key %s is not in the map", hashKey(key))).AddHint("check it first with ", parser.TEXT_HINT).AddHint("has(map, key)", parser.CODE_HINT).Throw()
+			}
+			return value
+		},
+		set: func(value RuntimeValue, isConstant bool) (RuntimeValue, error) {
+			converted, err := convertEntry(value, mapType.Value, isConstant, "map value")
+			if err != nil {
+				return nil, err
+			}
+			m.Entries.put(key, converted)
+			return converted, nil
+		},
+	}
+}
+
+// collectionArgument returns the map or set given as the first argument of a builtin
+func collectionArgument(name string, expr ast.FunctionCallExpr, args []RuntimeValue, count int, env *Environment) RuntimeValue {
+
+	if len(args) != count {
+		runtimeError(env, expr.StartPos, expr.EndPos, fmt.Sprintf("function '%s' expects %d arguments but %d were provided", name, count, len(args))).Throw()
+	}
+
+	switch args[0].(type) {
+	case MapValue, SetValue:
+		return args[0]
+	default:
+		start, end := expr.Args[0].GetPos()
+		runtimeError(env, start, end, fmt.Sprintf("function '%s' needs a map or a set, got %s", name, valueTypeName(args[0]))).Throw()
+		return nil
+	}
+}
+
+// collectionKey converts the key argument of a builtin to the key type of the collection
+func collectionKey(collection RuntimeValue, expr ast.FunctionCallExpr, args []RuntimeValue, env *Environment) RuntimeValue {
+	switch c := collection.(type) {
+	case MapValue:
+		return convertEntryAt(args[1], c.Type.(ast.MapType).Key, expr.Args[1], "map key", env)
+	default:
+		return convertEntryAt(args[1], c.(SetValue).Type.(ast.SetType).Element, expr.Args[1], "set element", env)
+	}
+}
+
+func entriesOf(collection RuntimeValue) *collectionEntries {
+	if m, ok := collection.(MapValue); ok {
+		return m.Entries
+	}
+	return collection.(SetValue).Entries
+}
+
+// builtinInsert adds an entry to a map, insert(m, key, value), or an element to a set, insert(s, element)
+func builtinInsert(expr ast.FunctionCallExpr, args []RuntimeValue, env *Environment) RuntimeValue {
+
+	count := 2
+	if len(args) > 0 && GetRuntimeType(args[0]) == ast.T_MAP {
+		count = 3
+	}
+
+	collection := collectionArgument("insert", expr, args, count, env)
+	key := collectionKey(collection, expr, args, env)
+
+	var value RuntimeValue
+	if m, ok := collection.(MapValue); ok {
+		value = convertEntryAt(args[2], m.Type.(ast.MapType).Value, expr.Args[2], "map value", env)
+	}
+
+	entriesOf(collection).put(key, value)
+
+	return MakeVOID()
+}
+
+// builtinDelete removes a key from a map or an element from a set. It returns false if it was not there
+func builtinDelete(expr ast.FunctionCallExpr, args []RuntimeValue, env *Environment) RuntimeValue {
+	collection := collectionArgument("delete", expr, args, 2, env)
+	return MakeBOOL(entriesOf(collection).remove(collectionKey(collection, expr, args, env)))
+}
+
+func builtinHas(expr ast.FunctionCallExpr, args []RuntimeValue, env *Environment) RuntimeValue {
+	collection := collectionArgument("has", expr, args, 2, env)
+	_, ok := entriesOf(collection).get(collectionKey(collection, expr, args, env))
+	return MakeBOOL(ok)
+}
+
+// builtinKeys returns the keys of a map, or the elements of a set, in insertion order
+func builtinKeys(expr ast.FunctionCallExpr, args []RuntimeValue, env *Environment) RuntimeValue {
+	collection := collectionArgument("keys", expr, args, 1, env)
+
+	keyType, _ := collectionElementTypes(typeOfValue(collection))
+
+	return MakeARRAY(append([]RuntimeValue{}, entriesOf(collection).keys...), keyType)
+}
+
+// builtinValues returns the values of a map in insertion order. The values of a set are its elements
+func builtinValues(expr ast.FunctionCallExpr, args []RuntimeValue, env *Environment) RuntimeValue {
+	collection := collectionArgument("values", expr, args, 1, env)
+
+	keyType, valueType := collectionElementTypes(typeOfValue(collection))
+
+	if valueType == nil {
+		return MakeARRAY(append([]RuntimeValue{}, entriesOf(collection).keys...), keyType)
+	}
+
+	return MakeARRAY(append([]RuntimeValue{}, entriesOf(collection).values...), valueType)
+}
+
+// collectionElementTypes returns the key and value types of a map, or the element type of a set and nil
+func collectionElementTypes(t ast.Type) (ast.Type, ast.Type) {
+	switch t := t.(type) {
+	case ast.MapType:
+		return t.Key, t.Value
+	case ast.SetType:
+		return t.Element, nil
+	default:
+		return nil, nil
+	}
+}
+
+// collectionItems returns the elements foreach gives for a map, (key, value) tuples, or for a set
+func collectionItems(collection RuntimeValue) []RuntimeValue {
+	switch c := collection.(type) {
+	case MapValue:
+		mapType := c.Type.(ast.MapType)
+		items := make([]RuntimeValue, len(c.Entries.keys))
+		for i, key := range c.Entries.keys {
+			items[i] = TupleValue{
+				Elements: []RuntimeValue{key, c.Entries.values[i]},
+				Type:     ast.TupleType{Kind: ast.T_TUPLE, Elements: []ast.Type{mapType.Key, mapType.Value}},
+			}
+		}
+		return items
+	default:
+		return append([]RuntimeValue{}, collection.(SetValue).Entries.keys...)
+	}
+}
+
+// keysResultType is the static type of keys(c): []K for a map[K]V, []T for a set[T]
+func keysResultType(args []ast.Type) ast.Type {
+	if len(args) == 0 {
+		return nil
+	}
+	if key, _ := collectionElementTypes(args[0]); key != nil {
+		return ast.ArrayType{Kind: ast.T_ARRAY, ElementType: key}
+	}
+	return nil
+}
+
+// valuesResultType is the static type of values(c): []V for a map[K]V, []T for a set[T]
+func valuesResultType(args []ast.Type) ast.Type {
+	if len(args) == 0 {
+		return nil
+	}
+	key, value := collectionElementTypes(args[0])
+	if value != nil {
+		return ast.ArrayType{Kind: ast.T_ARRAY, ElementType: value}
+	}
+	if key != nil {
+		return ast.ArrayType{Kind: ast.T_ARRAY, ElementType: key}
+	}
+	return nil
+}
+
+// checkHashable rejects a map or set type, anywhere inside the given type, whose keys cannot be hashed
+func (a *flowAnalyzer) checkHashable(t ast.Type, node ast.Node) {
+	var key ast.Type
+
+	switch collection := t.(type) {
+	case ast.MapType:
+		key = collection.Key
+		a.checkHashable(collection.Key, node)
+		a.checkHashable(collection.Value, node)
+	case ast.SetType:
+		key = collection.Element
+		a.checkHashable(collection.Element, node)
+	case ast.ArrayType:
+		a.checkHashable(collection.ElementType, node)
+	case ast.OptionalType:
+		a.checkHashable(collection.Inner, node)
+	case ast.ResultType:
+		a.checkHashable(collection.Value, node)
+	case ast.TupleType:
+		for _, element := range collection.Elements {
+			a.checkHashable(element, node)
+		}
+	}
+
+	if key == nil || isHashableType(key) {
+		return
+	}
+
+	start, end := node.GetPos()
+	parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("%s cannot be a key of %s", typeName(key), typeName(t))).AddHint("keys can be integers, bool, chr, str, or tuples of them", parser.TEXT_HINT).Display()
+}
+
+func (a *flowAnalyzer) analyzeMapLiteral(literal ast.MapLiteral) {
+	a.checkHashable(literal.Type, literal)
+
+	for i, key := range literal.Keys {
+		a.analyzeExpr(key)
+		a.analyzeExpr(literal.Values[i])
+		a.checkNullAssignment(literal.Type.Key, key)
+		a.checkNullAssignment(literal.Type.Value, literal.Values[i])
+		a.checkElementType(literal.Type.Key, key, a.inferType(key))
+		a.checkElementType(literal.Type.Value, literal.Values[i], a.inferType(literal.Values[i]))
+	}
+}
+
+func (a *flowAnalyzer) analyzeSetLiteral(literal ast.SetLiteral) {
+	a.checkHashable(literal.Type, literal)
+
+	for _, element := range literal.Elements {
+		a.analyzeExpr(element)
+		a.checkNullAssignment(literal.Type.Element, element)
+		a.checkElementType(literal.Type.Element, element, a.inferType(element))
+	}
+}
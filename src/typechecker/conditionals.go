@@ -0,0 +1,138 @@
+package typechecker
+
+import (
+	"fmt"
+	"walrus/frontend/ast"
+)
+
+// EvaluateTernaryExpr evaluates only the branch selected by the condition.
+// The result is converted to the common type of both branches, so it does not depend on the branch taken.
+func EvaluateTernaryExpr(expr ast.TernaryExpr, env *Environment) RuntimeValue {
+
+	condition := Evaluate(expr.Condition, env)
+
+	selected, other := expr.Consequent, expr.Alternate
+	if !IsTruthy(condition) {
+		selected, other = expr.Alternate, expr.Consequent
+	}
+
+	value := Evaluate(selected, env)
+
+	// the other branch is not evaluated, its type was recorded by the flow analysis
+	return convertToCommonType(value, selected, env.getExpressionType(other), other, env)
+}
+
+// evaluateNullishExpr evaluates a ?? b. b is evaluated only if a is null
+func evaluateNullishExpr(expr ast.BinaryExpr, env *Environment) RuntimeValue {
+
+	left := Evaluate(expr.Left, env)
+
+	if _, isNull := left.(NullValue); !isNull {
+		return left
+	}
+
+	value := Evaluate(expr.Right, env)
+
+	return convertToCommonType(value, expr.Right, env.getExpressionType(expr.Left), expr.Left, env)
+}
+
+// EvaluateSwitchStmt runs the block of the first case that matches the value, or the default block if no case matches.
+// There is no fallthrough. ret, break and continue in a block leave the enclosing function or loop
+func EvaluateSwitchStmt(stmt ast.SwitchStmt, env *Environment) RuntimeValue {
+
+	discriminant := Evaluate(stmt.Discriminant, env)
+
+	for _, switchCase := range stmt.Cases {
+		if switchCase.Test == nil || matchesCase(discriminant, switchCase.Test, env) {
+			return EvaluateBlockStmt(switchCase.Consequent, env)
+		}
+	}
+
+	return MakeVOID()
+}
+
+// convertToCommonType converts the value of one branch of a conditional expression to the type it shares with the other branch
+func convertToCommonType(value RuntimeValue, branch ast.Expression, otherType ast.Type, other ast.Expression, env *Environment) RuntimeValue {
+
+	common, err := commonBranchType(typeOfValue(value), otherType, isNumericConstant(branch), isNumericConstant(other))
+
+	if err == nil && common != nil {
+		value, err = convertImplicitly(value, common, isNumericConstant(branch))
+	}
+
+	if err != nil {
+		start, end := branch.GetPos()
+		runtimeError(env, start, end, err.Error()).Throw()
+	}
+
+	return value
+}
+
+// commonBranchType returns the type both branches of a conditional expression are converted to.
+// A numeric constant takes the type of the other branch, other numbers are widened when no data can be lost.
+// It returns nil if a type is not known.
+func commonBranchType(a ast.Type, b ast.Type, aIsConstant bool, bIsConstant bool) (ast.Type, error) {
+
+	if a == nil || b == nil {
+		return nil, nil
+	}
+
+	// null and T give T?
+	if a.IType() == ast.T_NULL {
+		return makeOptional(b), nil
+	}
+	if b.IType() == ast.T_NULL {
+		return makeOptional(a), nil
+	}
+
+	// T? and U give the optional common type of T and U
+	aOptional, aIsOptional := a.(ast.OptionalType)
+	bOptional, bIsOptional := b.(ast.OptionalType)
+	if aIsOptional || bIsOptional {
+		if aIsOptional {
+			a = aOptional.Inner
+		}
+		if bIsOptional {
+			b = bOptional.Inner
+		}
+		common, err := commonBranchType(a, b, aIsConstant, bIsConstant)
+		return makeOptional(common), err
+	}
+
+	if typeName(a) == typeName(b) {
+		return a, nil
+	}
+
+	// tuples of the same size have the common type of each element
+	aTuple, aIsTuple := a.(ast.TupleType)
+	bTuple, bIsTuple := b.(ast.TupleType)
+	if aIsTuple && bIsTuple && len(aTuple.Elements) == len(bTuple.Elements) {
+		elements := make([]ast.Type, len(aTuple.Elements))
+		for i := range elements {
+			common, err := commonBranchType(aTuple.Elements[i], bTuple.Elements[i], false, false)
+			if err != nil || common == nil {
+				return nil, fmt.Errorf("the branches have different types %s and %s. convert one of them with 'as'", typeName(a), typeName(b))
+			}
+			elements[i] = common
+		}
+		return ast.TupleType{Kind: ast.T_TUPLE, Elements: elements}, nil
+	}
+
+	aIsNumber := categoryOf(a) == integerCategory || categoryOf(a) == floatCategory
+	bIsNumber := categoryOf(b) == integerCategory || categoryOf(b) == floatCategory
+
+	if aIsNumber && bIsNumber {
+		switch {
+		case aIsConstant && !bIsConstant:
+			return b, nil
+		case bIsConstant && !aIsConstant:
+			return a, nil
+		case isLosslessConversion(a, b):
+			return b, nil
+		case isLosslessConversion(b, a):
+			return a, nil
+		}
+	}
+
+	return nil, fmt.Errorf("the branches have different types %s and %s. convert one of them with 'as'", typeName(a), typeName(b))
+}
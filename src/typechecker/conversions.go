@@ -0,0 +1,419 @@
+package typechecker
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"walrus/frontend/ast"
+)
+
+// Conversions with the 'as' operator
+//
+//	from \ to | integer            | float           | chr     | bool          | str
+//	----------+--------------------+-----------------+---------+---------------+------
+//	integer   | checked            | rounded         | checked | -             | text
+//	float     | truncated, checked | rounded         | -       | -             | text
+//	chr       | checked            | -               | same    | -             | text
+//	bool      | 0 or 1             | -               | -       | same          | text
+//	str       | parsed, checked    | parsed, checked | checked | parsed        | same
+//
+// checked:   fails at runtime if the value cannot be represented in the target type
+// truncated: the fractional part is dropped (rounds towards zero)
+// rounded:   the nearest value of the target type is used
+// parsed:    the text must be a valid literal of the target type ("true" or "false" for bool)
+// -:         rejected while type checking, as are conversions from or to any other type (structs, functions...)
+//
+// Implicit conversions only happen when no data can be lost: to a wider integer of the same signedness,
+// from an unsigned integer to a wider signed integer, from an integer to a float that represents all
+// of its values exactly (up to 16 bits for f32, 32 bits for f64) and from f32 to f64.
+
+type conversionCategory string
+
+const (
+	integerCategory   conversionCategory = "integer"
+	floatCategory     conversionCategory = "float"
+	characterCategory conversionCategory = "chr"
+	booleanCategory   conversionCategory = "bool"
+	stringCategory    conversionCategory = "str"
+)
+
+var allowedConversions = map[conversionCategory][]conversionCategory{
+	integerCategory:   {integerCategory, floatCategory, characterCategory, stringCategory},
+	floatCategory:     {integerCategory, floatCategory, stringCategory},
+	characterCategory: {integerCategory, characterCategory, stringCategory},
+	booleanCategory:   {integerCategory, booleanCategory, stringCategory},
+	stringCategory:    {integerCategory, floatCategory, characterCategory, booleanCategory, stringCategory},
+}
+
+func categoryOf(t ast.Type) conversionCategory {
+	switch t.(type) {
+	case ast.IntegerType:
+		return integerCategory
+	case ast.FloatType:
+		return floatCategory
+	case ast.CharType:
+		return characterCategory
+	case ast.BoolType:
+		return booleanCategory
+	case ast.StringType:
+		return stringCategory
+	default:
+		return ""
+	}
+}
+
+// typeName returns the name of a type as written in the source
+func typeName(t ast.Type) string {
+	switch t := t.(type) {
+	case ast.BoolType:
+		// the kind of the type is boolean, but the programs write bool
+		return "bool"
+	case ast.StructType:
+		return t.Name
+	case ast.ArrayType:
+		return "[]" + typeName(t.ElementType)
+	case ast.OptionalType:
+		return typeName(t.Inner) + "?"
+	case ast.ResultType:
+		return typeName(t.Value) + "!"
+	case ast.MapType:
+		return "map[" + typeName(t.Key) + "]" + typeName(t.Value)
+	case ast.SetType:
+		return "set[" + typeName(t.Element) + "]"
+	case ast.RangeType:
+		return "range[" + typeName(t.Element) + "]"
+	case ast.TupleType:
+		elements := make([]string, len(t.Elements))
+		for i, element := range t.Elements {
+			elements[i] = typeName(element)
+		}
+		return "(" + strings.Join(elements, ", ") + ")"
+	}
+	return string(t.IType())
+}
+
+// valueTypeName returns the name of the type of a runtime value
+func valueTypeName(value RuntimeValue) string {
+	if t := typeOfValue(value); t != nil {
+		return typeName(t)
+	}
+	return string(GetRuntimeType(value))
+}
+
+// checkConversion reports if a value of type 'from' can be converted to type 'to' with the 'as' operator
+func checkConversion(from ast.Type, to ast.Type) error {
+
+	if typeName(from) == typeName(to) {
+		return nil
+	}
+
+	fromCategory := categoryOf(from)
+	toCategory := categoryOf(to)
+
+	if fromCategory != "" && toCategory != "" {
+		for _, allowed := range allowedConversions[fromCategory] {
+			if allowed == toCategory {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("cannot convert a value of type '%s' to '%s'", typeName(from), typeName(to))
+}
+
+func EvaluateTypeCastExpr(expr ast.TypeCastExpr, env *Environment) RuntimeValue {
+
+	value := Evaluate(expr.Expression, env)
+
+	result, err := castValue(value, expr.TargetType)
+
+	if err != nil {
+		runtimeError(env, expr.StartPos, expr.EndPos, err.Error()).Throw()
+	}
+
+	return result
+}
+
+// castValue converts a value to the target type following the conversion table
+func castValue(value RuntimeValue, target ast.Type) (RuntimeValue, error) {
+
+	from := typeOfValue(value)
+
+	if from == nil {
+		return nil, fmt.Errorf("cannot convert a value of type '%s' to '%s'", GetRuntimeType(value), typeName(target))
+	}
+
+	if err := checkConversion(from, target); err != nil {
+		return nil, err
+	}
+
+	switch t := target.(type) {
+	case ast.IntegerType:
+		return castToInteger(value, t)
+	case ast.FloatType:
+		return castToFloat(value, t)
+	case ast.CharType:
+		return castToCharacter(value)
+	case ast.BoolType:
+		return castToBoolean(value)
+	case ast.StringType:
+		return CastToStringValue(value)
+	default:
+		// same struct type
+		return value, nil
+	}
+}
+
+func castToInteger(value RuntimeValue, target ast.IntegerType) (RuntimeValue, error) {
+
+	var exact *big.Int
+
+	switch v := value.(type) {
+	case IntegerValue:
+		exact = v.bigValue()
+	case FloatValue:
+		if math.IsNaN(v.Value) || math.IsInf(v.Value, 0) {
+			return nil, fmt.Errorf("cannot convert %v to %s", v.Value, target.IType())
+		}
+		exact, _ = big.NewFloat(math.Trunc(v.Value)).Int(nil)
+	case CharacterValue:
+		exact = big.NewInt(int64(v.Value))
+	case BooleanValue:
+		exact = big.NewInt(0)
+		if v.Value {
+			exact = big.NewInt(1)
+		}
+	case StringValue:
+		var ok bool
+		exact, ok = new(big.Int).SetString(v.Value, 10)
+		if !ok {
+			return nil, fmt.Errorf("cannot convert \"%s\" to %s. it is not an integer", v.Value, target.IType())
+		}
+	}
+
+	if !fitsInInteger(exact, target.BitSize, target.IsSigned) {
+		min, max := integerBounds(target.BitSize, target.IsSigned)
+		return nil, fmt.Errorf("value %s does not fit in %s. the range of %s is %s to %s", exact, target.IType(), target.IType(), min, max)
+	}
+
+	return makeINTFromBig(exact, target.BitSize, target.IsSigned)
+}
+
+func castToFloat(value RuntimeValue, target ast.FloatType) (RuntimeValue, error) {
+
+	var result float64
+
+	switch v := value.(type) {
+	case IntegerValue:
+		result = v.float()
+	case FloatValue:
+		result = v.Value
+	case StringValue:
+		parsed, err := strconv.ParseFloat(v.Value, int(target.BitSize))
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert \"%s\" to %s. it is not a number", v.Value, target.IType())
+		}
+		result = parsed
+	}
+
+	if target.BitSize == 32 {
+		if math.Abs(result) > math.MaxFloat32 && !math.IsInf(result, 0) {
+			return nil, fmt.Errorf("value %v does not fit in f32", result)
+		}
+		result = float64(float32(result))
+	}
+
+	return MakeFLOAT(result, target.BitSize), nil
+}
+
+func castToCharacter(value RuntimeValue) (RuntimeValue, error) {
+
+	switch v := value.(type) {
+	case CharacterValue:
+		return v, nil
+	case IntegerValue:
+		// chr holds a Unicode code point, surrogates are not characters
+		if !fitsInInteger(v.bigValue(), 32, true) || !utf8.ValidRune(rune(v.bigValue().Int64())) {
+			return nil, fmt.Errorf("value %s is not a valid chr", v)
+		}
+		return MakeCHAR(rune(v.bigValue().Int64())), nil
+	default:
+		text := value.(StringValue).Value
+		if utf8.RuneCountInString(text) != 1 {
+			return nil, fmt.Errorf("cannot convert \"%s\" to chr. it must have exactly one character", text)
+		}
+		char, _ := utf8.DecodeRuneInString(text)
+		return MakeCHAR(char), nil
+	}
+}
+
+func castToBoolean(value RuntimeValue) (RuntimeValue, error) {
+
+	switch v := value.(type) {
+	case BooleanValue:
+		return v, nil
+	default:
+		text := value.(StringValue).Value
+		switch text {
+		case "true":
+			return MakeBOOL(true), nil
+		case "false":
+			return MakeBOOL(false), nil
+		default:
+			return nil, fmt.Errorf("cannot convert \"%s\" to bool. it must be true or false", text)
+		}
+	}
+}
+
+// isLosslessConversion reports if every value of type 'from' can be represented exactly in type 'to'
+func isLosslessConversion(from ast.Type, to ast.Type) bool {
+
+	switch f := from.(type) {
+	case ast.IntegerType:
+		switch t := to.(type) {
+		case ast.IntegerType:
+			if t.BitSize == 0 || f.BitSize == 0 {
+				// every integer fits in a bigint, a bigint fits in nothing else
+				return t.BitSize == 0
+			}
+			if f.IsSigned == t.IsSigned {
+				return t.BitSize >= f.BitSize
+			}
+			return !f.IsSigned && t.BitSize > f.BitSize
+		case ast.FloatType:
+			// f32 has a 24 bit mantissa and f64 has a 53 bit mantissa
+			if f.BitSize == 0 {
+				return false
+			}
+			if t.BitSize == 32 {
+				return f.BitSize <= 16
+			}
+			return f.BitSize <= 32
+		}
+	case ast.FloatType:
+		if t, ok := to.(ast.FloatType); ok {
+			return t.BitSize >= f.BitSize
+		}
+	}
+
+	return false
+}
+
+// convertImplicitly converts a numeric value to the type of the variable it is stored in.
+// Constants are converted if they fit, other values only if no data can be lost.
+// Values of other types are returned unchanged.
+func convertImplicitly(value RuntimeValue, target ast.Type, isConstant bool) (RuntimeValue, error) {
+
+	// a number stored in a T? or a T! is converted to T
+	if result, ok := target.(ast.ResultType); ok {
+		target = result.Value
+	}
+	if optional, ok := target.(ast.OptionalType); ok {
+		target = optional.Inner
+	}
+
+	if tupleType, ok := target.(ast.TupleType); ok {
+		if tuple, ok := value.(TupleValue); ok {
+			return convertTuple(tuple, tupleType)
+		}
+	}
+
+	from := typeOfValue(value)
+
+	if from == nil || target == nil || from.IType() == target.IType() {
+		return value, nil
+	}
+
+	fromCategory := categoryOf(from)
+	toCategory := categoryOf(target)
+
+	if (fromCategory != integerCategory && fromCategory != floatCategory) || (toCategory != integerCategory && toCategory != floatCategory) {
+		return value, nil
+	}
+
+	if isConstant && !(fromCategory == floatCategory && toCategory == integerCategory) {
+		if floatTarget, ok := target.(ast.FloatType); ok {
+			if err := checkFloatConstant(value, floatTarget); err != nil {
+				return nil, err
+			}
+		}
+		return castValue(value, target)
+	}
+
+	if isLosslessConversion(from, target) {
+		return castValue(value, target)
+	}
+
+	return nil, fmt.Errorf("potential data loss. value of type %s cannot be assigned to %s implicitly. convert it with 'as %s'", from.IType(), target.IType(), target.IType())
+}
+
+// checkFloatConstant reports a constant that does not keep its value as a float of the target type.
+// A float keeps it if it reads back as the same number, so 3.14 is an f32 but 16777217.0 is not
+func checkFloatConstant(value RuntimeValue, target ast.FloatType) error {
+
+	switch v := value.(type) {
+	case IntegerValue:
+		exact := new(big.Float).SetInt(v.bigValue())
+		converted, accuracy := exact.Float64()
+		if target.BitSize == 32 {
+			f, a := exact.Float32()
+			converted, accuracy = float64(f), a
+		}
+		if accuracy != big.Exact {
+			return fmt.Errorf("constant %s cannot be represented exactly as %s. it would become %s", v, target.IType(), strconv.FormatFloat(converted, 'g', -1, int(target.BitSize)))
+		}
+	case FloatValue:
+		if target.BitSize != 32 {
+			return nil
+		}
+		if math.Abs(v.Value) > math.MaxFloat32 {
+			return fmt.Errorf("constant %v does not fit in f32. the largest f32 is %g", v.Value, math.MaxFloat32)
+		}
+		// an f64 has 15 significant decimal digits
+		converted := strconv.FormatFloat(float64(float32(v.Value)), 'g', -1, 32)
+		read, _ := strconv.ParseFloat(converted, 64)
+		expected, _ := strconv.ParseFloat(strconv.FormatFloat(v.Value, 'g', 15, 64), 64)
+		if read != expected {
+			return fmt.Errorf("constant %s cannot be represented exactly as f32. it would become %s", strconv.FormatFloat(v.Value, 'g', -1, 64), converted)
+		}
+	}
+
+	return nil
+}
+
+// matchesType reports if a value can be stored in a variable, field or element of the given type.
+// null only fits in optional types, errors fit in result types
+func matchesType(value RuntimeValue, t ast.Type) bool {
+	if result, ok := t.(ast.ResultType); ok {
+		if _, isError := value.(ErrorValue); isError {
+			return true
+		}
+		t = result.Value
+	}
+	if optional, ok := t.(ast.OptionalType); ok {
+		if _, isNull := value.(NullValue); isNull {
+			return true
+		}
+		t = optional.Inner
+	}
+	return valueTypeName(value) == typeName(t)
+}
+
+// isNumericConstant reports if the expression is made only of numeric literals without a type suffix
+func isNumericConstant(expr ast.Expression) bool {
+	switch e := expr.(type) {
+	case ast.NumericLiteral:
+		return e.Suffix == ""
+	case ast.UnaryExpr:
+		return (e.Operator.Value == "-" || e.Operator.Value == "+" || e.Operator.Value == "~") && isNumericConstant(e.Argument)
+	case ast.BinaryExpr:
+		switch e.Operator.Value {
+		case "+", "-", "*", "/", "%", "**", "&", "|", "^", "<<", ">>":
+			return isNumericConstant(e.Left) && isNumericConstant(e.Right)
+		}
+	}
+	return false
+}
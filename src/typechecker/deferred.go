@@ -0,0 +1,73 @@
+package typechecker
+
+import (
+	"fmt"
+	"walrus/frontend/ast"
+)
+
+// deferredCall is a call registered with defer. The function and the arguments are evaluated
+// when the defer statement runs, the call is made when the function returns
+type deferredCall struct {
+	fn   RuntimeValue
+	args []RuntimeValue
+	expr ast.FunctionCallExpr
+	env  *Environment
+}
+
+type deferredCalls struct {
+	calls []deferredCall
+}
+
+// functionScope returns the scope of the function call that contains the environment, nil outside of a function
+func (e *Environment) functionScope() *Environment {
+	for env := e; env != nil; env = env.parent {
+		if env.deferred != nil {
+			return env
+		}
+	}
+	return nil
+}
+
+func EvaluateDeferStmt(stmt ast.DeferStmt, env *Environment) RuntimeValue {
+
+	scope := env.functionScope()
+
+	if scope == nil {
+		runtimeError(env, stmt.StartPos, stmt.EndPos, "defer can only be used inside a function").Throw()
+	}
+
+	var args []RuntimeValue
+
+	for _, arg := range stmt.Call.Args {
+		args = append(args, Evaluate(arg, env))
+	}
+
+	var fn RuntimeValue
+
+	// a builtin is not a variable, the deferred call calls it like EvaluateFunctionCallExpr
+	if builtin, ok := lookupBuiltin(stmt.Call.Caller.Identifier); ok && !env.HasVariable(stmt.Call.Caller.Identifier) {
+		fn = NativeFunctionValue{
+			Caller: func(args ...RuntimeValue) RuntimeValue { return builtin.call(stmt.Call, args, env) },
+			Type:   ast.NativeFnType{Kind: ast.T_NATIVE_FN},
+		}
+	} else {
+		fn = Evaluate(stmt.Call.Caller, env)
+	}
+
+	if !IsFunction(fn) {
+		runtimeError(env, stmt.Call.StartPos, stmt.Call.EndPos, fmt.Sprintf("could not defer the call. %s not a function", stmt.Call.Caller.Identifier)).Throw()
+	}
+
+	scope.deferred.calls = append(scope.deferred.calls, deferredCall{fn: fn, args: args, expr: stmt.Call, env: env})
+
+	return MakeVOID()
+}
+
+// runDeferredCalls makes the deferred calls of a function, the last registered first
+func runDeferredCalls(scope *Environment) {
+	for i := len(scope.deferred.calls) - 1; i >= 0; i-- {
+		call := scope.deferred.calls[i]
+		result := callFunction(call.fn, call.args, call.expr, call.env)
+		checkUnhandledError(call.expr, result, call.env)
+	}
+}
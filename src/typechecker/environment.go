@@ -12,16 +12,42 @@ type Environment struct {
 	constants map[string]bool
 	//user defined types declared with struct keyword
 	structs map[string]RuntimeValue
-	parser    *parser.Parser
+	//traits declared with the trait keyword
+	traits map[string]ast.TraitDeclStatement
+	//declared types of the variables that have one. A variable declared with T? can hold null
+	types map[string]ast.Type
+	//types of variables declared without a value, inferred by the flow analysis. Keyed by the declaration index
+	inferredTypes map[int]ast.Type
+	//static types of the expressions whose value is converted to the type of another one, like the branches of ?:,
+	//recorded by the flow analysis because the other one is not evaluated
+	expressionTypes map[expressionSpan]ast.Type
+	parser          *parser.Parser
+	//functions that are running, shared with the parent environment
+	calls *callStack
+	//calls registered with defer. Only the scope of a function call has them
+	deferred *deferredCalls
+	//return type of the function, in the scope of a function call
+	returnType ast.Type
+	//loads the imported modules. Only the environment of a program or a module has it
+	modules *moduleLoader
 }
 
 func NewEnvironment(parent *Environment, p *parser.Parser) *Environment {
+	calls := &callStack{}
+	if parent != nil {
+		calls = parent.calls
+	}
 	return &Environment{
-		parent:    parent,
-		variables: make(map[string]RuntimeValue),
-		constants: make(map[string]bool),
-		structs:   make(map[string]RuntimeValue),
-		parser:    p,
+		parent:          parent,
+		variables:       make(map[string]RuntimeValue),
+		constants:       make(map[string]bool),
+		types:           make(map[string]ast.Type),
+		structs:         make(map[string]RuntimeValue),
+		traits:          make(map[string]ast.TraitDeclStatement),
+		inferredTypes:   make(map[int]ast.Type),
+		expressionTypes: make(map[expressionSpan]ast.Type),
+		parser:          p,
+		calls:           calls,
 	}
 }
 
@@ -31,18 +57,22 @@ func (e *Environment) DeclareVariable(name string, value RuntimeValue, isConstan
 		return nil, fmt.Errorf("variable %s already declared in this scope", name)
 	}
 
-	switch v := value.(type){
+	switch v := value.(type) {
 	case StructInstance:
 		// check all fields are initialized
-		structDeclaration := e.structs[v.StructName]
+		structDeclaration, err := e.GetStructType(v.StructName)
+
+		if err != nil {
+			return nil, err
+		}
 
 		for _, field := range structDeclaration.(StructValue).Fields {
 			if v.Fields[field.Name] == nil {
 				return nil, fmt.Errorf("field '%s' of struct '%s' is not initialized", field.Name, v.StructName)
 			} else {
 				// check type compatibility
-				if GetRuntimeType(v.Fields[field.Name]) != field.Type.IType() {
-					return nil, fmt.Errorf("field '%s' of struct '%s' is of type %s, but got %s", field.Name, v.StructName, field.Type.IType(), GetRuntimeType(v.Fields[field.Name]))
+				if !matchesType(v.Fields[field.Name], field.Type) {
+					return nil, fmt.Errorf("field '%s' of struct '%s' is of type %s, but got %s", field.Name, v.StructName, typeName(field.Type), valueTypeName(v.Fields[field.Name]))
 				}
 			}
 		}
@@ -72,21 +102,37 @@ func (e *Environment) AssignVariable(name string, value RuntimeValue) (RuntimeVa
 	// check type compatibility
 	variable := env.variables[name]
 
-	if !((IsBothINT(variable, value) || IsBothFLOAT(variable, value)) || (GetRuntimeType(variable) == GetRuntimeType(value))) {
-		return nil, fmt.Errorf("cannot assign value %v of type %s to %s", value, GetRuntimeType(value), GetRuntimeType(variable))
-	} else {
-		switch t := variable.(type) {
-		case IntegerValue:
-			if t.Size < value.(IntegerValue).Size {
-				return nil, fmt.Errorf("potential data loss. %d bit value cannot be assigned to %s of size %d. You can try type casting", value.(IntegerValue).Size, t.Type.IType(), t.Size)
-			}
-		case FloatValue:
-			if t.Size < value.(FloatValue).Size {
-				return nil, fmt.Errorf("potential data loss. %d bit value cannot be assigned to %s of size %d. You can try type casting", value.(FloatValue).Size, t.Type.IType(), t.Size)
-			}
+	// a variable declared with a struct type but no value holds the struct declaration until it is assigned
+	if structValue, ok := variable.(StructValue); ok {
+		if instance, ok := value.(StructInstance); ok && instance.StructName == structValue.Type.(ast.StructType).Name {
+			env.variables[name] = value
+			return value, nil
 		}
 	}
 
+	if declared, ok := env.types[name]; ok {
+		value, err = convertImplicitly(value, declared, false)
+		if err != nil {
+			return nil, err
+		}
+		if !matchesType(value, declared) {
+			return nil, fmt.Errorf("cannot assign value of type '%s' to %s of type '%s'", valueTypeName(value), name, typeName(declared))
+		}
+		env.variables[name] = value
+		return value, nil
+	}
+
+	// numbers are widened to the type of the variable when no data can be lost
+	value, err = convertImplicitly(value, typeOfValue(variable), false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !((IsBothINT(variable, value) || IsBothFLOAT(variable, value)) || (GetRuntimeType(variable) == GetRuntimeType(value))) {
+		return nil, fmt.Errorf("cannot assign value of type '%s' to %s of type '%s'", valueTypeName(value), name, valueTypeName(variable))
+	}
+
 	env.variables[name] = value
 
 	return value, nil
@@ -105,7 +151,7 @@ func (e *Environment) DeclareFunction(name string, returnType ast.Type, paramete
 		Type: ast.FunctionType{
 			Kind: ast.T_FUNCTION,
 		},
-		ReturnType: returnType,
+		ReturnType:     returnType,
 		DeclarationEnv: e,
 	}
 
@@ -141,7 +187,7 @@ func (e *Environment) ResolveVariable(name string) (*Environment, error) {
 }
 
 func (e *Environment) GetStructType(name string) (RuntimeValue, error) {
-	
+
 	if _, ok := e.structs[name]; ok {
 		return e.structs[name], nil
 	}
@@ -173,3 +219,59 @@ func (e *Environment) HasVariable(name string) bool {
 	}
 	return e.parent.HasVariable(name)
 }
+
+// setDeclaredType records the type a variable was declared with. Assignments must match it
+func (e *Environment) setDeclaredType(name string, t ast.Type) {
+	e.types[name] = t
+}
+
+// getDeclaredType returns the declared type of a variable, or nil if it was declared without one
+func (e *Environment) getDeclaredType(name string) ast.Type {
+	env, err := e.ResolveVariable(name)
+	if err != nil {
+		return nil
+	}
+	return env.types[name]
+}
+
+func (e *Environment) setInferredType(declarationIndex int, t ast.Type) {
+	if e.parent != nil {
+		e.parent.setInferredType(declarationIndex, t)
+		return
+	}
+	e.inferredTypes[declarationIndex] = t
+}
+
+func (e *Environment) getInferredType(declarationIndex int) ast.Type {
+	if e.parent != nil {
+		return e.parent.getInferredType(declarationIndex)
+	}
+	return e.inferredTypes[declarationIndex]
+}
+
+// expressionSpan identifies an expression of a file by the positions where it starts and ends
+type expressionSpan struct {
+	start int
+	end   int
+}
+
+func spanOf(expr ast.Expression) expressionSpan {
+	start, end := expr.GetPos()
+	return expressionSpan{start.Index, end.Index}
+}
+
+func (e *Environment) setExpressionType(expr ast.Expression, t ast.Type) {
+	if e.parent != nil {
+		e.parent.setExpressionType(expr, t)
+		return
+	}
+	e.expressionTypes[spanOf(expr)] = t
+}
+
+// getExpressionType returns the type recorded by the flow analysis, or nil if the type is not known
+func (e *Environment) getExpressionType(expr ast.Expression) ast.Type {
+	if e.parent != nil {
+		return e.parent.getExpressionType(expr)
+	}
+	return e.expressionTypes[spanOf(expr)]
+}
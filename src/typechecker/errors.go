@@ -1,6 +1,125 @@
 package typechecker
 
-import "fmt"
+import (
+	"fmt"
+	"runtime"
+	"walrus/frontend/ast"
+	"walrus/frontend/lexer"
+	"walrus/frontend/parser"
+	"walrus/utils"
+)
 
 var errorDivisionByZero error = fmt.Errorf("division by zero is forbidden")
 var invalidOperationMsg string = "cannot evaluate numeric operation. unsupported operator %v"
+
+// callFrame is a call of a function, with the position of the call in the source
+type callFrame struct {
+	function string
+	parser   *parser.Parser
+	start    lexer.Position
+	end      lexer.Position
+}
+
+// callStack holds the functions that are running. It is shared by all the environments of a program
+type callStack struct {
+	frames []callFrame
+}
+
+func (s *callStack) push(frame callFrame) {
+	s.frames = append(s.frames, frame)
+}
+
+func (s *callStack) pop() {
+	s.frames = s.frames[:len(s.frames)-1]
+}
+
+// snapshot copies the frames, the stack changes while the error goes up to its handler
+func (s *callStack) snapshot() []callFrame {
+	return append([]callFrame{}, s.frames...)
+}
+
+// RuntimeError stops the program unless a recover statement handles it.
+// It keeps the calls that were running when it was raised, to show them in the stack trace
+type RuntimeError struct {
+	Message string
+	report  *parser.ErrorMessage
+	stack   []callFrame
+}
+
+// runtimeError creates an error at a position of the running program. Throw raises it
+func runtimeError(env *Environment, start lexer.Position, end lexer.Position, message string) *RuntimeError {
+	return &RuntimeError{
+		Message: message,
+		report:  parser.MakeError(env.parser, start.Line, env.parser.FilePath, start, end, message),
+		stack:   env.calls.snapshot(),
+	}
+}
+
+func (e *RuntimeError) AddHint(text string, kind parser.HintKind) *RuntimeError {
+	e.report.AddHint(text, kind)
+	return e
+}
+
+func (e *RuntimeError) Throw() {
+	panic(e)
+}
+
+func (e *RuntimeError) Error() string {
+	return e.Message
+}
+
+// Trace returns the error with the calls that led to it, the most recent call first
+func (e *RuntimeError) Trace() string {
+	trace := e.report.String() + "\n"
+	for i := len(e.stack) - 1; i >= 0; i-- {
+		frame := e.stack[i]
+		trace += parser.MakeTrace(frame.parser, frame.start, frame.end, fmt.Sprintf("in call to '%s'", frame.function))
+	}
+	return trace
+}
+
+// asRuntimeError converts a panic of the interpreter, like a failed type assertion, to a runtime error at the given position.
+// It returns nil for the panics that are not errors of the running program
+func asRuntimeError(recovered any, env *Environment, start lexer.Position, end lexer.Position) *RuntimeError {
+	switch r := recovered.(type) {
+	case *RuntimeError:
+		return r
+	case runtime.Error:
+		return runtimeError(env, start, end, fmt.Sprintf("internal error: %s", r.Error()))
+	default:
+		return nil
+	}
+}
+
+// reportUncaught prints an error that no recover statement handled and stops the program
+func reportUncaught(err *RuntimeError) {
+	fmt.Print(err.Trace())
+	fmt.Println(utils.Colorize(utils.RED, "uncaught runtime error"))
+	panic("Error")
+}
+
+// EvaluateRecoverStmt runs the handler if a runtime error stops the block. The handler gets the error as a value
+func EvaluateRecoverStmt(stmt ast.RecoverStmt, env *Environment) (result RuntimeValue) {
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		start, end := stmt.Block.GetPos()
+		err := asRuntimeError(r, env, start, end)
+		if err == nil {
+			panic(r)
+		}
+
+		scope := NewEnvironment(env, env.parser)
+		if stmt.ErrorName != "" {
+			scope.DeclareVariable(stmt.ErrorName, MakeERROR(err.Message), true)
+		}
+
+		result = EvaluateBlockStmt(stmt.Handler, scope)
+	}()
+
+	return EvaluateBlockStmt(stmt.Block, env)
+}
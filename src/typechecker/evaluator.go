@@ -2,9 +2,12 @@ package typechecker
 
 import (
 	"fmt"
+	"math/big"
+	"sort"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 	"walrus/frontend/ast"
-	"walrus/frontend/parser"
 )
 
 func GetRuntimeType(runtimeValue RuntimeValue) ast.DATA_TYPE {
@@ -21,6 +24,8 @@ func GetRuntimeType(runtimeValue RuntimeValue) ast.DATA_TYPE {
 		return t.Type.IType()
 	case NullValue:
 		return t.Type.IType()
+	case ErrorValue:
+		return t.Type.IType()
 	case VoidValue:
 		return t.Type.IType()
 	case FunctionValue:
@@ -31,6 +36,18 @@ func GetRuntimeType(runtimeValue RuntimeValue) ast.DATA_TYPE {
 		return t.Type.IType()
 	case StructInstance:
 		return ast.DATA_TYPE(t.StructName)
+	case ArrayValue:
+		return t.Type.IType()
+	case TupleValue:
+		return t.Type.IType()
+	case MapValue:
+		return t.Type.IType()
+	case SetValue:
+		return t.Type.IType()
+	case RangeValue:
+		return t.Type.IType()
+	case TypeValue:
+		return t.Type.IType()
 	default:
 		panic(fmt.Sprintf("This runtime value is not implemented yet: %T", runtimeValue))
 	}
@@ -40,7 +57,7 @@ func GetNumericValue(runtimeValue RuntimeValue) (float64, error) {
 	//cast to float64
 	switch t := runtimeValue.(type) {
 	case IntegerValue:
-		return float64(t.Value), nil
+		return t.float(), nil
 	case FloatValue:
 		return t.Value, nil
 	case BooleanValue:
@@ -58,7 +75,8 @@ func GetNumericValue(runtimeValue RuntimeValue) (float64, error) {
 
 func IsINT(runtimeValue RuntimeValue) bool {
 	switch GetRuntimeType(runtimeValue) {
-	case ast.T_INTEGER8, ast.T_INTEGER16, ast.T_INTEGER32, ast.T_INTEGER64:
+	case ast.T_INTEGER8, ast.T_INTEGER16, ast.T_INTEGER32, ast.T_INTEGER64, ast.T_INTEGER128, ast.T_BIGINT,
+		ast.T_UNSIGNED8, ast.T_UNSIGNED16, ast.T_UNSIGNED32, ast.T_UNSIGNED64, ast.T_UNSIGNED128:
 		return true
 	default:
 		return false
@@ -115,19 +133,104 @@ func IsFunction(value RuntimeValue) bool {
 	return GetRuntimeType(value) == ast.T_FUNCTION || GetRuntimeType(value) == ast.T_NATIVE_FN
 }
 
+// structText returns Name { field: value, ... }. The fields are in the order of their names
+func structText(instance StructInstance) (StringValue, error) {
+	names := make([]string, 0, len(instance.Fields))
+	for name := range instance.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]string, len(names))
+	for i, name := range names {
+		text, err := CastToStringValue(instance.Fields[name])
+		if err != nil {
+			return StringValue{}, err
+		}
+		fields[i] = name + ": " + text.Value
+	}
+
+	if len(fields) == 0 {
+		return MakeSTRING(instance.StructName + " {}"), nil
+	}
+	return MakeSTRING(instance.StructName + " { " + strings.Join(fields, ", ") + " }"), nil
+}
+
 func CastToStringValue(value RuntimeValue) (StringValue, error) {
 
 	switch t := value.(type) {
 	case StringValue:
 		return t, nil
 	case IntegerValue:
-		return MakeSTRING(strconv.FormatInt(t.Value, 10)), nil
+		return MakeSTRING(t.String()), nil
 	case FloatValue:
 		return MakeSTRING(strconv.FormatFloat(t.Value, 'f', -1, 64)), nil
 	case BooleanValue:
 		return MakeSTRING(strconv.FormatBool(t.Value)), nil
 	case CharacterValue:
 		return MakeSTRING(string(t.Value)), nil
+	case ArrayValue:
+		elements := make([]string, len(t.Elements))
+		for i, element := range t.Elements {
+			text, err := CastToStringValue(element)
+			if err != nil {
+				return StringValue{}, err
+			}
+			elements[i] = text.Value
+		}
+		return MakeSTRING("[" + strings.Join(elements, ", ") + "]"), nil
+	case TupleValue:
+		elements := make([]string, len(t.Elements))
+		for i, element := range t.Elements {
+			text, err := CastToStringValue(element)
+			if err != nil {
+				return StringValue{}, err
+			}
+			elements[i] = text.Value
+		}
+		return MakeSTRING("(" + strings.Join(elements, ", ") + ")"), nil
+	case MapValue:
+		entries := make([]string, len(t.Entries.keys))
+		for i, key := range t.Entries.keys {
+			keyText, err := CastToStringValue(key)
+			if err != nil {
+				return StringValue{}, err
+			}
+			valueText, err := CastToStringValue(t.Entries.values[i])
+			if err != nil {
+				return StringValue{}, err
+			}
+			entries[i] = keyText.Value + ": " + valueText.Value
+		}
+		return MakeSTRING("{" + strings.Join(entries, ", ") + "}"), nil
+	case SetValue:
+		elements := make([]string, len(t.Entries.keys))
+		for i, element := range t.Entries.keys {
+			text, err := CastToStringValue(element)
+			if err != nil {
+				return StringValue{}, err
+			}
+			elements[i] = text.Value
+		}
+		return MakeSTRING("{" + strings.Join(elements, ", ") + "}"), nil
+	case RangeValue:
+		return MakeSTRING(t.String()), nil
+	case TypeValue:
+		return MakeSTRING(typeName(t.Of)), nil
+	case ErrorValue:
+		return MakeSTRING("error: " + t.Message), nil
+	case StructInstance:
+		return structText(t)
+	case NullValue:
+		return MakeSTRING("null"), nil
+	case VoidValue:
+		return MakeSTRING("void"), nil
+	case FunctionValue:
+		return MakeSTRING("fn " + t.Name), nil
+	case NativeFunctionValue:
+		return MakeSTRING("native fn"), nil
+	case StructValue:
+		return MakeSTRING("struct " + typeName(t.Type)), nil
 	default:
 		return StringValue{}, fmt.Errorf("cannot cast %T to string", value)
 	}
@@ -138,22 +241,22 @@ func Evaluate(astNode ast.Node, env *Environment) RuntimeValue {
 	case ast.NumericLiteral:
 		// Check if the number is an integer or a float
 		if node.BaseStmt.Kind == ast.INTEGER_LITERAL {
-			val, _ := strconv.ParseInt(node.Value, 10, int(node.BitSize))
-			return MakeINT(val, node.BitSize, true)
+			return evaluateIntegerLiteral(node, false, env)
 		} else if node.BaseStmt.Kind == ast.FLOAT_LITERAL {
-			val, _ := strconv.ParseFloat(node.Value, 64)
+			val, _ := strconv.ParseFloat(node.Value, int(node.BitSize))
 			return MakeFLOAT(val, node.BitSize)
 		} else {
-			parser.MakeError(env.parser, node.StartPos.Line, env.parser.FilePath, node.StartPos, node.EndPos, "invalid numeric literal").Display()
+			runtimeError(env, node.StartPos, node.EndPos, "invalid numeric literal").Throw()
 			return nil
 		}
 	case ast.StringLiteral:
 		return MakeSTRING(node.Value)
 	case ast.CharacterLiteral:
-		if len(node.Value) > 1 {
-			parser.MakeError(env.parser, node.StartPos.Line, env.parser.FilePath, node.StartPos, node.EndPos, "character literals can only have one character").Display()
+		if utf8.RuneCountInString(node.Value) != 1 {
+			runtimeError(env, node.StartPos, node.EndPos, "character literals can only have one character").Throw()
 		}
-		return MakeCHAR(node.Value[0])
+		char, _ := utf8.DecodeRuneInString(node.Value)
+		return MakeCHAR(char)
 	case ast.BooleanLiteral:
 		return MakeBOOL(node.Value)
 	case ast.NullLiteral:
@@ -184,15 +287,78 @@ func Evaluate(astNode ast.Node, env *Environment) RuntimeValue {
 		return EvaluateReturnStmt(node, env)
 	case ast.StructDeclStatement:
 		return EvaluateStructDeclarationStmt(node, env)
+	case ast.TraitDeclStatement:
+		return EvaluateTraitDeclarationStmt(node, env)
 	case ast.StructLiteral:
 		return EvaluateStructLiteral(node, env)
 	case ast.StructPropertyExpr:
 		return EvaluateStructPropertyExpr(node, env)
+	case ast.TypeCastExpr:
+		return EvaluateTypeCastExpr(node, env)
+	case ast.ArrayLiterals:
+		return EvaluateArrayLiteral(node, nil, env)
+	case ast.ArrayIndexAccess:
+		return EvaluateArrayIndexAccess(node, env)
+	case ast.PostfixExpr:
+		return EvaluatePostfixExpr(node, env)
+	case ast.TernaryExpr:
+		return EvaluateTernaryExpr(node, env)
+	case ast.TupleExpr:
+		return EvaluateTupleExpr(node, nil, env)
+	case ast.MapLiteral:
+		return EvaluateMapLiteral(node, env)
+	case ast.SetLiteral:
+		return EvaluateSetLiteral(node, env)
+	case ast.DestructuringDclStmt:
+		return EvaluateDestructuringDclStmt(node, env)
+	case ast.WhileLoopStmt:
+		return EvaluateWhileLoopStmt(node, env)
+	case ast.ForStmt:
+		return EvaluateForStmt(node, env)
+	case ast.ForeachStmt:
+		return EvaluateForeachStmt(node, env)
+	case ast.BreakStmt:
+		return BreakValue{Label: node.Label}
+	case ast.ContinueStmt:
+		return ContinueValue{Label: node.Label}
+	case ast.DeferStmt:
+		return EvaluateDeferStmt(node, env)
+	case ast.RecoverStmt:
+		return EvaluateRecoverStmt(node, env)
+	case ast.TryExpr:
+		return EvaluateTryExpr(node, env)
+	case ast.CatchExpr:
+		return EvaluateCatchExpr(node, env)
+	case ast.RangeExpr:
+		return EvaluateRangeExpr(node, env)
+	case ast.MethodCallExpr:
+		return EvaluateMethodCallExpr(node, env)
+	case ast.TypeofExpr:
+		return EvaluateTypeofExpr(node, env)
+	case ast.SwitchStmt:
+		return EvaluateSwitchStmt(node, env)
 	default:
 		panic(fmt.Sprintf("This ast node is not implemented yet: %v", node))
 	}
 }
 
+// evaluateIntegerLiteral creates the value of an integer literal, negated if it has a minus sign in front of it.
+// The parser has already checked the literal against its type
+func evaluateIntegerLiteral(node ast.NumericLiteral, negative bool, env *Environment) RuntimeValue {
+	val, ok := new(big.Int).SetString(node.Value, 10)
+	if !ok {
+		runtimeError(env, node.StartPos, node.EndPos, fmt.Sprintf("invalid integer literal %s", node.Value)).Throw()
+	}
+	if negative {
+		val.Neg(val)
+	}
+	integer, err := makeINTFromBig(val, node.BitSize, node.IsSigned)
+	if err != nil {
+		runtimeError(env, node.StartPos, node.EndPos, err.Error()).Throw()
+	}
+	return integer
+}
+
 func HasStruct(name string, env *Environment) bool {
 	// if not found in the current scope, check the parent scope
 	if _, ok := env.structs[name]; ok {
@@ -205,3 +371,15 @@ func HasStruct(name string, env *Environment) bool {
 
 	return false
 }
+
+func HasTrait(name string, env *Environment) bool {
+	if _, ok := env.traits[name]; ok {
+		return true
+	}
+
+	if env.parent != nil {
+		return HasTrait(name, env.parent)
+	}
+
+	return false
+}
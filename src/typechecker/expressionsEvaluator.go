@@ -2,6 +2,8 @@ package typechecker
 
 import (
 	"fmt"
+	"math"
+	"math/big"
 	"walrus/frontend/ast"
 	"walrus/frontend/lexer"
 	"walrus/frontend/parser"
@@ -13,56 +15,51 @@ func EvaluateIdenitifierExpr(expr ast.IdentifierExpr, env *Environment) RuntimeV
 
 		msg := fmt.Sprintf("variable %v is not declared in this scope\n", expr.Identifier)
 
-		parser.MakeError(env.parser, expr.StartPos.Line, env.parser.FilePath, expr.StartPos, expr.EndPos, msg).Display()
+		runtimeError(env, expr.StartPos, expr.EndPos, msg).Throw()
 	}
 
 	runtimeVal, err := env.GetRuntimeValue(expr.Identifier)
 
 	if err != nil {
-		parser.MakeError(env.parser, expr.StartPos.Line, env.parser.FilePath, expr.StartPos, expr.EndPos, err.Error()).Display()
+		runtimeError(env, expr.StartPos, expr.EndPos, err.Error()).Throw()
 	}
 
 	return runtimeVal
 }
 
 func EvaluateUnaryExpression(unary ast.UnaryExpr, env *Environment) RuntimeValue {
+	// -128i8 is a valid literal even though 128 does not fit in i8
+	if literal, ok := unary.Argument.(ast.NumericLiteral); ok && unary.Operator.Value == "-" && literal.Kind == ast.INTEGER_LITERAL {
+		return evaluateIntegerLiteral(literal, true, env)
+	}
+
+	if unary.Operator.Value == "++" || unary.Operator.Value == "--" {
+		// the value of ++x is the updated value
+		_, updated := evaluateIncrement(unary.Argument, unary.Operator, env)
+		return updated
+	}
+
 	// Evaluate the unary argument expression
 	expr := Evaluate(unary.Argument, env)
 
-	// Error message for unsupported unary operations
-	errMsg := fmt.Errorf("unsupported unary operation for type %v", expr)
-
 	// Switch based on the unary operator value
 	switch unary.Operator.Value {
 	case "-", "+":
-		return handleUnaryAdditive(expr, unary, errMsg)
+		return handleUnaryAdditive(expr, unary, env)
 	case "!":
 		// Handle unary logical NOT operator
-		return handleUnaryNegation(expr, errMsg)
-
-	case "++", "--":
-		// Handle pre-increment and pre-decrement operators
-		if !helpers.TypesMatchT[IntegerValue](expr) {
-			panic(errMsg)
-		}
+		return handleUnaryNegation(expr, unary, env)
 
-		value := expr.(IntegerValue).Value
-		if unary.Operator.Value == "++" {
-			value++
-		} else {
-			value--
+	case "~":
+		integer, ok := expr.(IntegerValue)
+		if !ok {
+			runtimeError(env, unary.StartPos, unary.EndPos, fmt.Sprintf("operator ~ needs an integer operand, got %v", GetRuntimeType(expr))).Throw()
 		}
-
-		runtimeVal := MakeINT(value, 32, true)
-
-		// Assign the incremented or decremented value if the argument is an identifier
-		if idExpr, ok := unary.Argument.(ast.IdentifierExpr); ok {
-			if env.HasVariable(idExpr.Identifier) {
-				env.AssignVariable(idExpr.Identifier, runtimeVal)
-			}
+		result, err := bitwiseNot(integer)
+		if err != nil {
+			runtimeError(env, unary.StartPos, unary.EndPos, err.Error()).Throw()
 		}
-
-		return runtimeVal
+		return result
 
 	default:
 		// Default case for unsupported unary operators
@@ -70,9 +67,14 @@ func EvaluateUnaryExpression(unary ast.UnaryExpr, env *Environment) RuntimeValue
 	}
 }
 
-func handleUnaryNegation(expr RuntimeValue, errMsg error) RuntimeValue {
+// unsupportedUnaryError stops the program on a unary operator that does not apply to the type of its operand
+func unsupportedUnaryError(expr RuntimeValue, unary ast.UnaryExpr, env *Environment) {
+	runtimeError(env, unary.StartPos, unary.EndPos, fmt.Sprintf("operator %s cannot be applied to a value of type %s", unary.Operator.Value, valueTypeName(expr))).Throw()
+}
+
+func handleUnaryNegation(expr RuntimeValue, unary ast.UnaryExpr, env *Environment) RuntimeValue {
 	if !helpers.TypesMatchT[BooleanValue](expr) {
-		panic(errMsg)
+		unsupportedUnaryError(expr, unary, env)
 	}
 
 	return BooleanValue{
@@ -81,29 +83,83 @@ func handleUnaryNegation(expr RuntimeValue, errMsg error) RuntimeValue {
 	}
 }
 
-func handleUnaryAdditive(expr RuntimeValue, unary ast.UnaryExpr, errMsg error) RuntimeValue {
+func handleUnaryAdditive(expr RuntimeValue, unary ast.UnaryExpr, env *Environment) RuntimeValue {
 	// Handle unary minus and plus operators
-	if !helpers.TypesMatchT[IntegerValue](expr) {
-		panic(errMsg)
+	switch value := expr.(type) {
+	case IntegerValue:
+		if unary.Operator.Value == "+" {
+			return value
+		}
+		// the operand keeps its type, so negating an unsigned value or the smallest signed value overflows
+		result, err := makeINTFromBig(new(big.Int).Neg(value.bigValue()), value.Size, value.isSigned())
+		if err != nil {
+			runtimeError(env, unary.StartPos, unary.EndPos, err.Error()).Throw()
+		}
+		return result
+	case FloatValue:
+		if unary.Operator.Value == "-" {
+			return MakeFLOAT(-value.Value, value.Size)
+		}
+		return value
+	default:
+		unsupportedUnaryError(expr, unary, env)
+		return nil
 	}
+}
 
-	value := expr.(IntegerValue).Value
-	if unary.Operator.Value == "-" {
-		value = -value
+func EvaluateBinaryExpr(binop ast.BinaryExpr, env *Environment) RuntimeValue {
+
+	// the right side of &&, || and ?? is evaluated only when it is needed
+	switch binop.Operator.Kind {
+	case lexer.AND_TOKEN, lexer.OR_TOKEN:
+		return evaluateLogicalExpr(binop, env)
+	case lexer.NULLISH_TOKEN:
+		return evaluateNullishExpr(binop, env)
+	}
+
+	var left, right RuntimeValue
+
+	// a constant used with a bigint is evaluated as a bigint, constants have no side effects to order
+	switch {
+	case isNumericConstant(binop.Left) && !isNumericConstant(binop.Right):
+		right = Evaluate(binop.Right, env)
+		left = evaluateAs(binop.Left, typeOfValue(right), env)
+	case isNumericConstant(binop.Right) && !isNumericConstant(binop.Left):
+		left = Evaluate(binop.Left, env)
+		right = evaluateAs(binop.Right, typeOfValue(left), env)
+	default:
+		left = Evaluate(binop.Left, env)
+		right = Evaluate(binop.Right, env)
 	}
 
-	return MakeINT(value, 32, true)
+	return evaluateBinaryValues(left, right, binop, env)
 }
 
-func EvaluateBinaryExpr(binop ast.BinaryExpr, env *Environment) RuntimeValue {
+// evaluateBinaryValues applies a binary operator to operands that are already evaluated
+func evaluateBinaryValues(left RuntimeValue, right RuntimeValue, binop ast.BinaryExpr, env *Environment) RuntimeValue {
 
-	left := Evaluate(binop.Left, env)
-	right := Evaluate(binop.Right, env)
+	// the shift count does not change the type of the shifted value
+	if binop.Operator.Value != "<<" && binop.Operator.Value != ">>" {
+		left, right = adaptIntegerOperands(left, right, binop, env)
+		left, right = adaptFloatOperands(left, right, binop, env)
+	}
 
 	switch binop.Operator.Value {
 	// Arithmetic operators
-	case "+", "-", "*", "/", "%", "^":
+	case "+", "-", "*", "/", "%", "**":
 		return handleBinaryArithmeticExpr(left, right, binop, env)
+	// Bitwise operators
+	case "&", "|", "^", "<<", ">>":
+		leftInt, leftIsInt := left.(IntegerValue)
+		rightInt, rightIsInt := right.(IntegerValue)
+		if !leftIsInt || !rightIsInt {
+			handleBinaryExprError(fmt.Errorf("operator %s needs integer operands, got %v and %v", binop.Operator.Value, GetRuntimeType(left), GetRuntimeType(right)), binop, env)
+		}
+		result, err := evaluateBitwiseExpr(leftInt, rightInt, binop.Operator)
+		if err != nil {
+			handleBinaryExprError(err, binop, env)
+		}
+		return result
 	// Relational operators
 	case "==", "!=", ">", "<", ">=", "<=":
 		result, err := evaluateComparisonExpr(left, right, binop.Operator)
@@ -112,19 +168,6 @@ func EvaluateBinaryExpr(binop ast.BinaryExpr, env *Environment) RuntimeValue {
 		}
 		return result
 
-	// Logical operators
-	case "&&":
-		if IsTruthy(left) {
-			return right
-		}
-		return left
-
-	case "||":
-		if IsTruthy(left) {
-			return left
-		}
-		return right
-
 	default:
 		handleBinaryExprError(fmt.Errorf("unsupported operator: %v", binop.Operator.Value), binop, env)
 	}
@@ -132,6 +175,60 @@ func EvaluateBinaryExpr(binop ast.BinaryExpr, env *Environment) RuntimeValue {
 	return MakeNULL()
 }
 
+// adaptIntegerOperands gives an integer constant operand the type of the other integer operand
+func adaptIntegerOperands(left RuntimeValue, right RuntimeValue, binop ast.BinaryExpr, env *Environment) (RuntimeValue, RuntimeValue) {
+	leftInt, leftIsInt := left.(IntegerValue)
+	rightInt, rightIsInt := right.(IntegerValue)
+
+	if !leftIsInt || !rightIsInt {
+		return left, right
+	}
+
+	leftIsConstant := isNumericConstant(binop.Left)
+	rightIsConstant := isNumericConstant(binop.Right)
+
+	var err error
+
+	if leftIsConstant && !rightIsConstant {
+		left, err = adaptIntegerConstant(leftInt, binop.Left, rightInt, env)
+	} else if rightIsConstant && !leftIsConstant {
+		right, err = adaptIntegerConstant(rightInt, binop.Right, leftInt, env)
+	}
+
+	if err != nil {
+		handleBinaryExprError(err, binop, env)
+	}
+
+	return left, right
+}
+
+// adaptFloatOperands gives a float constant operand the size of the other float operand
+func adaptFloatOperands(left RuntimeValue, right RuntimeValue, binop ast.BinaryExpr, env *Environment) (RuntimeValue, RuntimeValue) {
+	leftFloat, leftIsFloat := left.(FloatValue)
+	rightFloat, rightIsFloat := right.(FloatValue)
+
+	if !leftIsFloat || !rightIsFloat {
+		return left, right
+	}
+
+	leftIsConstant := isNumericConstant(binop.Left)
+	rightIsConstant := isNumericConstant(binop.Right)
+
+	var err error
+
+	if leftIsConstant && !rightIsConstant {
+		left, err = convertImplicitly(leftFloat, rightFloat.Type, true)
+	} else if rightIsConstant && !leftIsConstant {
+		right, err = convertImplicitly(rightFloat, leftFloat.Type, true)
+	}
+
+	if err != nil {
+		handleBinaryExprError(err, binop, env)
+	}
+
+	return left, right
+}
+
 func handleBinaryArithmeticExpr(left RuntimeValue, right RuntimeValue, binop ast.BinaryExpr, env *Environment) RuntimeValue {
 
 	leftType := GetRuntimeType(left)
@@ -161,7 +258,7 @@ func handleBinaryArithmeticExpr(left RuntimeValue, right RuntimeValue, binop ast
 }
 
 func handleBinaryExprError(err error, binop ast.BinaryExpr, env *Environment) {
-	parser.MakeError(env.parser, binop.StartPos.Line, env.parser.FilePath, binop.Operator.StartPos, binop.Operator.EndPos, err.Error()).Display()
+	runtimeError(env, binop.Operator.StartPos, binop.Operator.EndPos, err.Error()).Throw()
 }
 
 func evaluateNumericArithmeticExpr(left RuntimeValue, right RuntimeValue, operator lexer.Token) (RuntimeValue, error) {
@@ -182,73 +279,28 @@ func evaluateNumericArithmeticExpr(left RuntimeValue, right RuntimeValue, operat
 
 func EvaluateAssignmentExpr(assignNode ast.AssignmentExpr, env *Environment) RuntimeValue {
 
-	var err error
-
-	var variableToAssign ast.IdentifierExpr
-	var variableNameString string
+	place := resolveReference(assignNode.Assigne, env)
 
-	switch assignNode.Assigne.(type) {
-	case ast.IdentifierExpr:
-		variableToAssign = assignNode.Assigne.(ast.IdentifierExpr)
-		variableNameString = variableToAssign.Identifier
-	case ast.StructPropertyExpr:
-		variableToAssign = assignNode.Assigne.(ast.StructPropertyExpr).Property
-
-		//check if object is an identifier
-		object, ok := assignNode.Assigne.(ast.StructPropertyExpr).Object.(ast.IdentifierExpr)
-		if !ok {
-			err = fmt.Errorf("invalid left-hand side in assignment expression. expected identifier got %s", assignNode.Assigne.(ast.StructPropertyExpr).Object.INodeType())
-			parser.MakeError(env.parser, assignNode.StartPos.Line, env.parser.FilePath, variableToAssign.StartPos, variableToAssign.EndPos, err.Error()).Display()
+	// x ??= value evaluates the value only if x is null
+	if assignNode.Operator.Kind == lexer.NULLISH_ASSIGNMENT_TOKEN {
+		if current := place.get(); !helpers.TypesMatchT[NullValue](current) {
+			return current
 		}
-
-		variableNameString = object.Identifier
-
-	default:
-		err = fmt.Errorf("invalid left-hand side in assignment expression")
-		parser.MakeError(env.parser, assignNode.StartPos.Line, env.parser.FilePath, variableToAssign.StartPos, variableToAssign.EndPos, err.Error()).Display()
-	}
-
-	//if assigne is any of "false", "true", "null";
-	if helpers.ContainsIn([]string{"false", "true", "null"}, variableToAssign.Identifier) {
-		err = fmt.Errorf("cannot assign to built-in constant %v", variableToAssign.Identifier)
-		parser.MakeError(env.parser, assignNode.StartPos.Line, env.parser.FilePath, variableToAssign.StartPos, variableToAssign.EndPos, err.Error()).Display()
-	}
-
-	currentValueOfIdentifier, err := env.GetRuntimeValue(variableNameString)
-
-	if err != nil {
-		valStart, valEnd := assignNode.Value.GetPos()
-		parser.MakeError(env.parser, assignNode.StartPos.Line, env.parser.FilePath, valStart, valEnd, err.Error()).Display()
 	}
 
-	valueToSet := Evaluate(assignNode.Value, env)
-
-	if helpers.TypesMatchT[StructInstance](currentValueOfIdentifier) {
-
-		// assign struct instance properties
-		// if object is declared in the current scope
-		if !env.HasVariable(variableNameString) {
-			err = fmt.Errorf("variable %v is not declared in this scope", variableNameString)
-			parser.MakeError(env.parser, assignNode.StartPos.Line, env.parser.FilePath, variableToAssign.StartPos, variableToAssign.EndPos, err.Error()).Display()
-		}
-
-		structInstance := env.variables[variableNameString]
-		i := structInstance.(StructInstance)
+	valueToSet := evaluateAs(assignNode.Value, typeOfValue(place.get()), env)
 
-		i.Fields[assignNode.Assigne.(ast.StructPropertyExpr).Property.Identifier] = valueToSet
-
-		env.structs[variableNameString] = i
-
-		return valueToSet
-	}
+	// a numeric constant takes the type of the place it is assigned to
+	isConstant := isNumericConstant(assignNode.Value)
 
 	switch assignNode.Operator.Kind {
-	case lexer.PLUS_EQUALS_TOKEN, lexer.MINUS_EQUALS_TOKEN, lexer.TIMES_EQUALS_TOKEN, lexer.DIVIDE_EQUALS_TOKEN, lexer.MODULO_EQUALS_TOKEN, lexer.POWER_EQUALS_TOKEN:
+	case lexer.PLUS_EQUALS_TOKEN, lexer.MINUS_EQUALS_TOKEN, lexer.TIMES_EQUALS_TOKEN, lexer.DIVIDE_EQUALS_TOKEN, lexer.MODULO_EQUALS_TOKEN, lexer.POWER_EQUALS_TOKEN,
+		lexer.BIT_AND_EQUALS_TOKEN, lexer.BIT_OR_EQUALS_TOKEN, lexer.XOR_EQUALS_TOKEN, lexer.SHIFT_LEFT_EQUALS_TOKEN, lexer.SHIFT_RIGHT_EQUALS_TOKEN:
 
 		//remove the = from the operator
 		opChar := assignNode.Operator.Value[:len(assignNode.Operator.Value)-1]
 
-		operationValue := EvaluateBinaryExpr(ast.BinaryExpr{
+		valueToSet = evaluateBinaryValues(place.get(), valueToSet, ast.BinaryExpr{
 			BaseStmt: ast.BaseStmt{
 				Kind:     ast.BINARY_EXPRESSION,
 				StartPos: assignNode.StartPos,
@@ -264,14 +316,15 @@ func EvaluateAssignmentExpr(assignNode ast.AssignmentExpr, env *Environment) Run
 			},
 		}, env)
 
-		valueToSet = operationValue
+		isConstant = false
 	}
 
-	runtimeVal, err := env.AssignVariable(variableToAssign.Identifier, valueToSet)
+	// the value of an assignment expression is the stored value, so a = b = 5 assigns 5 to both
+	runtimeVal, err := place.set(valueToSet, isConstant)
 
 	if err != nil {
 		start, end := assignNode.Value.GetPos()
-		parser.MakeError(env.parser, assignNode.StartPos.Line, env.parser.FilePath, start, end, err.Error()).Display()
+		runtimeError(env, start, end, err.Error()).Throw()
 	}
 
 	return runtimeVal
@@ -279,137 +332,76 @@ func EvaluateAssignmentExpr(assignNode ast.AssignmentExpr, env *Environment) Run
 
 func evaluateIntInt(left IntegerValue, right IntegerValue, operator lexer.Token) (RuntimeValue, error) {
 
-	highestBit := uint8(0)
+	size, signed, err := commonIntegerType(left, right)
 
-	if left.Size > right.Size {
-		highestBit = left.Size
-	} else {
-		highestBit = right.Size
+	if err != nil {
+		return nil, err
 	}
 
+	leftValue := left.bigValue()
+	rightValue := right.bigValue()
+	result := new(big.Int)
+
 	switch operator.Value {
 	case "+", "+=":
-		return MakeINT(left.Value+right.Value, highestBit, true), nil
+		result.Add(leftValue, rightValue)
 	case "-", "-=":
-		return MakeINT(left.Value-right.Value, highestBit, true), nil
+		result.Sub(leftValue, rightValue)
 	case "*", "*=":
-		return MakeINT(left.Value*right.Value, highestBit, true), nil
+		result.Mul(leftValue, rightValue)
 	case "/", "/=":
-		if right.Value == 0 {
+		if rightValue.Sign() == 0 {
 			return nil, errorDivisionByZero
 		}
-		return MakeINT(left.Value/right.Value, highestBit, true), nil
+		result.Quo(leftValue, rightValue)
 	case "%", "%=":
-		if right.Value == 0 {
+		if rightValue.Sign() == 0 {
 			return nil, errorDivisionByZero
 		}
-		return MakeINT(left.Value%right.Value, highestBit, true), nil
-	case "^":
+		result.Rem(leftValue, rightValue)
+	case "**":
 		//power operation
-		number := left.Value
-		power := right.Value
-
-		//use bit shifting to calculate power
-		result := int64(1)
-		for power > 0 {
-			if power&1 == 1 {
-				result *= number
-			}
-			number *= number
-			power >>= 1
+		result, err = integerPower(leftValue, rightValue, size, signed)
+		if err != nil {
+			return nil, err
 		}
-
-		return MakeINT(result, highestBit, true), nil
-
 	default:
 		return nil, fmt.Errorf(invalidOperationMsg, operator.Value)
 	}
-}
-
-func evaluateIntFloat(left IntegerValue, right FloatValue, operator lexer.Token) (RuntimeValue, error) {
-
-	highestBit := uint8(0)
 
-	if left.Size > right.Size {
-		highestBit = left.Size
-	} else {
-		highestBit = right.Size
+	if !fitsInInteger(result, size, signed) {
+		return nil, fmt.Errorf("integer overflow. %s %s %s does not fit in %s", leftValue, operator.Value, rightValue, integerTypeName(size, signed))
 	}
 
-	switch operator.Value {
-	case "+", "+=":
-		return MakeINT(int64(float64(left.Value)+right.Value), highestBit, true), nil
-	case "-", "-=":
-		return MakeINT(int64(float64(left.Value)-right.Value), highestBit, true), nil
-	case "*", "*=":
-		return MakeINT(int64(float64(left.Value)*right.Value), highestBit, true), nil
-	case "/", "/=":
-		if right.Value == 0 {
-			return nil, errorDivisionByZero
-		}
-		return MakeINT(int64(float64(left.Value)/right.Value), highestBit, true), nil
-	case "^":
-		//power operation
-		number := float64(left.Value)
-		power := right.Value
-
-		//use bit shifting to calculate power
-		result := 1.0
-		for power > 0 {
-			if int64(power)&1 == 1 {
-				result *= number
-			}
-			number *= number
-			power /= 2
-		}
+	return makeINTFromBig(result, size, signed)
+}
 
-		return MakeINT(int64(result), highestBit, true), nil
-	default:
-		return nil, fmt.Errorf(invalidOperationMsg, operator.Value)
-	}
+// evaluateIntFloat promotes the integer to a float, so the result is the same float whatever the order of the
+// operands, and it never wraps
+func evaluateIntFloat(left IntegerValue, right FloatValue, operator lexer.Token) (RuntimeValue, error) {
+	return evaluateFloatFloat(MakeFLOAT(left.float(), promotedFloatSize(left, right)), right, operator)
 }
 
 func evaluateFloatInt(left FloatValue, right IntegerValue, operator lexer.Token) (RuntimeValue, error) {
+	return evaluateFloatFloat(left, MakeFLOAT(right.float(), promotedFloatSize(right, left)), operator)
+}
 
-	highestBit := uint8(0)
+// promotedFloatSize is the size of the float an integer is promoted to: the size of the other float,
+// or 64 bits for an integer wider than it
+func promotedFloatSize(integer IntegerValue, float FloatValue) uint8 {
+	return promotedFloatBits(integer.Size, float.Size)
+}
 
-	if left.Size > right.Size {
-		highestBit = left.Size
-	} else {
-		highestBit = right.Size
+func promotedFloatBits(integerSize uint8, floatSize uint8) uint8 {
+	if integerSize == 0 || integerSize > floatSize {
+		return 64
 	}
+	return floatSize
+}
 
-	switch operator.Value {
-	case "+", "+=":
-		return MakeFLOAT(left.Value+float64(right.Value), highestBit), nil
-	case "-", "-=":
-		return MakeFLOAT(left.Value-float64(right.Value), highestBit), nil
-	case "*", "*=":
-		return MakeFLOAT(left.Value*float64(right.Value), highestBit), nil
-	case "/", "/=":
-		if right.Value == 0 {
-			return nil, fmt.Errorf("division by zero is forbidden")
-		}
-		return MakeFLOAT(left.Value/float64(right.Value), highestBit), nil
-	case "^":
-		//power operation
-		number := left.Value
-		power := float64(right.Value)
-
-		//use bit shifting to calculate power
-		result := 1.0
-		for power > 0 {
-			if int64(power)&1 == 1 {
-				result *= number
-			}
-			number *= number
-			power /= 2
-		}
-
-		return MakeFLOAT(result, highestBit), nil
-	default:
-		return nil, fmt.Errorf(invalidOperationMsg, operator.Value)
-	}
+// floatType returns the type of the floats of a size
+func floatType(size uint8) ast.FloatType {
+	return ast.FloatType{Kind: ast.DATA_TYPE(fmt.Sprintf("f%d", size)), BitSize: size}
 }
 
 func evaluateFloatFloat(left FloatValue, right FloatValue, operator lexer.Token) (RuntimeValue, error) {
@@ -434,20 +426,12 @@ func evaluateFloatFloat(left FloatValue, right FloatValue, operator lexer.Token)
 			return nil, fmt.Errorf("division by zero is forbidden")
 		}
 		return MakeFLOAT(left.Value/right.Value, highestBit), nil
-	case "^":
+	case "**":
 		//power operation
 		number := left.Value
 		power := right.Value
 
-		//use bit shifting to calculate power
-		result := 1.0
-		for power > 0 {
-			if int64(power)&1 == 1 {
-				result *= number
-			}
-			number *= number
-			power /= 2
-		}
+		result := math.Pow(number, power)
 
 		return MakeFLOAT(result, highestBit), nil
 	default:
@@ -456,6 +440,21 @@ func evaluateFloatFloat(left FloatValue, right FloatValue, operator lexer.Token)
 }
 
 func evaluateComparisonExpr(left RuntimeValue, right RuntimeValue, operator lexer.Token) (RuntimeValue, error) {
+	// any value can be compared with null
+	_, leftIsNull := left.(NullValue)
+	_, rightIsNull := right.(NullValue)
+
+	if leftIsNull || rightIsNull {
+		switch operator.Value {
+		case "==":
+			return MakeBOOL(leftIsNull && rightIsNull), nil
+		case "!=":
+			return MakeBOOL(leftIsNull != rightIsNull), nil
+		default:
+			return nil, fmt.Errorf("operator %v cannot be used with null", operator.Value)
+		}
+	}
+
 	// Handle string comparison
 	if GetRuntimeType(left) == ast.T_STRING && GetRuntimeType(right) == ast.T_STRING {
 		switch operator.Value {
@@ -468,6 +467,47 @@ func evaluateComparisonExpr(left RuntimeValue, right RuntimeValue, operator lexe
 		}
 	}
 
+	// types are equal if they have the same name
+	leftType, leftIsType := left.(TypeValue)
+	rightType, rightIsType := right.(TypeValue)
+
+	if leftIsType && rightIsType {
+		switch operator.Value {
+		case "==":
+			return MakeBOOL(typeName(leftType.Of) == typeName(rightType.Of)), nil
+		case "!=":
+			return MakeBOOL(typeName(leftType.Of) != typeName(rightType.Of)), nil
+		default:
+			return nil, fmt.Errorf("operator %v cannot be used with types", operator.Value)
+		}
+	}
+
+	if leftIsType || rightIsType {
+		return nil, fmt.Errorf("cannot compare a value of type '%s' with a value of type '%s'", valueTypeName(left), valueTypeName(right))
+	}
+
+	// Integers are compared exactly, whatever their size
+	leftInt, leftIsInt := left.(IntegerValue)
+	rightInt, rightIsInt := right.(IntegerValue)
+
+	if leftIsInt && rightIsInt {
+		cmp := leftInt.bigValue().Cmp(rightInt.bigValue())
+		switch operator.Value {
+		case ">":
+			return MakeBOOL(cmp > 0), nil
+		case "<":
+			return MakeBOOL(cmp < 0), nil
+		case ">=":
+			return MakeBOOL(cmp >= 0), nil
+		case "<=":
+			return MakeBOOL(cmp <= 0), nil
+		case "==":
+			return MakeBOOL(cmp == 0), nil
+		case "!=":
+			return MakeBOOL(cmp != 0), nil
+		}
+	}
+
 	// Handle numeric comparison
 	leftValue, err := GetNumericValue(left)
 	if err != nil {
@@ -492,31 +532,39 @@ func evaluateComparisonExpr(left RuntimeValue, right RuntimeValue, operator lexe
 		return MakeBOOL(leftValue == rightValue), nil
 	case "!=":
 		return MakeBOOL(leftValue != rightValue), nil
-	case "&&":
-		// Logical AND operator
-		return evaluateLogicalAND(leftValue, rightValue), nil
-	case "||":
-		// Logical OR operator
-		return evaluateLogicalOR(leftValue, rightValue), nil
 	default:
 		return nil, fmt.Errorf("unsupported operator %v for comparison", operator.Value)
 	}
 }
 
-func evaluateLogicalAND(left float64, right float64) RuntimeValue {
-	// Logical AND evaluates to the last truthy value if all are true, otherwise false
-	if left == 1 && right == 1 {
+// evaluateLogicalExpr evaluates && and || with short-circuit evaluation: the right side is evaluated only if
+// the left side does not decide the result. Both operands must be bool and the result is always a bool
+func evaluateLogicalExpr(binop ast.BinaryExpr, env *Environment) RuntimeValue {
+
+	left := logicalOperand(binop.Left, binop, env)
+
+	if binop.Operator.Kind == lexer.AND_TOKEN && !left {
+		return MakeBOOL(false)
+	}
+	if binop.Operator.Kind == lexer.OR_TOKEN && left {
 		return MakeBOOL(true)
 	}
-	return MakeBOOL(false)
+
+	return MakeBOOL(logicalOperand(binop.Right, binop, env))
 }
 
-func evaluateLogicalOR(left float64, right float64) RuntimeValue {
-	// Logical OR evaluates to the first truthy value, otherwise false
-	if left == 1 || right == 1 {
-		return MakeBOOL(true)
+func logicalOperand(operand ast.Expression, binop ast.BinaryExpr, env *Environment) bool {
+
+	value := Evaluate(operand, env)
+
+	boolean, ok := value.(BooleanValue)
+
+	if !ok {
+		start, end := operand.GetPos()
+		runtimeError(env, start, end, fmt.Sprintf("operator %s needs bool operands, got %s", binop.Operator.Value, valueTypeName(value))).AddHint("compare the value explicitly, e.g. ", parser.TEXT_HINT).AddHint("count != 0", parser.CODE_HINT).Throw()
 	}
-	return MakeBOOL(false)
+
+	return boolean.Value
 }
 
 func evaluateStringExpr(left StringValue, right StringValue, operator lexer.Token) (RuntimeValue, error) {
@@ -0,0 +1,1232 @@
+package typechecker
+
+import (
+	"fmt"
+	"reflect"
+	"walrus/frontend/ast"
+	"walrus/frontend/lexer"
+	"walrus/frontend/parser"
+	"walrus/helpers"
+)
+
+// flowVariable is a variable declared with let or const, tracked by the flow analysis
+type flowVariable struct {
+	name        string
+	declaration ast.VariableDclStml
+	//static type of the variable, nil while it is not known
+	declType ast.Type
+	//declared with let x; so the type must be inferred from an assignment
+	needsType bool
+}
+
+// flowState holds the variables that are definitely assigned at some point of the program,
+// and the optional variables that are known not to be null.
+// An unreachable state (after ret, break or continue) is treated as having every variable assigned
+type flowState struct {
+	assigned    map[*flowVariable]bool
+	nonNull     map[*flowVariable]bool
+	unreachable bool
+}
+
+func newFlowState() flowState {
+	return flowState{assigned: make(map[*flowVariable]bool), nonNull: make(map[*flowVariable]bool)}
+}
+
+func (s flowState) copy() flowState {
+	copied := newFlowState()
+	copied.unreachable = s.unreachable
+	for v := range s.assigned {
+		copied.assigned[v] = true
+	}
+	for v := range s.nonNull {
+		copied.nonNull[v] = true
+	}
+	return copied
+}
+
+// mergeFlowStates joins two branches. A variable stays assigned, or not null, only if it is on both paths
+func mergeFlowStates(a, b flowState) flowState {
+	if a.unreachable {
+		return b.copy()
+	}
+	if b.unreachable {
+		return a.copy()
+	}
+
+	merged := newFlowState()
+	for v := range a.assigned {
+		if b.assigned[v] {
+			merged.assigned[v] = true
+		}
+	}
+	for v := range a.nonNull {
+		if b.nonNull[v] {
+			merged.nonNull[v] = true
+		}
+	}
+	return merged
+}
+
+type flowAnalyzer struct {
+	env       *Environment
+	scopes    []map[string]*flowVariable
+	functions map[string]ast.Type
+	structs   map[string]ast.StructDeclStatement
+	traits    map[string]bool
+	state     flowState
+	//function whose body is analyzed, nil at the top level
+	function *ast.FunctionDeclStmt
+	//labels of the loops around the analyzed statement, "" for a loop without label
+	loops []string
+	//labels of the loops around the enclosing functions. break and continue cannot reach them
+	outerLoops []string
+	//functions declared at the top level of the program, which a function body may call before their declaration
+	topLevel map[string]bool
+	//parameters of the functions declared in the program, including the top-level ones declared later
+	parameters map[string][]ast.FunctionParameter
+	//structs and traits declared at the top level of the program, which a function may name before their declaration
+	topLevelTypes map[string]bool
+	//index of the first scope of the analyzed function. The scopes before it belong to the enclosing functions and the program
+	functionScopes int
+	//variables that a function assigns although they are declared outside of it. A call may change them
+	captured map[*flowVariable]bool
+}
+
+// AnalyzeFlow statically checks the program before it is evaluated.
+// It proves that every variable declared without a value is assigned on every path before it is read,
+// and infers the type of untyped declarations (let x;) from their first assignment.
+func AnalyzeFlow(program ast.ProgramStmt, env *Environment) {
+	a := &flowAnalyzer{
+		env:           env,
+		functions:     make(map[string]ast.Type),
+		structs:       make(map[string]ast.StructDeclStatement),
+		traits:        make(map[string]bool),
+		state:         newFlowState(),
+		topLevel:      make(map[string]bool),
+		topLevelTypes: make(map[string]bool),
+		parameters:    make(map[string][]ast.FunctionParameter),
+		captured:      make(map[*flowVariable]bool),
+	}
+
+	for _, node := range program.Contents {
+		switch node := node.(type) {
+		case ast.FunctionDeclStmt:
+			a.topLevel[node.Name.Identifier] = true
+			a.parameters[node.Name.Identifier] = node.Parameters
+		case ast.StructDeclStatement:
+			a.topLevelTypes[node.StructName] = true
+		case ast.TraitDeclStatement:
+			a.topLevelTypes[node.TraitName] = true
+		}
+	}
+
+	a.pushScope()
+	for _, node := range program.Contents {
+		a.analyzeNode(node)
+	}
+	a.popScope()
+}
+
+func (a *flowAnalyzer) pushScope() {
+	a.scopes = append(a.scopes, make(map[string]*flowVariable))
+}
+
+func (a *flowAnalyzer) popScope() {
+	scope := a.scopes[len(a.scopes)-1]
+
+	for _, variable := range scope {
+		if variable.needsType && variable.declType == nil {
+			decl := variable.declaration
+			parser.MakeError(a.env.parser, decl.StartPos.Line, a.env.parser.FilePath, decl.Identifier.StartPos, decl.Identifier.EndPos, fmt.Sprintf("cannot infer the type of '%s'", variable.name)).AddHint("Add a type annotation like ", parser.TEXT_HINT).AddHint(fmt.Sprintf("let %s : str;", variable.name), parser.CODE_HINT).AddHint(" or assign a value while declaring it", parser.TEXT_HINT).Display()
+		}
+		delete(a.state.assigned, variable)
+	}
+
+	a.scopes = a.scopes[:len(a.scopes)-1]
+}
+
+func (a *flowAnalyzer) resolve(name string) *flowVariable {
+	for i := len(a.scopes) - 1; i >= 0; i-- {
+		if variable, ok := a.scopes[i][name]; ok {
+			return variable
+		}
+	}
+	return nil
+}
+
+func (a *flowAnalyzer) analyzeNode(node ast.Node) {
+	switch node := node.(type) {
+	case ast.VariableDclStml:
+		a.analyzeVariableDeclaration(node)
+	case ast.DestructuringDclStmt:
+		a.analyzeDestructuring(node)
+	case ast.BlockStmt:
+		a.analyzeBlock(node)
+	case ast.IfStmt:
+		a.analyzeIf(node)
+	case ast.WhileLoopStmt:
+		a.forgetLoopNarrowing(node)
+		a.checkCondition(node.Condition)
+		a.analyzeExpr(node.Condition)
+		a.inLoop(node.Label, node.StartPos, func() {
+			a.analyzeLoopBody(node.Block, func() {
+				a.narrow(node.Condition, true)
+			})
+		})
+		a.analyzeLoopElse(node.Else)
+	case ast.ForStmt:
+		a.pushScope()
+		a.analyzeExpr(node.Init)
+		a.declareAssigned(node.Variable)
+		a.forgetLoopNarrowing(node)
+		a.analyzeExpr(node.Condition)
+		before := a.state.copy()
+		a.inLoop(node.Label, node.StartPos, func() {
+			a.analyzeBlock(node.Block)
+		})
+		a.analyzeExpr(node.Post)
+		a.state = before
+		a.popScope()
+		a.analyzeLoopElse(node.Else)
+	case ast.ForeachStmt:
+		a.analyzeExpr(node.Iterable)
+		a.forgetLoopNarrowing(node)
+		a.inLoop(node.Label, node.StartPos, func() {
+			a.analyzeLoopBody(node.Block, func() {
+				if len(node.Variables) > 0 {
+					a.declareForeachVariables(node, a.inferElementType(node.Iterable))
+				} else {
+					a.declareAssigned(node.Variable)
+				}
+				if node.IndexVariable != "" {
+					a.declareAssigned(node.IndexVariable)
+				}
+				if node.WhereClause != nil {
+					a.analyzeExpr(node.WhereClause)
+				}
+			})
+		})
+		a.analyzeLoopElse(node.Else)
+	case ast.SwitchStmt:
+		a.analyzeSwitch(node)
+	case ast.RecoverStmt:
+		a.analyzeRecover(node)
+	case ast.DeferStmt:
+		a.analyzeDefer(node)
+	case ast.FunctionDeclStmt:
+		a.analyzeFunction(node)
+	case ast.StructDeclStatement:
+		a.structs[node.StructName] = node
+		for _, property := range node.Properties {
+			a.checkHashable(property.Type, node)
+			a.checkKnownType(property.Type, node)
+		}
+	case ast.TraitDeclStatement:
+		a.traits[node.TraitName] = true
+	case ast.ImplementStatement:
+		for _, method := range node.Methods {
+			a.analyzeFunction(method.FunctionDeclStmt)
+		}
+	case ast.ReturnStmt:
+		a.analyzeExpr(node.Expression)
+		if a.function != nil {
+			a.checkReturnValue(node)
+		}
+		a.state.unreachable = true
+	case ast.BreakStmt:
+		a.checkBreakout(node, node.Label, "break")
+		a.state.unreachable = true
+	case ast.ContinueStmt:
+		a.checkBreakout(node, node.Label, "continue")
+		a.state.unreachable = true
+	case ast.Expression:
+		a.analyzeExpr(node)
+		a.checkIgnoredResult(node)
+	}
+}
+
+func (a *flowAnalyzer) analyzeVariableDeclaration(stmt ast.VariableDclStml) {
+	a.checkHashable(stmt.ExplicitType, stmt)
+	a.checkKnownType(stmt.ExplicitType, stmt)
+
+	variable := &flowVariable{
+		name:        stmt.Identifier.Identifier,
+		declaration: stmt,
+		declType:    stmt.ExplicitType,
+		needsType:   stmt.ExplicitType == nil && stmt.Value == nil,
+	}
+
+	if stmt.Value != nil {
+		a.analyzeExpr(stmt.Value)
+		a.checkNullAssignment(variable.declType, stmt.Value)
+		if tupleType, ok := variable.declType.(ast.TupleType); ok {
+			a.checkTupleAssignment(tupleType, stmt.Value)
+		}
+		if variable.declType == nil {
+			variable.declType = a.inferType(stmt.Value)
+			if _, isNull := variable.declType.(ast.NullType); isNull {
+				parser.MakeError(a.env.parser, stmt.StartPos.Line, a.env.parser.FilePath, stmt.Identifier.StartPos, stmt.Identifier.EndPos, fmt.Sprintf("cannot infer the type of '%s' from null", variable.name)).AddHint("declare an optional type. e.g. ", parser.TEXT_HINT).AddHint(fmt.Sprintf("let %s : str? = null;", variable.name), parser.CODE_HINT).Display()
+			}
+		}
+	}
+
+	a.scopes[len(a.scopes)-1][variable.name] = variable
+
+	if stmt.Value != nil {
+		a.state.assigned[variable] = true
+		a.assignNullability(variable, stmt.Value)
+	}
+}
+
+func (a *flowAnalyzer) declareAssigned(name string) {
+	variable := &flowVariable{
+		name: name,
+	}
+	a.scopes[len(a.scopes)-1][name] = variable
+	a.state.assigned[variable] = true
+}
+
+func (a *flowAnalyzer) analyzeBlock(block ast.BlockStmt) {
+	a.pushScope()
+	for _, item := range block.Items {
+		a.analyzeNode(item)
+	}
+	a.popScope()
+}
+
+// analyzeLoopBody analyzes a loop body that may run zero times, so the assignments inside it are
+// not definite after the loop
+func (a *flowAnalyzer) analyzeLoopBody(block ast.BlockStmt, declareLoopVariables func()) {
+	before := a.state.copy()
+
+	a.pushScope()
+	if declareLoopVariables != nil {
+		declareLoopVariables()
+	}
+	for _, item := range block.Items {
+		a.analyzeNode(item)
+	}
+	a.popScope()
+
+	a.state = before
+}
+
+// forgetLoopNarrowing drops what is known about the nullability of the variables a loop assigns, and of those
+// the functions it calls may assign. The condition and the body run again after the assignments, and the
+// code after the loop may run after any of them
+func (a *flowAnalyzer) forgetLoopNarrowing(loop ast.Node) {
+	for _, node := range syntaxNodes(loop) {
+		switch node := node.(type) {
+		case ast.AssignmentExpr:
+			if identifier, ok := node.Assigne.(ast.IdentifierExpr); ok {
+				if variable := a.resolve(identifier.Identifier); variable != nil {
+					delete(a.state.nonNull, variable)
+				}
+			}
+		case ast.FunctionCallExpr:
+			a.forgetCapturedNarrowing(node)
+		}
+	}
+}
+
+// syntaxNodes returns a node and all the nodes under it
+func syntaxNodes(node ast.Node) []ast.Node {
+	var nodes []ast.Node
+	collectSyntaxNodes(reflect.ValueOf(node), &nodes)
+	return nodes
+}
+
+func collectSyntaxNodes(value reflect.Value, nodes *[]ast.Node) {
+	switch value.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if !value.IsNil() {
+			collectSyntaxNodes(value.Elem(), nodes)
+		}
+	case reflect.Struct:
+		if value.CanInterface() {
+			if node, ok := value.Interface().(ast.Node); ok {
+				*nodes = append(*nodes, node)
+			}
+		}
+		for i := 0; i < value.NumField(); i++ {
+			collectSyntaxNodes(value.Field(i), nodes)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			collectSyntaxNodes(value.Index(i), nodes)
+		}
+	case reflect.Map:
+		iterator := value.MapRange()
+		for iterator.Next() {
+			collectSyntaxNodes(iterator.Value(), nodes)
+		}
+	}
+}
+
+// inLoop analyzes the body of a loop with its label visible to break and continue
+func (a *flowAnalyzer) inLoop(label string, start lexer.Position, analyze func()) {
+	if label != "" && helpers.ContainsIn(a.loops, label) {
+		end := start
+		end.Column += len(label)
+		parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("label '%s' is already used by an enclosing loop", label)).AddHint("give the inner loop another label", parser.TEXT_HINT).Display()
+	}
+
+	a.loops = append(a.loops, label)
+	analyze()
+	a.loops = a.loops[:len(a.loops)-1]
+}
+
+// analyzeLoopElse analyzes the els block of a loop. It does not run if the loop ends with break,
+// so its assignments are not definite after the loop
+func (a *flowAnalyzer) analyzeLoopElse(block *ast.BlockStmt) {
+	if block == nil {
+		return
+	}
+
+	before := a.state.copy()
+	a.analyzeBlock(*block)
+	a.state = before
+}
+
+// checkBreakout checks that break and continue are inside a loop, and that their label names one of the loops around them
+func (a *flowAnalyzer) checkBreakout(stmt ast.Statement, label string, keyword string) {
+	start, end := stmt.GetPos()
+
+	isDefined := label == "" || helpers.ContainsIn(a.loops, label)
+
+	switch {
+	case !isDefined && helpers.ContainsIn(a.outerLoops, label):
+		parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("cannot %s loop '%s' from inside a nested function", keyword, label)).AddHint("a function cannot leave the loops around it. return a value and check it in the loop", parser.TEXT_HINT).Display()
+	case len(a.loops) == 0:
+		parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("%s can only be used inside a loop", keyword)).Display()
+	case !isDefined:
+		parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("label '%s' is not defined", label)).AddHint("put the label before an enclosing loop. e.g. ", parser.TEXT_HINT).AddHint(fmt.Sprintf("%s: foreach item in items { ... }", label), parser.CODE_HINT).Display()
+	}
+}
+
+func (a *flowAnalyzer) analyzeIf(stmt ast.IfStmt) {
+	a.checkCondition(stmt.Condition)
+	a.analyzeExpr(stmt.Condition)
+
+	before := a.state.copy()
+
+	a.narrow(stmt.Condition, true)
+	a.analyzeBlock(stmt.Block)
+	consequent := a.state
+
+	a.state = before
+	a.narrow(stmt.Condition, false)
+	switch alternate := stmt.Alternate.(type) {
+	case ast.IfStmt:
+		a.analyzeIf(alternate)
+	case ast.BlockStmt:
+		a.analyzeBlock(alternate)
+	}
+
+	a.state = mergeFlowStates(consequent, a.state)
+}
+
+func (a *flowAnalyzer) analyzeSwitch(stmt ast.SwitchStmt) {
+	a.analyzeExpr(stmt.Discriminant)
+
+	before := a.state.copy()
+	hasDefault := false
+	var merged *flowState
+
+	for _, switchCase := range stmt.Cases {
+		a.state = before.copy()
+		if switchCase.Test != nil {
+			a.analyzeExpr(switchCase.Test)
+			a.checkRangeCase(a.inferType(stmt.Discriminant), switchCase.Test)
+		} else {
+			hasDefault = true
+		}
+		a.analyzeBlock(switchCase.Consequent)
+
+		if merged == nil {
+			merged = &flowState{}
+			*merged = a.state
+		} else {
+			*merged = mergeFlowStates(*merged, a.state)
+		}
+	}
+
+	switch {
+	case merged == nil:
+		a.state = before
+	case hasDefault:
+		a.state = *merged
+	default:
+		// no case may match
+		a.state = mergeFlowStates(*merged, before)
+	}
+}
+
+// analyzeRecover treats the handler like an els block. The block may stop at any statement,
+// so its assignments are not definite in the handler
+func (a *flowAnalyzer) analyzeRecover(stmt ast.RecoverStmt) {
+	before := a.state.copy()
+
+	a.analyzeBlock(stmt.Block)
+	completed := a.state
+
+	a.state = before
+	a.pushScope()
+	if stmt.ErrorName != "" {
+		a.declareAssigned(stmt.ErrorName)
+		a.resolve(stmt.ErrorName).declType = ast.ErrorType{Kind: ast.T_ERROR}
+	}
+	a.analyzeBlock(stmt.Handler)
+	a.popScope()
+
+	a.state = mergeFlowStates(completed, a.state)
+}
+
+// analyzeDefer checks that a deferred call is inside a function, and that it cannot fail: its result is lost
+func (a *flowAnalyzer) analyzeDefer(stmt ast.DeferStmt) {
+	if a.function == nil {
+		parser.MakeError(a.env.parser, stmt.StartPos.Line, a.env.parser.FilePath, stmt.StartPos, stmt.EndPos, "defer can only be used inside a function").Display()
+	}
+
+	a.analyzeExpr(stmt.Call)
+
+	// like a call, a deferred call of a builtin is made even though the builtin is not a variable
+	name := stmt.Call.Caller.Identifier
+	if _, isBuiltin := a.builtinOf(stmt.Call); !isBuiltin && !a.isCallable(name) {
+		parser.MakeError(a.env.parser, stmt.StartPos.Line, a.env.parser.FilePath, stmt.Call.Caller.StartPos, stmt.Call.Caller.EndPos, fmt.Sprintf("cannot defer '%s', there is no function with this name", name)).Display()
+	}
+
+	if t := a.inferType(stmt.Call); isFallibleType(t) {
+		start, end := stmt.Call.GetPos()
+		parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("deferred call returns %s, but its error cannot be handled", typeName(t))).AddHint("defer a function that handles the error with catch", parser.TEXT_HINT).Display()
+	}
+}
+
+// isCallable reports if a name may refer to a function when the statement runs
+func (a *flowAnalyzer) isCallable(name string) bool {
+	if _, declared := a.functions[name]; declared || a.topLevel[name] || a.resolve(name) != nil {
+		return true
+	}
+	_, err := a.env.GetRuntimeValue(name)
+	return err == nil
+}
+
+// checkArguments rejects the arguments that may be null or an error for parameters that do not allow it.
+// The count and the other types are checked when the function is called
+func (a *flowAnalyzer) checkArguments(expr ast.FunctionCallExpr) {
+
+	parameters := a.parametersOf(expr)
+
+	for i, arg := range expr.Args {
+		// an argument may be an error only where the parameter is a T!
+		if parameters == nil || len(parameters) != len(expr.Args) {
+			a.checkHandled(arg)
+			continue
+		}
+		if structType, ok := parameters[i].Type.(ast.StructType); ok && a.isTrait(structType.Name) {
+			a.checkHandled(arg)
+			continue
+		}
+		a.checkNullAssignment(parameters[i].Type, arg)
+	}
+}
+
+// parametersOf returns the declared parameters of the called function, or nil when they are not known
+func (a *flowAnalyzer) parametersOf(expr ast.FunctionCallExpr) []ast.FunctionParameter {
+
+	if parameters, declared := a.parameters[expr.Caller.Identifier]; declared && a.resolve(expr.Caller.Identifier) == nil {
+		return parameters
+	}
+
+	if signature := a.nativeOf(expr); signature != nil {
+		return signature.Parameters
+	}
+
+	if a.resolve(expr.Caller.Identifier) != nil {
+		return nil
+	}
+
+	value, err := a.env.GetRuntimeValue(expr.Caller.Identifier)
+	if function, isFunction := value.(FunctionValue); err == nil && isFunction {
+		return function.Parameters
+	}
+
+	return nil
+}
+
+// isTrait reports if a name is declared with trait
+func (a *flowAnalyzer) isTrait(name string) bool {
+	return a.traits[name] || HasTrait(name, a.env)
+}
+
+// checkKnownType reports the names in a declared type that are neither a struct nor a trait, like a misspelled type
+func (a *flowAnalyzer) checkKnownType(t ast.Type, node ast.Node) {
+
+	switch t := t.(type) {
+	case ast.StructType:
+		if _, declared := a.structs[t.Name]; declared || a.topLevelTypes[t.Name] || a.isTrait(t.Name) || HasStruct(t.Name, a.env) {
+			return
+		}
+		start, end := node.GetPos()
+		a.checkPrivateUse(t.Name, start, end)
+		parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("unknown type '%s'", t.Name)).AddHint("declare it with struct or trait, or import it", parser.TEXT_HINT).Display()
+	case ast.OptionalType:
+		a.checkKnownType(t.Inner, node)
+	case ast.ResultType:
+		a.checkKnownType(t.Value, node)
+	case ast.ArrayType:
+		a.checkKnownType(t.ElementType, node)
+	case ast.MapType:
+		a.checkKnownType(t.Key, node)
+		a.checkKnownType(t.Value, node)
+	case ast.SetType:
+		a.checkKnownType(t.Element, node)
+	case ast.TupleType:
+		for _, element := range t.Elements {
+			a.checkKnownType(element, node)
+		}
+	case ast.FunctionType:
+		for _, param := range t.Parameters {
+			a.checkKnownType(param.Type, node)
+		}
+		a.checkKnownType(t.ReturnType, node)
+	}
+}
+
+// checkReturnValue checks the value of a ret statement against the return type of the function
+func (a *flowAnalyzer) checkReturnValue(stmt ast.ReturnStmt) {
+	a.checkNullAssignment(a.function.ReturnType, stmt.Expression)
+
+	tupleType, returnsTuple := resultValueType(a.function.ReturnType).(ast.TupleType)
+
+	if returnsTuple {
+		a.checkTupleAssignment(tupleType, stmt.Expression)
+	} else if tuple, ok := stmt.Expression.(ast.TupleExpr); ok {
+		parser.MakeError(a.env.parser, stmt.StartPos.Line, a.env.parser.FilePath, tuple.StartPos, tuple.EndPos, fmt.Sprintf("'%s' returns %s, but ret gives %d values", a.function.Name.Identifier, typeName(a.function.ReturnType), len(tuple.Elements))).AddHint("declare a tuple return type. e.g. ", parser.TEXT_HINT).AddHint("-> (str, i64)", parser.CODE_HINT).Display()
+	}
+}
+
+// inferElementType returns the type of the elements foreach gives for a value, nil if it is not known
+func (a *flowAnalyzer) inferElementType(iterable ast.Expression) ast.Type {
+	switch t := a.inferType(iterable).(type) {
+	case ast.ArrayType:
+		return t.ElementType
+	case ast.StringType:
+		return ast.CharType{Kind: ast.T_CHARACTER}
+	case ast.MapType:
+		return ast.TupleType{Kind: ast.T_TUPLE, Elements: []ast.Type{t.Key, t.Value}}
+	case ast.SetType:
+		return t.Element
+	case ast.RangeType:
+		return t.Element
+	default:
+		return nil
+	}
+}
+
+// analyzeFunction checks a function body on its own. Variables of the enclosing scopes are
+// treated as assigned, because the function may be called after they are assigned.
+func (a *flowAnalyzer) analyzeFunction(stmt ast.FunctionDeclStmt) {
+	a.functions[stmt.Name.Identifier] = stmt.ReturnType
+	a.parameters[stmt.Name.Identifier] = stmt.Parameters
+
+	outerScopes := a.scopes
+	outerState := a.state
+	outerFunction, outerFunctionScopes := a.function, a.functionScopes
+	outerLoops, loops := a.outerLoops, a.loops
+
+	a.function = &stmt
+	a.outerLoops = append(append([]string{}, outerLoops...), loops...)
+	a.loops = nil
+	// the body sees the variables around the function. It may run whenever the function is called, after
+	// the declaration, so the variables assigned there are assigned in it, but their narrowing does not hold
+	a.scopes = append([]map[string]*flowVariable{}, outerScopes...)
+	a.functionScopes = len(a.scopes)
+	a.state = newFlowState()
+	for variable := range outerState.assigned {
+		a.state.assigned[variable] = true
+	}
+
+	a.checkHashable(stmt.ReturnType, stmt.Name)
+	a.checkKnownType(stmt.ReturnType, stmt.Name)
+
+	a.pushScope()
+	parameters := a.scopes[len(a.scopes)-1]
+	for _, param := range stmt.Parameters {
+		a.checkHashable(param.Type, param.Identifier)
+		a.checkKnownType(param.Type, param.Identifier)
+		parameters[param.Identifier.Identifier] = &flowVariable{
+			name:     param.Identifier.Identifier,
+			declType: param.Type,
+		}
+		a.state.assigned[parameters[param.Identifier.Identifier]] = true
+	}
+	a.analyzeBlock(stmt.Block)
+	a.popScope()
+
+	a.scopes = outerScopes
+	a.state = outerState
+	a.function, a.functionScopes = outerFunction, outerFunctionScopes
+	a.outerLoops, a.loops = outerLoops, loops
+}
+
+// isOuter reports if a variable is declared outside of the analyzed function
+func (a *flowAnalyzer) isOuter(variable *flowVariable) bool {
+	for _, scope := range a.scopes[:a.functionScopes] {
+		if scope[variable.name] == variable {
+			return true
+		}
+	}
+	return false
+}
+
+// forgetCapturedNarrowing drops what is known about the nullability of the variables a call may assign.
+// A function declared later may assign any variable of the program
+func (a *flowAnalyzer) forgetCapturedNarrowing(expr ast.FunctionCallExpr) {
+
+	if _, isBuiltin := a.builtinOf(expr); isBuiltin || a.nativeOf(expr) != nil {
+		return
+	}
+
+	for variable := range a.captured {
+		delete(a.state.nonNull, variable)
+	}
+
+	name := expr.Caller.Identifier
+	if _, declared := a.functions[name]; !declared && a.topLevel[name] {
+		for _, variable := range a.scopes[0] {
+			delete(a.state.nonNull, variable)
+		}
+	}
+}
+
+func (a *flowAnalyzer) analyzeExpr(expr ast.Expression) {
+	switch expr := expr.(type) {
+	case ast.IdentifierExpr:
+		a.checkPrivateUse(expr.Identifier, expr.StartPos, expr.EndPos)
+		a.checkRead(expr)
+	case ast.AssignmentExpr:
+		a.analyzeAssignment(expr)
+	case ast.BinaryExpr:
+		a.analyzeExpr(expr.Left)
+		if expr.Operator.Kind == lexer.NULLISH_TOKEN {
+			a.env.setExpressionType(expr.Left, a.inferType(expr.Left))
+		}
+		if expr.Operator.Kind == lexer.AND_TOKEN || expr.Operator.Kind == lexer.OR_TOKEN {
+			a.checkLogicalOperand(expr.Left, expr.Operator)
+			a.checkLogicalOperand(expr.Right, expr.Operator)
+		}
+		if expr.Operator.Kind == lexer.AND_TOKEN || expr.Operator.Kind == lexer.OR_TOKEN || expr.Operator.Kind == lexer.NULLISH_TOKEN {
+			// the right side may not be evaluated. It is evaluated only if the left side of && is true,
+			// or the left side of || is false
+			before := a.state.copy()
+			a.narrow(expr.Left, expr.Operator.Kind == lexer.AND_TOKEN)
+			a.analyzeExpr(expr.Right)
+			a.state = before
+		} else {
+			a.analyzeExpr(expr.Right)
+		}
+		switch expr.Operator.Kind {
+		case lexer.EQUALS_TOKEN, lexer.NOT_EQUALS_TOKEN, lexer.NULLISH_TOKEN, lexer.AND_TOKEN, lexer.OR_TOKEN:
+		default:
+			a.checkNotNull(expr.Left)
+			a.checkNotNull(expr.Right)
+		}
+		if expr.Operator.Kind != lexer.NULLISH_TOKEN {
+			a.checkHandled(expr.Left)
+			a.checkHandled(expr.Right)
+		}
+		a.checkTypeComparison(expr)
+	case ast.UnaryExpr:
+		if expr.Operator.Value == "++" || expr.Operator.Value == "--" {
+			a.checkMutable(expr.Argument, "increment")
+		}
+		a.analyzeExpr(expr.Argument)
+	case ast.PostfixExpr:
+		a.checkMutable(expr.Argument, "increment")
+		a.analyzeExpr(expr.Argument)
+	case ast.ArrayIndexAccess:
+		a.analyzeExpr(expr.Array)
+		a.analyzeExpr(expr.Index)
+	case ast.FunctionCallExpr:
+		a.analyzeExpr(expr.Caller)
+		for _, arg := range expr.Args {
+			a.analyzeExpr(arg)
+		}
+		if builtin, ok := a.builtinOf(expr); ok && builtin.analyze != nil {
+			builtin.analyze(a, expr)
+		}
+		if signature := a.nativeOf(expr); signature != nil {
+			a.analyzeNativeCall(expr, signature)
+		}
+		a.checkArguments(expr)
+		a.forgetCapturedNarrowing(expr)
+	case ast.StructLiteral:
+		a.checkPrivateUse(expr.StructName, expr.StartPos, expr.EndPos)
+		declaration, declared := a.structs[expr.StructName]
+		for name, value := range expr.Properties {
+			a.analyzeExpr(value)
+			if declared {
+				a.checkNullAssignment(declaration.Properties[name].Type, value)
+			}
+		}
+	case ast.StructPropertyExpr:
+		a.analyzeExpr(expr.Object)
+		if !expr.Optional {
+			a.checkNotNull(expr.Object)
+		}
+		a.checkHandled(expr.Object)
+	case ast.ArrayLiterals:
+		for _, element := range expr.Elements {
+			a.analyzeExpr(element)
+			a.checkHandled(element)
+		}
+	case ast.TupleExpr:
+		for _, element := range expr.Elements {
+			a.analyzeExpr(element)
+		}
+	case ast.MapLiteral:
+		a.analyzeMapLiteral(expr)
+	case ast.SetLiteral:
+		a.analyzeSetLiteral(expr)
+	case ast.RangeExpr:
+		a.analyzeRange(expr)
+	case ast.MethodCallExpr:
+		a.analyzeMethodCall(expr)
+	case ast.TypeofExpr:
+		a.analyzeExpr(expr.Expression)
+	case ast.TypeCastExpr:
+		a.analyzeExpr(expr.Expression)
+		a.checkCast(expr)
+	case ast.TernaryExpr:
+		a.analyzeTernary(expr)
+	case ast.TryExpr:
+		a.analyzeTry(expr)
+	case ast.CatchExpr:
+		a.analyzeCatch(expr)
+	}
+}
+
+// analyzeTernary treats the branches of ?: like the blocks of an if statement and checks that they have a common type
+func (a *flowAnalyzer) analyzeTernary(expr ast.TernaryExpr) {
+	a.analyzeExpr(expr.Condition)
+
+	before := a.state.copy()
+
+	a.narrow(expr.Condition, true)
+	a.analyzeExpr(expr.Consequent)
+	consequent := a.state
+
+	a.state = before
+	a.narrow(expr.Condition, false)
+	a.analyzeExpr(expr.Alternate)
+
+	a.state = mergeFlowStates(consequent, a.state)
+
+	a.env.setExpressionType(expr.Consequent, a.inferType(expr.Consequent))
+	a.env.setExpressionType(expr.Alternate, a.inferType(expr.Alternate))
+
+	if _, err := a.inferTernaryType(expr); err != nil {
+		parser.MakeError(a.env.parser, expr.StartPos.Line, a.env.parser.FilePath, expr.StartPos, expr.EndPos, err.Error()).Display()
+	}
+}
+
+func (a *flowAnalyzer) inferTernaryType(expr ast.TernaryExpr) (ast.Type, error) {
+	return commonBranchType(a.inferType(expr.Consequent), a.inferType(expr.Alternate), isNumericConstant(expr.Consequent), isNumericConstant(expr.Alternate))
+}
+
+// checkCast rejects conversions that are not in the conversion table before the program runs
+func (a *flowAnalyzer) checkCast(expr ast.TypeCastExpr) {
+	from := a.inferType(expr.Expression)
+
+	if from == nil {
+		return
+	}
+
+	if err := checkConversion(from, expr.TargetType); err != nil {
+		parser.MakeError(a.env.parser, expr.StartPos.Line, a.env.parser.FilePath, expr.StartPos, expr.EndPos, err.Error()).Display()
+	}
+}
+
+func (a *flowAnalyzer) analyzeAssignment(expr ast.AssignmentExpr) {
+	a.checkMutable(expr.Assigne, "assign to")
+
+	identifier, isIdentifier := expr.Assigne.(ast.IdentifierExpr)
+
+	if variable := a.resolve(identifier.Identifier); isIdentifier && variable != nil && a.isOuter(variable) {
+		a.captured[variable] = true
+	}
+
+	if !isIdentifier || expr.Operator.Kind != lexer.ASSIGNMENT_TOKEN {
+		// compound assignments and property assignments read the assignee first
+		a.analyzeExpr(expr.Assigne)
+		a.analyzeExpr(expr.Value)
+		if expr.Operator.Kind == lexer.ASSIGNMENT_TOKEN {
+			a.checkNullAssignment(a.inferType(expr.Assigne), expr.Value)
+		}
+		// x ??= value is not null afterwards if the value is not null
+		if variable := a.resolve(identifier.Identifier); isIdentifier && variable != nil && expr.Operator.Kind == lexer.NULLISH_ASSIGNMENT_TOKEN {
+			a.assignNullability(variable, expr.Value)
+		}
+		return
+	}
+
+	a.analyzeExpr(expr.Value)
+
+	variable := a.resolve(identifier.Identifier)
+	if variable == nil {
+		return
+	}
+
+	if variable.needsType && variable.declType == nil {
+		variable.declType = a.inferType(expr.Value)
+		if _, isNull := variable.declType.(ast.NullType); isNull {
+			parser.MakeError(a.env.parser, identifier.StartPos.Line, a.env.parser.FilePath, identifier.StartPos, identifier.EndPos, fmt.Sprintf("cannot infer the type of '%s' from null", variable.name)).AddHint("declare an optional type. e.g. ", parser.TEXT_HINT).AddHint(fmt.Sprintf("let %s : str?;", variable.name), parser.CODE_HINT).Display()
+		}
+		if variable.declType != nil {
+			a.env.setInferredType(variable.declaration.StartPos.Index, variable.declType)
+		}
+	} else if variable.needsType {
+		// the first assignment gave the type, the others must match it
+		a.checkElementType(variable.declType, expr.Value, a.inferType(expr.Value))
+	}
+
+	a.checkNullAssignment(variable.declType, expr.Value)
+
+	if !a.state.unreachable {
+		a.state.assigned[variable] = true
+	}
+
+	a.assignNullability(variable, expr.Value)
+}
+
+// checkMutable rejects assignments and increments of constants before the program runs
+func (a *flowAnalyzer) checkMutable(target ast.Expression, action string) {
+	identifier, ok := target.(ast.IdentifierExpr)
+	if !ok {
+		return
+	}
+
+	isConstant := false
+	if variable := a.resolve(identifier.Identifier); variable != nil {
+		isConstant = variable.declaration.IsConstant
+	} else if env, err := a.env.ResolveVariable(identifier.Identifier); err == nil {
+		isConstant = env.constants[identifier.Identifier]
+	}
+
+	if isConstant {
+		parser.MakeError(a.env.parser, identifier.StartPos.Line, a.env.parser.FilePath, identifier.StartPos, identifier.EndPos, fmt.Sprintf("cannot %s constant '%s'", action, identifier.Identifier)).AddHint("declare it with let to change its value", parser.TEXT_HINT).Display()
+	}
+}
+
+// checkLogicalOperand rejects an operand of && or || that is known not to be a bool
+func (a *flowAnalyzer) checkLogicalOperand(operand ast.Expression, operator lexer.Token) {
+	t := a.inferType(operand)
+
+	if t == nil || t.IType() == ast.T_BOOLEAN {
+		return
+	}
+
+	start, end := operand.GetPos()
+	parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("operator %s needs bool operands, got %s", operator.Value, typeName(t))).AddHint("compare the value explicitly, e.g. ", parser.TEXT_HINT).AddHint("count != 0", parser.CODE_HINT).Display()
+}
+
+// checkCondition rejects an assignment used as a condition, which is almost always a typo for ==
+func (a *flowAnalyzer) checkCondition(condition ast.Expression) {
+	assignment, ok := condition.(ast.AssignmentExpr)
+	if !ok {
+		return
+	}
+
+	parser.MakeError(a.env.parser, assignment.StartPos.Line, a.env.parser.FilePath, assignment.Operator.StartPos, assignment.Operator.EndPos, "assignment cannot be used as a condition").AddHint("use == to compare values", parser.TEXT_HINT).Display()
+}
+
+func (a *flowAnalyzer) checkRead(expr ast.IdentifierExpr) {
+	variable := a.resolve(expr.Identifier)
+
+	if variable == nil || a.state.unreachable || a.state.assigned[variable] {
+		return
+	}
+
+	parser.MakeError(a.env.parser, expr.StartPos.Line, a.env.parser.FilePath, expr.StartPos, expr.EndPos, fmt.Sprintf("variable '%s' may be used before assignment", expr.Identifier)).AddHint("Assign a value on every path before reading it", parser.TEXT_HINT).Display()
+}
+
+// inferType returns the static type of an expression, or nil if it cannot be known without evaluating it
+func (a *flowAnalyzer) inferType(expr ast.Expression) ast.Type {
+	switch expr := expr.(type) {
+	case ast.NumericLiteral:
+		if expr.Kind == ast.FLOAT_LITERAL {
+			return floatType(expr.BitSize)
+		}
+		return ast.IntegerType{Kind: ast.DATA_TYPE(integerTypeName(expr.BitSize, expr.IsSigned)), BitSize: expr.BitSize, IsSigned: expr.IsSigned}
+	case ast.StringLiteral:
+		return ast.StringType{Kind: ast.T_STRING}
+	case ast.CharacterLiteral:
+		return ast.CharType{Kind: ast.T_CHARACTER}
+	case ast.BooleanLiteral:
+		return ast.BoolType{Kind: ast.T_BOOLEAN}
+	case ast.StructLiteral:
+		return ast.StructType{Kind: ast.T_STRUCT, Name: expr.StructName}
+	case ast.IdentifierExpr:
+		if variable := a.resolve(expr.Identifier); variable != nil {
+			// an optional variable checked against null has the type of its value
+			if optional, ok := variable.declType.(ast.OptionalType); ok && a.state.nonNull[variable] {
+				return optional.Inner
+			}
+			return variable.declType
+		}
+		if value, err := a.env.GetRuntimeValue(expr.Identifier); err == nil {
+			return typeOfValue(value)
+		}
+		return nil
+	case ast.FunctionCallExpr:
+		if returnType, ok := a.functions[expr.Caller.Identifier]; ok {
+			return returnType
+		}
+		if value, err := a.env.GetRuntimeValue(expr.Caller.Identifier); err == nil {
+			if function, ok := value.(FunctionValue); ok {
+				return function.ReturnType
+			}
+			if native, ok := value.(NativeFunctionValue); ok && native.Signature != nil {
+				return native.Signature.ReturnType
+			}
+		}
+		if builtin, ok := a.builtinOf(expr); ok {
+			if builtin.resultType != nil {
+				args := make([]ast.Type, len(expr.Args))
+				for i, arg := range expr.Args {
+					args[i] = a.inferType(arg)
+				}
+				return builtin.resultType(args)
+			}
+			return builtin.returnType
+		}
+		return nil
+	case ast.UnaryExpr:
+		if expr.Operator.Value == "!" {
+			return ast.BoolType{Kind: ast.T_BOOLEAN}
+		}
+		return a.inferType(expr.Argument)
+	case ast.PostfixExpr:
+		return a.inferType(expr.Argument)
+	case ast.AssignmentExpr:
+		// the value of an assignment is the value stored in the assignee
+		if assigneeType := a.inferType(expr.Assigne); assigneeType != nil {
+			return assigneeType
+		}
+		return a.inferType(expr.Value)
+	case ast.ArrayLiterals:
+		if len(expr.Elements) == 0 {
+			return nil
+		}
+		if elementType := a.inferType(expr.Elements[0]); elementType != nil {
+			return ast.ArrayType{Kind: ast.T_ARRAY, ElementType: elementType}
+		}
+		return nil
+	case ast.ArrayIndexAccess:
+		arrayType := a.inferType(expr.Array)
+		// a slice has the type of the sliced value
+		if _, isRange := a.inferType(expr.Index).(ast.RangeType); isRange {
+			return arrayType
+		}
+		switch t := arrayType.(type) {
+		case ast.ArrayType:
+			return t.ElementType
+		case ast.MapType:
+			return t.Value
+		}
+		return nil
+	case ast.MapLiteral:
+		return expr.Type
+	case ast.SetLiteral:
+		return expr.Type
+	case ast.BinaryExpr:
+		return a.inferBinaryType(expr)
+	case ast.TypeCastExpr:
+		return expr.TargetType
+	case ast.TernaryExpr:
+		t, _ := a.inferTernaryType(expr)
+		return t
+	case ast.NullLiteral:
+		return ast.NullType{Kind: ast.T_NULL}
+	case ast.StructPropertyExpr:
+		return a.inferPropertyType(expr)
+	case ast.TryExpr:
+		// an error leaves the function, so the value is a T
+		if t := a.inferType(expr.Expression); t == nil || t.IType() != ast.T_ERROR {
+			return resultValueType(t)
+		}
+		return nil
+	case ast.CatchExpr:
+		t, _ := a.inferCatchType(expr)
+		return t
+	case ast.TupleExpr:
+		return a.inferTupleType(expr)
+	case ast.RangeExpr:
+		return a.inferRangeType(expr)
+	case ast.MethodCallExpr:
+		return a.inferMethodType(expr)
+	case ast.TypeofExpr:
+		return typeType
+	default:
+		return nil
+	}
+}
+
+// builtinOf returns the builtin function a call refers to. A variable or a function with the same name hides it
+func (a *flowAnalyzer) builtinOf(expr ast.FunctionCallExpr) (builtinFunction, bool) {
+	name := expr.Caller.Identifier
+	if _, declared := a.functions[name]; declared || a.resolve(name) != nil {
+		return builtinFunction{}, false
+	}
+	if _, err := a.env.GetRuntimeValue(name); err == nil {
+		return builtinFunction{}, false
+	}
+	return lookupBuiltin(name)
+}
+
+// inferPropertyType returns the declared type of a struct field. a?.b is optional
+func (a *flowAnalyzer) inferPropertyType(expr ast.StructPropertyExpr) ast.Type {
+	objectType := a.inferType(expr.Object)
+	if optional, ok := objectType.(ast.OptionalType); ok {
+		objectType = optional.Inner
+	}
+
+	if objectType != nil && objectType.IType() == ast.T_ERROR && expr.Property.Identifier == "message" {
+		return ast.StringType{Kind: ast.T_STRING}
+	}
+
+	structType, ok := objectType.(ast.StructType)
+	if !ok {
+		return nil
+	}
+
+	var fields map[string]ast.Property
+	if declaration, ok := a.structs[structType.Name]; ok {
+		fields = declaration.Properties
+	} else if structValue, err := a.env.GetStructType(structType.Name); err == nil {
+		fields = structValue.(StructValue).Fields
+	}
+
+	field, ok := fields[expr.Property.Identifier]
+	if !ok {
+		return nil
+	}
+
+	if expr.Optional {
+		return makeOptional(field.Type)
+	}
+	return field.Type
+}
+
+func (a *flowAnalyzer) inferBinaryType(expr ast.BinaryExpr) ast.Type {
+	switch expr.Operator.Value {
+	case "==", "!=", ">", "<", ">=", "<=", "&&", "||":
+		return ast.BoolType{Kind: ast.T_BOOLEAN}
+	case "<<", ">>":
+		// shifts keep the type of the shifted value
+		return a.inferType(expr.Left)
+	case "??":
+		// the left side is used only when it is not null
+		left := a.inferType(expr.Left)
+		if optional, ok := left.(ast.OptionalType); ok {
+			left = optional.Inner
+		}
+		t, _ := commonBranchType(left, a.inferType(expr.Right), isNumericConstant(expr.Left), isNumericConstant(expr.Right))
+		return t
+	}
+
+	left := a.inferType(expr.Left)
+	right := a.inferType(expr.Right)
+
+	if left == nil || right == nil {
+		return nil
+	}
+
+	if left.IType() == ast.T_STRING {
+		return left
+	}
+
+	return a.inferNumericType(left, right, expr)
+}
+
+// inferNumericType returns the type of an arithmetic or a bitwise operation, with the rules of the evaluator:
+// a constant takes the type of the other integer or float, integers take their common type and an integer
+// mixed with a float is promoted to a float
+func (a *flowAnalyzer) inferNumericType(left ast.Type, right ast.Type, expr ast.BinaryExpr) ast.Type {
+
+	leftInt, leftIsInt := left.(ast.IntegerType)
+	rightInt, rightIsInt := right.(ast.IntegerType)
+	leftFloat, leftIsFloat := left.(ast.FloatType)
+	rightFloat, rightIsFloat := right.(ast.FloatType)
+
+	switch {
+	case leftIsInt && rightIsInt:
+		leftIsConstant, rightIsConstant := isNumericConstant(expr.Left), isNumericConstant(expr.Right)
+		if leftIsConstant && !rightIsConstant {
+			return rightInt
+		}
+		if rightIsConstant && !leftIsConstant {
+			return leftInt
+		}
+		size, signed, err := commonIntegerSize(leftInt.BitSize, leftInt.IsSigned, rightInt.BitSize, rightInt.IsSigned)
+		if err != nil {
+			return nil
+		}
+		return integerType(size, signed)
+	case leftIsFloat && rightIsFloat:
+		leftIsConstant, rightIsConstant := isNumericConstant(expr.Left), isNumericConstant(expr.Right)
+		if leftIsConstant && !rightIsConstant {
+			return rightFloat
+		}
+		if rightIsConstant && !leftIsConstant {
+			return leftFloat
+		}
+		if rightFloat.BitSize > leftFloat.BitSize {
+			return rightFloat
+		}
+		return leftFloat
+	case leftIsInt && rightIsFloat:
+		return floatType(promotedFloatBits(leftInt.BitSize, rightFloat.BitSize))
+	case leftIsFloat && rightIsInt:
+		return floatType(promotedFloatBits(rightInt.BitSize, leftFloat.BitSize))
+	}
+
+	return nil
+}
+
+// typeOfValue returns the type a runtime value was created with
+func typeOfValue(value RuntimeValue) ast.Type {
+	switch v := value.(type) {
+	case IntegerValue:
+		return v.Type
+	case FloatValue:
+		return v.Type
+	case BooleanValue:
+		return v.Type
+	case StringValue:
+		return v.Type
+	case CharacterValue:
+		return v.Type
+	case StructInstance:
+		return ast.StructType{Kind: ast.T_STRUCT, Name: v.StructName}
+	case FunctionValue:
+		return v.Type
+	case ArrayValue:
+		return v.Type
+	case TupleValue:
+		return v.Type
+	case MapValue:
+		return v.Type
+	case SetValue:
+		return v.Type
+	case RangeValue:
+		return v.Type
+	case TypeValue:
+		return v.Type
+	case ErrorValue:
+		return v.Type
+	default:
+		return nil
+	}
+}
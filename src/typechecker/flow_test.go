@@ -0,0 +1,146 @@
+package typechecker
+
+import "testing"
+
+func TestCallForgetsTheNarrowingOfCapturedVariables(t *testing.T) {
+	expectFailure(t, `let s : str? = "a";
+fn f() { s = null; }
+if s != null { f(); println(s + "x"); }
+`)
+
+	// h is declared after g, so the call in g may assign any variable of the program
+	expectFailure(t, `let s : str? = "a";
+fn g() { if s != null { h(); println(s + "x"); } }
+fn h() { s = null; }
+g();
+`)
+
+	// k does not assign s, and the s of f is another variable
+	expectOutput(t, `let s : str? = "a";
+fn k() { println("k"); }
+fn f() { let s : str? = "b"; s = null; }
+if s != null { k(); f(); println(s + "x"); }
+`, "k\nax\n")
+}
+
+func TestOnlyDeclaredTypesAreKnown(t *testing.T) {
+	expectFailure(t, `fn f(x : Strng) -> i32 { ret 1; }
+println(f(5));
+`)
+	expectFailure(t, `fn f() -> Missing? { ret null; }`)
+	expectFailure(t, `let m : map[str][]Nope = map[str][]Nope{};`)
+
+	// the struct is declared after the function that names it
+	expectOutput(t, `trait Shape { fn area() -> f64; }
+fn show(s : Shape) -> i32 { ret 2; }
+fn value(q : Q) -> i32 { ret q.v; }
+struct Q { pub v : i32; }
+println(value(Q{v: 3}));
+`, "3\n")
+}
+
+func TestFunctionsReadOnlyAssignedOuterVariables(t *testing.T) {
+	expectFailure(t, `let b;
+fn f() -> i32 { ret b + 1; }
+print(f());
+b = 5;
+`)
+
+	expectOutput(t, `let b;
+b = 5;
+fn f() -> i32 { ret b + 1; }
+println(f());
+`, "6\n")
+}
+
+func TestAssignmentsMatchTheInferredType(t *testing.T) {
+	expectFailure(t, `let c := true;
+let b;
+if c { b = 1; } els { b = "s"; }
+`)
+	expectFailure(t, `let c;
+c = null;
+`)
+
+	expectOutput(t, `let b;
+b = 2;
+b = 3;
+let s;
+s = "a";
+s = s + "b";
+println(b, " ", s);
+`, "3 ab\n")
+}
+
+func TestInferredTypesFollowTheArithmeticRules(t *testing.T) {
+	expectOutput(t, `let x : u32 = 1;
+let y : i32 = 1;
+let c := x + y;
+let d;
+d = c;
+let e;
+e = x + 1;
+let g;
+let f : f64 = 2.5;
+g = y * f;
+println(typeof(d), " ", typeof(e), " ", typeof(g), " ", g);
+`, "i64 u32 f64 2.5\n")
+}
+
+func TestLoopsForgetTheNarrowingOfWhatTheyAssign(t *testing.T) {
+	expectFailure(t, `let s : str? = "a";
+let i := 0;
+if s != null { while i < 1 { s = null; i++; } println(s + "x"); }
+`)
+
+	// the second iteration reads the null of the first one
+	expectFailure(t, `let s : str? = "a";
+if s != null { for i := 0; i < 2; i++ { println(s + "x"); s = null; } }
+`)
+
+	expectFailure(t, `let s : str? = "a";
+fn clear() { s = null; }
+if s != null { foreach n in [1, 2] { println(s + "x"); clear(); } }
+`)
+
+	expectOutput(t, `let s : str? = "a";
+while s != null { println(s + "x"); s = null; }
+let t : str? = "b";
+if t != null { foreach n in [1, 2] { println(t + "y"); } println(t); }
+`, "ax\nby\nby\nb\n")
+}
+
+func TestArgumentsMayBeNullOnlyForOptionalParameters(t *testing.T) {
+	expectFailure(t, `fn f(s : str) {}
+let x : str? = null;
+f(x);
+`)
+	expectFailure(t, `fn f() { g(null); }
+fn g(s : str) {}
+`)
+
+	expectOutput(t, `fn f(s : str) { println(s); }
+fn g(s : str?) { println(s ?? "none"); }
+let x : str? = "a";
+if x != null { f(x); }
+f(x ?? "b");
+g(null);
+`, "a\na\nnone\n")
+}
+
+func TestErrorsOfArgumentsAndElementsMustBeHandled(t *testing.T) {
+	p := "fn p() -> i32! { ret error(\"x\"); }\n"
+
+	expectFailure(t, p+"println(p());\n")
+	expectFailure(t, p+"fn q(x : i32) {}\nq(p());\n")
+	expectFailure(t, p+"let a := [p()];\n")
+	expectFailure(t, p+"let m := map[str]i32{\"a\": p()};\n")
+	expectFailure(t, p+"let s := set[i32]{p()};\n")
+
+	expectOutput(t, p+`fn r(x : i32!) { println("r"); }
+r(p());
+println(p() catch 0);
+let a := [p() catch 1];
+println(a[0]);
+`, "r\n0\n1\n")
+}
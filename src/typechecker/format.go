@@ -0,0 +1,506 @@
+package typechecker
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"walrus/frontend/ast"
+	"walrus/frontend/parser"
+	"walrus/utils"
+)
+
+// printf and sprintf replace each {} of the format with the text of the next argument:
+//
+//	printf("{} has {} items\n", name, count);
+//	sprintf("{:>8}", name);      // right aligned in 8 characters
+//	sprintf("{:*^9.2}", ratio);  // centered with * around, 2 digits after the point
+//	sprintf("{:#x} {:o} {:b}", 255, 8, 5);   // 0xff 10 101
+//	sprintf("{1} before {0}", "a", "b");
+//
+// A placeholder is {index:spec}, both parts are optional. The spec is [[fill]align][#][0][width][.precision][verb]:
+// align is < (left), > (right) or ^ (center), numbers are aligned to the right and other values to the left.
+// # adds the 0x, 0o or 0b prefix and 0 pads a number with zeros after its sign. The width counts characters.
+// The precision is the number of digits after the point of a float, or the maximum number of characters of
+// other values. The verbs x, X, o and b write an integer in base 16, 8 or 2. {{ and }} write { and }.
+//
+// Every argument must be used, and the flow analysis checks a literal format before the program runs.
+// print and println write the text of their arguments, eprint and eprintln write to the error output.
+// Nothing is colored unless the program asks for it with colorize(text, "red").
+
+// Output and ErrorOutput are where the print functions write. An embedder can redirect them
+var (
+	Output      io.Writer = os.Stdout
+	ErrorOutput io.Writer = os.Stderr
+)
+
+// formatPiece is a part of a format: literal text, or a placeholder if isPlaceholder
+type formatPiece struct {
+	text          string
+	isPlaceholder bool
+	index         int
+	spec          formatSpec
+}
+
+type formatSpec struct {
+	text      string
+	fill      rune
+	align     rune
+	alternate bool
+	zero      bool
+	width     int
+	// -1 without a precision
+	precision int
+	verb      rune
+}
+
+// parseFormat splits a format into its text and its placeholders. It returns the number of arguments the format uses
+func parseFormat(format string) ([]formatPiece, int, error) {
+
+	var pieces []formatPiece
+	var text strings.Builder
+
+	next, count := 0, 0
+
+	for i := 0; i < len(format); i++ {
+		switch {
+		case strings.HasPrefix(format[i:], "{{"), strings.HasPrefix(format[i:], "}}"):
+			text.WriteByte(format[i])
+			i++
+		case format[i] == '}':
+			return nil, 0, fmt.Errorf("unmatched } in the format. write }} for a }")
+		case format[i] == '{':
+			end := strings.IndexByte(format[i:], '}')
+			if end < 0 {
+				return nil, 0, fmt.Errorf("unclosed { in the format. write {{ for a {")
+			}
+			placeholder := format[i+1 : i+end]
+			i += end
+
+			indexText, specText, _ := strings.Cut(placeholder, ":")
+
+			index := next
+			if indexText == "" {
+				next++
+			} else {
+				parsed, err := strconv.Atoi(indexText)
+				if err != nil || parsed < 0 {
+					return nil, 0, fmt.Errorf("invalid placeholder {%s}. the index must be a number", placeholder)
+				}
+				index = parsed
+			}
+
+			spec, err := parseFormatSpec(specText)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			if text.Len() > 0 {
+				pieces = append(pieces, formatPiece{text: text.String()})
+				text.Reset()
+			}
+			pieces = append(pieces, formatPiece{isPlaceholder: true, index: index, spec: spec})
+
+			if index+1 > count {
+				count = index + 1
+			}
+		default:
+			text.WriteByte(format[i])
+		}
+	}
+
+	if text.Len() > 0 {
+		pieces = append(pieces, formatPiece{text: text.String()})
+	}
+
+	return pieces, count, nil
+}
+
+// maxFormatWidth is the largest width or precision of a placeholder
+const maxFormatWidth = 1024
+
+func parseFormatSpec(text string) (formatSpec, error) {
+
+	spec := formatSpec{text: text, fill: ' ', precision: -1}
+	runes := []rune(text)
+	i := 0
+
+	isAlign := func(r rune) bool { return r == '<' || r == '>' || r == '^' }
+
+	if len(runes) >= 2 && isAlign(runes[1]) {
+		spec.fill, spec.align = runes[0], runes[1]
+		i = 2
+	} else if len(runes) >= 1 && isAlign(runes[0]) {
+		spec.align = runes[0]
+		i = 1
+	}
+
+	if i < len(runes) && runes[i] == '#' {
+		spec.alternate = true
+		i++
+	}
+	if i < len(runes) && runes[i] == '0' {
+		spec.zero = true
+		i++
+	}
+
+	// digits reads a width or a precision, which is absent if there are no digits
+	digits := func(what string) (int, bool, error) {
+		start := i
+		for i < len(runes) && runes[i] >= '0' && runes[i] <= '9' {
+			i++
+		}
+		if start == i {
+			return 0, false, nil
+		}
+		value, err := strconv.Atoi(string(runes[start:i]))
+		if err != nil || value > maxFormatWidth {
+			return 0, false, fmt.Errorf("invalid format spec {:%s}. the %s is larger than %d", text, what, maxFormatWidth)
+		}
+		return value, true, nil
+	}
+
+	width, _, err := digits("width")
+	if err != nil {
+		return spec, err
+	}
+	spec.width = width
+
+	if i < len(runes) && runes[i] == '.' {
+		i++
+		precision, ok, err := digits("precision")
+		if err != nil {
+			return spec, err
+		}
+		if !ok {
+			return spec, fmt.Errorf("invalid format spec {:%s}. the precision needs digits after the point", text)
+		}
+		spec.precision = precision
+	}
+
+	if i < len(runes) && strings.ContainsRune("xXob", runes[i]) {
+		spec.verb = runes[i]
+		i++
+	}
+
+	if i != len(runes) {
+		return spec, fmt.Errorf("invalid format spec {:%s}", text)
+	}
+
+	return spec, nil
+}
+
+// check reports if a value of the given type can be written with the spec
+func (spec formatSpec) check(t ast.Type) error {
+
+	category := categoryOf(t)
+
+	if spec.verb != 0 && category != integerCategory {
+		return fmt.Errorf("{:%s} needs an integer, got %s", spec.text, typeName(t))
+	}
+	if spec.precision >= 0 && category == integerCategory {
+		return fmt.Errorf("{:%s} has a precision, which cannot be used with an integer", spec.text)
+	}
+	if (spec.zero || spec.alternate) && category != integerCategory && category != floatCategory {
+		return fmt.Errorf("{:%s} pads with zeros or adds a prefix, which only works with numbers", spec.text)
+	}
+
+	return nil
+}
+
+// formatValues writes the arguments in the placeholders of the format
+func formatValues(format string, args []RuntimeValue) (string, error) {
+
+	pieces, count, err := parseFormat(format)
+
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkFormatArgumentCount(count, len(args)); err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+
+	for _, piece := range pieces {
+		if !piece.isPlaceholder {
+			result.WriteString(piece.text)
+			continue
+		}
+
+		text, err := formatValue(args[piece.index], piece.spec)
+		if err != nil {
+			return "", err
+		}
+		result.WriteString(text)
+	}
+
+	return result.String(), nil
+}
+
+func checkFormatArgumentCount(used int, given int) error {
+	if used > given {
+		return fmt.Errorf("the format needs %d arguments but %d were provided", used, given)
+	}
+	if used < given {
+		return fmt.Errorf("the format uses %d arguments but %d were provided. every argument needs a placeholder", used, given)
+	}
+	return nil
+}
+
+// formatValue writes a value with a spec. The text of a value without a spec is its default text
+func formatValue(value RuntimeValue, spec formatSpec) (string, error) {
+
+	if t := typeOfValue(value); t != nil {
+		if err := spec.check(t); err != nil {
+			return "", err
+		}
+	}
+
+	var text string
+
+	switch v := value.(type) {
+	case IntegerValue:
+		text = formatInteger(v, spec)
+	case FloatValue:
+		if spec.precision >= 0 {
+			text = strconv.FormatFloat(v.Value, 'f', spec.precision, int(v.Size))
+		} else {
+			text = strconv.FormatFloat(v.Value, 'f', -1, int(v.Size))
+		}
+	default:
+		str, err := CastToStringValue(value)
+		if err != nil {
+			return "", err
+		}
+		text = str.Value
+		if spec.precision >= 0 && utf8.RuneCountInString(text) > spec.precision {
+			text = string([]rune(text)[:spec.precision])
+		}
+	}
+
+	return pad(text, value, spec), nil
+}
+
+func formatInteger(value IntegerValue, spec formatSpec) string {
+
+	base, prefix := 10, ""
+
+	switch spec.verb {
+	case 'x', 'X':
+		base, prefix = 16, "0x"
+	case 'o':
+		base, prefix = 8, "0o"
+	case 'b':
+		base, prefix = 2, "0b"
+	}
+
+	digits := new(big.Int).Abs(value.bigValue()).Text(base)
+	if spec.verb == 'X' {
+		digits = strings.ToUpper(digits)
+	}
+
+	if spec.alternate {
+		digits = prefix + digits
+	}
+	if value.bigValue().Sign() < 0 {
+		digits = "-" + digits
+	}
+
+	return digits
+}
+
+// pad fills the text up to the width of the spec
+func pad(text string, value RuntimeValue, spec formatSpec) string {
+
+	missing := spec.width - utf8.RuneCountInString(text)
+
+	if missing <= 0 {
+		return text
+	}
+
+	// zeros go after the sign and the prefix of a number
+	if spec.zero {
+		sign := ""
+		if strings.HasPrefix(text, "-") {
+			sign, text = "-", text[1:]
+		}
+		prefix := ""
+		if spec.alternate && len(text) > 2 && text[0] == '0' {
+			prefix, text = text[:2], text[2:]
+		}
+		return sign + prefix + strings.Repeat("0", missing) + text
+	}
+
+	align := spec.align
+	if align == 0 {
+		align = '<'
+		if category := categoryOf(typeOfValue(value)); category == integerCategory || category == floatCategory {
+			align = '>'
+		}
+	}
+
+	fill := string(spec.fill)
+
+	switch align {
+	case '>':
+		return strings.Repeat(fill, missing) + text
+	case '^':
+		return strings.Repeat(fill, missing/2) + text + strings.Repeat(fill, missing-missing/2)
+	default:
+		return text + strings.Repeat(fill, missing)
+	}
+}
+
+// textOf returns the default text of every argument, written one after the other
+func textOf(expr ast.FunctionCallExpr, args []RuntimeValue, env *Environment) string {
+
+	var text strings.Builder
+
+	for i, arg := range args {
+		str, err := CastToStringValue(arg)
+		if err != nil {
+			start, end := expr.Args[i].GetPos()
+			runtimeError(env, start, end, err.Error()).Throw()
+		}
+		text.WriteString(str.Value)
+	}
+
+	return text.String()
+}
+
+// formatArguments formats the arguments of printf or sprintf. The first argument is the format
+func formatArguments(expr ast.FunctionCallExpr, args []RuntimeValue, env *Environment) string {
+
+	name := expr.Caller.Identifier
+
+	if len(args) == 0 {
+		runtimeError(env, expr.StartPos, expr.EndPos, fmt.Sprintf("function '%s' needs a format", name)).AddHint("e.g. ", parser.TEXT_HINT).AddHint(name+"(\"{} items\", count)", parser.CODE_HINT).Throw()
+	}
+
+	format, ok := args[0].(StringValue)
+
+	if !ok {
+		start, end := expr.Args[0].GetPos()
+		runtimeError(env, start, end, fmt.Sprintf("the format of '%s' must be a str, got %s", name, valueTypeName(args[0]))).Throw()
+	}
+
+	text, err := formatValues(format.Value, args[1:])
+
+	if err != nil {
+		start, end := expr.Args[0].GetPos()
+		runtimeError(env, start, end, err.Error()).Throw()
+	}
+
+	return text
+}
+
+func write(output io.Writer, text string) RuntimeValue {
+	fmt.Fprint(output, text)
+	return MakeVOID()
+}
+
+func builtinPrint(expr ast.FunctionCallExpr, args []RuntimeValue, env *Environment) RuntimeValue {
+	return write(Output, textOf(expr, args, env))
+}
+
+func builtinPrintln(expr ast.FunctionCallExpr, args []RuntimeValue, env *Environment) RuntimeValue {
+	return write(Output, textOf(expr, args, env)+"\n")
+}
+
+func builtinEprint(expr ast.FunctionCallExpr, args []RuntimeValue, env *Environment) RuntimeValue {
+	return write(ErrorOutput, textOf(expr, args, env))
+}
+
+func builtinEprintln(expr ast.FunctionCallExpr, args []RuntimeValue, env *Environment) RuntimeValue {
+	return write(ErrorOutput, textOf(expr, args, env)+"\n")
+}
+
+func builtinPrintf(expr ast.FunctionCallExpr, args []RuntimeValue, env *Environment) RuntimeValue {
+	return write(Output, formatArguments(expr, args, env))
+}
+
+func builtinSprintf(expr ast.FunctionCallExpr, args []RuntimeValue, env *Environment) RuntimeValue {
+	return MakeSTRING(formatArguments(expr, args, env))
+}
+
+// colors are the names colorize accepts
+var colors = map[string]string{
+	"red":    utils.RED,
+	"green":  utils.GREEN,
+	"yellow": utils.YELLOW,
+	"orange": utils.ORANGE,
+	"blue":   utils.BLUE,
+	"purple": utils.PURPLE,
+	"cyan":   utils.CYAN,
+	"white":  utils.WHITE,
+	"grey":   utils.GREY,
+	"bold":   utils.BOLD,
+}
+
+// builtinColorize wraps a text in the terminal codes of a color. e.g. colorize("done", "green")
+func builtinColorize(expr ast.FunctionCallExpr, args []RuntimeValue, env *Environment) RuntimeValue {
+
+	if len(args) != 2 {
+		runtimeError(env, expr.StartPos, expr.EndPos, fmt.Sprintf("function 'colorize' expects 2 arguments but %d were provided", len(args))).Throw()
+	}
+
+	for i, arg := range args {
+		if _, ok := arg.(StringValue); !ok {
+			start, end := expr.Args[i].GetPos()
+			runtimeError(env, start, end, fmt.Sprintf("colorize needs a str, got %s", valueTypeName(arg))).Throw()
+		}
+	}
+
+	color, ok := colors[args[1].(StringValue).Value]
+
+	if !ok {
+		start, end := expr.Args[1].GetPos()
+		runtimeError(env, start, end, fmt.Sprintf("unknown color \"%s\"", args[1].(StringValue).Value)).AddHint("the colors are red, green, yellow, orange, blue, purple, cyan, white, grey and bold", parser.TEXT_HINT).Throw()
+	}
+
+	return MakeSTRING(utils.Colorize(color, args[0].(StringValue).Value))
+}
+
+// analyzeFormat checks a literal format of printf or sprintf against the number and the types of the arguments
+func (a *flowAnalyzer) analyzeFormat(expr ast.FunctionCallExpr) {
+
+	if len(expr.Args) == 0 {
+		return
+	}
+
+	literal, ok := expr.Args[0].(ast.StringLiteral)
+
+	if !ok {
+		return
+	}
+
+	start, end := literal.GetPos()
+
+	pieces, count, err := parseFormat(literal.Value)
+
+	if err == nil {
+		err = checkFormatArgumentCount(count, len(expr.Args)-1)
+	}
+
+	if err != nil {
+		parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, err.Error()).Display()
+	}
+
+	for _, piece := range pieces {
+		if !piece.isPlaceholder {
+			continue
+		}
+		arg := expr.Args[piece.index+1]
+		if t := a.inferType(arg); t != nil {
+			if err := piece.spec.check(t); err != nil {
+				argStart, argEnd := arg.GetPos()
+				parser.MakeError(a.env.parser, argStart.Line, a.env.parser.FilePath, argStart, argEnd, err.Error()).Display()
+			}
+		}
+	}
+}
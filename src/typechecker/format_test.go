@@ -0,0 +1,16 @@
+package typechecker
+
+import "testing"
+
+func TestFormatSpecWidthIsBounded(t *testing.T) {
+	for _, text := range []string{"1000000000", "99999999999999999999", ".5000", ">2000"} {
+		if _, err := parseFormatSpec(text); err == nil {
+			t.Errorf("{:%s} was accepted", text)
+		}
+	}
+
+	spec, err := parseFormatSpec("*^9.2")
+	if err != nil || spec.width != 9 || spec.precision != 2 {
+		t.Errorf("{:*^9.2} gave %+v, %v", spec, err)
+	}
+}
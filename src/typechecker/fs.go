@@ -0,0 +1,227 @@
+package typechecker
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"walrus/frontend/ast"
+)
+
+// core::fs reads and writes files and directories:
+//
+//	import "core::fs";
+//
+//	fn list(dir : str) -> i64! {
+//		try write_file(dir + "/notes.txt", "first line");
+//		let entries := try read_dir(dir);
+//		foreach entry in entries {
+//			println(entry.name, " ", entry.is_dir);
+//		}
+//		ret len(entries);
+//	}
+//
+// Every function returns T!, and the error of a failed operation is the message of the system, with the
+// path it was about. A relative path is relative to the directory the interpreter runs in. stat does not
+// follow a symbolic link, so is_symlink tells what the path itself is.
+
+var (
+	voidResult = ast.ResultType{Kind: ast.T_RESULT, Value: ast.VoidType{Kind: ast.T_VOID}}
+	boolResult = ast.ResultType{Kind: ast.T_RESULT, Value: boolType}
+	strResult  = ast.ResultType{Kind: ast.T_RESULT, Value: strType}
+
+	dirEntryType = ast.StructType{Kind: ast.T_STRUCT, Name: "DirEntry"}
+	fileInfoType = ast.StructType{Kind: ast.T_STRUCT, Name: "FileInfo"}
+)
+
+// coreFS is core::fs, the files and the directories
+func coreFS() NativeModule {
+	return NativeModule{
+		Path: "core::fs",
+		Functions: []NativeFunction{
+			{Signature: NativeSignature("read_file", strResult, NativeParam("path", strType)), Call: fsReadFile},
+			{Signature: NativeSignature("write_file", voidResult, NativeParam("path", strType), NativeParam("contents", strType)), Call: fsWriteFile},
+			{Signature: NativeSignature("append_file", voidResult, NativeParam("path", strType), NativeParam("contents", strType)), Call: fsAppendFile},
+			{Signature: NativeSignature("read_dir", ast.ResultType{Kind: ast.T_RESULT, Value: ast.ArrayType{Kind: ast.T_ARRAY, ElementType: dirEntryType}}, NativeParam("path", strType)), Call: fsReadDir},
+			{Signature: NativeSignature("stat", ast.ResultType{Kind: ast.T_RESULT, Value: fileInfoType}, NativeParam("path", strType)), Call: fsStat},
+			{Signature: NativeSignature("mkdir_all", voidResult, NativeParam("path", strType)), Call: fsMkdirAll},
+			{Signature: NativeSignature("remove", voidResult, NativeParam("path", strType)), Call: fsRemove},
+			{Signature: NativeSignature("rename", voidResult, NativeParam("from", strType), NativeParam("to", strType)), Call: fsRename},
+			{Signature: NativeSignature("copy", voidResult, NativeParam("from", strType), NativeParam("to", strType)), Call: fsCopy},
+			{Signature: NativeSignature("exists", boolResult, NativeParam("path", strType)), Call: fsExists},
+			{Signature: NativeSignature("temp_dir", strResult), Call: fsTempDir},
+		},
+		Structs: []NativeStruct{
+			{Name: "DirEntry", Fields: []ast.Property{
+				NativeField("name", strType),
+				// the path of the directory joined with the name
+				NativeField("path", strType),
+				NativeField("is_dir", boolType),
+				NativeField("is_symlink", boolType),
+			}},
+			{Name: "FileInfo", Fields: []ast.Property{
+				NativeField("name", strType),
+				// in bytes
+				NativeField("size", i64Type),
+				// the permission bits, like 0o644
+				NativeField("mode", ast.IntegerType{Kind: ast.T_UNSIGNED32, BitSize: 32, IsSigned: false}),
+				// the seconds since January 1, 1970 UTC, like time() of core::os
+				NativeField("modified", i64Type),
+				NativeField("is_dir", boolType),
+				NativeField("is_symlink", boolType),
+			}},
+		},
+	}
+}
+
+func stringArg(args []RuntimeValue, i int) string {
+	return args[i].(StringValue).Value
+}
+
+func fsReadFile(args ...RuntimeValue) RuntimeValue {
+	contents, err := os.ReadFile(stringArg(args, 0))
+	return ResultOf(MakeSTRING(string(contents)), err)
+}
+
+// fsWriteFile creates the file, or replaces what it contains
+func fsWriteFile(args ...RuntimeValue) RuntimeValue {
+	return ResultOf(MakeVOID(), os.WriteFile(stringArg(args, 0), []byte(stringArg(args, 1)), 0644))
+}
+
+// fsAppendFile adds to the end of the file, and creates it if it does not exist
+func fsAppendFile(args ...RuntimeValue) RuntimeValue {
+
+	file, err := os.OpenFile(stringArg(args, 0), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return MakeERROR(err.Error())
+	}
+
+	_, err = file.WriteString(stringArg(args, 1))
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return ResultOf(MakeVOID(), err)
+}
+
+// fsReadDir lists a directory sorted by name, without . and ..
+func fsReadDir(args ...RuntimeValue) RuntimeValue {
+
+	dir := stringArg(args, 0)
+
+	entries, err := os.ReadDir(dir)
+
+	if err != nil {
+		return MakeERROR(err.Error())
+	}
+
+	elements := make([]RuntimeValue, len(entries))
+
+	for i, entry := range entries {
+		elements[i] = StructInstance{StructName: dirEntryType.Name, Fields: map[string]RuntimeValue{
+			"name":       MakeSTRING(entry.Name()),
+			"path":       MakeSTRING(filepath.Join(dir, entry.Name())),
+			"is_dir":     MakeBOOL(entry.IsDir()),
+			"is_symlink": MakeBOOL(entry.Type()&os.ModeSymlink != 0),
+		}}
+	}
+
+	return MakeARRAY(elements, dirEntryType)
+}
+
+func fsStat(args ...RuntimeValue) RuntimeValue {
+
+	info, err := os.Lstat(stringArg(args, 0))
+
+	if err != nil {
+		return MakeERROR(err.Error())
+	}
+
+	return StructInstance{StructName: fileInfoType.Name, Fields: map[string]RuntimeValue{
+		"name":       MakeSTRING(info.Name()),
+		"size":       MakeINT(info.Size(), 64, true),
+		"mode":       MakeINT(int64(info.Mode().Perm()), 32, false),
+		"modified":   MakeINT(info.ModTime().Unix(), 64, true),
+		"is_dir":     MakeBOOL(info.IsDir()),
+		"is_symlink": MakeBOOL(info.Mode()&os.ModeSymlink != 0),
+	}}
+}
+
+// fsMkdirAll creates a directory and the missing directories above it. A directory that exists is not an error
+func fsMkdirAll(args ...RuntimeValue) RuntimeValue {
+	return ResultOf(MakeVOID(), os.MkdirAll(stringArg(args, 0), 0755))
+}
+
+// fsRemove removes a file or an empty directory
+func fsRemove(args ...RuntimeValue) RuntimeValue {
+	return ResultOf(MakeVOID(), os.Remove(stringArg(args, 0)))
+}
+
+// fsRename moves a file or a directory, replacing the file at the new path
+func fsRename(args ...RuntimeValue) RuntimeValue {
+	return ResultOf(MakeVOID(), os.Rename(stringArg(args, 0), stringArg(args, 1)))
+}
+
+// fsCopy copies a file with its permissions, replacing the file at the new path. Directories are not copied
+func fsCopy(args ...RuntimeValue) RuntimeValue {
+
+	from, to := stringArg(args, 0), stringArg(args, 1)
+
+	source, err := os.Open(from)
+
+	if err != nil {
+		return MakeERROR(err.Error())
+	}
+
+	defer source.Close()
+
+	info, err := source.Stat()
+
+	if err != nil {
+		return MakeERROR(err.Error())
+	}
+
+	if info.IsDir() {
+		return MakeERROR(fmt.Sprintf("copy %s: is a directory", from))
+	}
+
+	// opening the target empties it, so a copy onto the source would lose the contents
+	if targetInfo, err := os.Stat(to); err == nil && os.SameFile(info, targetInfo) {
+		return MakeERROR(fmt.Sprintf("copy %s to %s: same file", from, to))
+	}
+
+	target, err := os.OpenFile(to, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode().Perm())
+
+	if err != nil {
+		return MakeERROR(err.Error())
+	}
+
+	_, err = io.Copy(target, source)
+
+	if closeErr := target.Close(); err == nil {
+		err = closeErr
+	}
+
+	return ResultOf(MakeVOID(), err)
+}
+
+// fsExists reports if something is at the path. It fails when the path cannot be checked, like in a directory
+// the program cannot read
+func fsExists(args ...RuntimeValue) RuntimeValue {
+
+	_, err := os.Lstat(stringArg(args, 0))
+
+	if os.IsNotExist(err) {
+		return MakeBOOL(false)
+	}
+
+	return ResultOf(MakeBOOL(true), err)
+}
+
+// fsTempDir creates a new empty directory in the temporary directory of the system and returns its path
+func fsTempDir(args ...RuntimeValue) RuntimeValue {
+	dir, err := os.MkdirTemp("", "walrus-")
+	return ResultOf(MakeSTRING(dir), err)
+}
@@ -0,0 +1,240 @@
+package typechecker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func str(value string) RuntimeValue {
+	return MakeSTRING(value)
+}
+
+// expectNoError fails the test if a native of core::fs returned an error
+func expectNoError(t *testing.T, result RuntimeValue) RuntimeValue {
+	t.Helper()
+	if err, isError := result.(ErrorValue); isError {
+		t.Fatalf("unexpected error: %s", err.Message)
+	}
+	return result
+}
+
+func expectError(t *testing.T, result RuntimeValue, contains string) {
+	t.Helper()
+	err, isError := result.(ErrorValue)
+	if !isError {
+		t.Fatalf("expected an error, got %s", valueTypeName(result))
+	}
+	if !strings.Contains(err.Message, contains) {
+		t.Errorf("error %q does not contain %q", err.Message, contains)
+	}
+}
+
+func TestFsWriteAppendRead(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "notes.txt")
+
+	expectNoError(t, fsWriteFile(str(file), str("first")))
+	expectNoError(t, fsAppendFile(str(file), str(" second")))
+
+	if text := expectNoError(t, fsReadFile(str(file))).(StringValue).Value; text != "first second" {
+		t.Errorf("read %q", text)
+	}
+
+	expectNoError(t, fsWriteFile(str(file), str("replaced")))
+
+	if text := expectNoError(t, fsReadFile(str(file))).(StringValue).Value; text != "replaced" {
+		t.Errorf("read %q after write_file", text)
+	}
+}
+
+func TestFsAppendCreatesTheFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "log.txt")
+
+	expectNoError(t, fsAppendFile(str(file), str("line")))
+
+	if text := expectNoError(t, fsReadFile(str(file))).(StringValue).Value; text != "line" {
+		t.Errorf("read %q", text)
+	}
+}
+
+func TestFsReadMissingFile(t *testing.T) {
+	expectError(t, fsReadFile(str(filepath.Join(t.TempDir(), "missing.txt"))), "missing.txt")
+}
+
+func TestFsReadDir(t *testing.T) {
+	dir := t.TempDir()
+
+	expectNoError(t, fsWriteFile(str(filepath.Join(dir, "b.txt")), str("")))
+	expectNoError(t, fsMkdirAll(str(filepath.Join(dir, "a"))))
+	if err := os.Symlink(filepath.Join(dir, "b.txt"), filepath.Join(dir, "c")); err != nil {
+		t.Skip("symbolic links are not supported:", err)
+	}
+
+	entries := expectNoError(t, fsReadDir(str(dir))).(ArrayValue)
+
+	expected := []struct {
+		name      string
+		isDir     bool
+		isSymlink bool
+	}{{"a", true, false}, {"b.txt", false, false}, {"c", false, true}}
+
+	if len(entries.Elements) != len(expected) {
+		t.Fatalf("read %d entries, expected %d", len(entries.Elements), len(expected))
+	}
+
+	for i, entry := range entries.Elements {
+		fields := entry.(StructInstance).Fields
+		if fields["name"].(StringValue).Value != expected[i].name {
+			t.Errorf("entry %d is %s, expected %s", i, fields["name"].(StringValue).Value, expected[i].name)
+		}
+		if fields["path"].(StringValue).Value != filepath.Join(dir, expected[i].name) {
+			t.Errorf("entry %d has path %s", i, fields["path"].(StringValue).Value)
+		}
+		if fields["is_dir"].(BooleanValue).Value != expected[i].isDir || fields["is_symlink"].(BooleanValue).Value != expected[i].isSymlink {
+			t.Errorf("entry %s has is_dir %v and is_symlink %v", expected[i].name, fields["is_dir"], fields["is_symlink"])
+		}
+	}
+
+	expectError(t, fsReadDir(str(filepath.Join(dir, "missing"))), "missing")
+}
+
+func TestFsStat(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "data.bin")
+
+	if err := os.WriteFile(file, []byte("12345678"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	fields := expectNoError(t, fsStat(str(file))).(StructInstance).Fields
+
+	if fields["name"].(StringValue).Value != "data.bin" || fields["size"].(IntegerValue).Value != 8 {
+		t.Errorf("stat gave name %v and size %v", fields["name"], fields["size"])
+	}
+	if fields["mode"].(IntegerValue).Value != 0640 {
+		t.Errorf("stat gave mode %o", fields["mode"].(IntegerValue).Value)
+	}
+	if fields["modified"].(IntegerValue).Value <= 0 || fields["is_dir"].(BooleanValue).Value {
+		t.Errorf("stat gave modified %v and is_dir %v", fields["modified"], fields["is_dir"])
+	}
+
+	if !expectNoError(t, fsStat(str(dir))).(StructInstance).Fields["is_dir"].(BooleanValue).Value {
+		t.Error("a directory is not is_dir")
+	}
+
+	expectError(t, fsStat(str(filepath.Join(dir, "missing"))), "missing")
+}
+
+func TestFsMkdirAllRemoveRename(t *testing.T) {
+	dir := t.TempDir()
+	deep := filepath.Join(dir, "a", "b", "c")
+
+	expectNoError(t, fsMkdirAll(str(deep)))
+	expectNoError(t, fsMkdirAll(str(deep)))
+
+	// only an empty directory is removed
+	expectError(t, fsRemove(str(filepath.Join(dir, "a"))), "not empty")
+
+	expectNoError(t, fsRename(str(filepath.Join(dir, "a")), str(filepath.Join(dir, "z"))))
+
+	if exists := expectNoError(t, fsExists(str(filepath.Join(dir, "z", "b", "c")))).(BooleanValue).Value; !exists {
+		t.Error("the renamed directory does not exist")
+	}
+
+	expectNoError(t, fsRemove(str(filepath.Join(dir, "z", "b", "c"))))
+
+	if exists := expectNoError(t, fsExists(str(filepath.Join(dir, "z", "b", "c")))).(BooleanValue).Value; exists {
+		t.Error("the removed directory exists")
+	}
+
+	expectError(t, fsRename(str(filepath.Join(dir, "missing")), str(filepath.Join(dir, "other"))), "missing")
+}
+
+func TestFsCopy(t *testing.T) {
+	dir := t.TempDir()
+	from, to := filepath.Join(dir, "from.sh"), filepath.Join(dir, "to.sh")
+
+	if err := os.WriteFile(from, []byte("echo hi"), 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	expectNoError(t, fsCopy(str(from), str(to)))
+
+	if text, _ := os.ReadFile(to); string(text) != "echo hi" {
+		t.Errorf("copied %q", text)
+	}
+	if info, _ := os.Stat(to); info.Mode().Perm() != 0750 {
+		t.Errorf("the copy has mode %o", info.Mode().Perm())
+	}
+
+	expectError(t, fsCopy(str(dir), str(filepath.Join(dir, "copy"))), "is a directory")
+	expectError(t, fsCopy(str(filepath.Join(dir, "missing")), str(to)), "missing")
+}
+
+func TestFsCopyOntoItself(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "same.txt")
+
+	if err := os.WriteFile(file, []byte("contents"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	expectError(t, fsCopy(str(file), str(file)), "same file")
+	expectError(t, fsCopy(str(file), str(filepath.Join(dir, ".", "same.txt"))), "same file")
+
+	if text, _ := os.ReadFile(file); string(text) != "contents" {
+		t.Errorf("the file now contains %q", text)
+	}
+}
+
+func TestFsTempDir(t *testing.T) {
+	dir := expectNoError(t, fsTempDir()).(StringValue).Value
+	defer os.RemoveAll(dir)
+
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 0 {
+		t.Errorf("temp_dir gave %s with %d entries and error %v", dir, len(entries), err)
+	}
+	if other := expectNoError(t, fsTempDir()).(StringValue).Value; other == dir {
+		t.Error("temp_dir gave the same directory twice")
+	} else {
+		os.RemoveAll(other)
+	}
+}
+
+func TestFsFromAProgram(t *testing.T) {
+	dir := t.TempDir()
+
+	expectOutput(t, `import "core::fs";
+
+fn backup(dir : str) -> i64! {
+    try write_file(dir + "/a.txt", "hello");
+    try mkdir_all(dir + "/backup");
+    let entries := try read_dir(dir);
+    foreach entry in entries {
+        if !entry.is_dir {
+            try copy(entry.path, dir + "/backup/" + entry.name);
+        }
+    }
+    ret len(entries);
+}
+
+fn name_of(path : str) -> str {
+    let info := stat(path) catch |e| null;
+    ret info == null ? "missing" : info.name;
+}
+
+println(backup("`+dir+`") catch -1);
+println(read_file("`+dir+`/backup/a.txt") catch |e| e.message);
+println(name_of("`+dir+`/missing"));
+`, "2\nhello\nmissing\n")
+}
+
+func TestFsErrorsMustBeHandled(t *testing.T) {
+	expectFailure(t, `import "core::fs";
+write_file("/tmp/never", "x");
+`)
+	expectFailure(t, `import "core::fs";
+let text : str = read_file("/tmp/never");
+`)
+}
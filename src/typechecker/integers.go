@@ -0,0 +1,291 @@
+package typechecker
+
+import (
+	"fmt"
+	"math/big"
+	"walrus/frontend/ast"
+	"walrus/frontend/lexer"
+)
+
+// Integer arithmetic rules
+//
+// Every integer value has a fixed width (8, 16, 32, 64 or 128 bits) and a signedness taken from its type.
+// bigint is signed and unbounded, its operations never overflow.
+// Arithmetic is checked: when the exact result of +, -, *, /, %, ** (power), <<, unary -, ++ or -- does not
+// fit in the result type, the evaluation stops with an integer overflow error. Values never wrap silently.
+// Division truncates towards zero and the remainder has the sign of the dividend.
+//
+// The result type of an operation on two integers is
+//   - the wider of the two types if both are signed or both are unsigned (i8 + i32 is i32)
+//   - the signed type if it is wider than the unsigned one (u8 + i16 is i16)
+//   - otherwise the signed type twice as wide as the unsigned one (u32 + i32 is i64).
+//     u128 has no common type with the signed types.
+//   - bigint if any of the two is a bigint.
+//
+// An integer constant (an expression made only of integer literals) takes the type of the other operand,
+// so `x + 1` has the type of x. A constant that does not fit in that type is an error.
+//
+// &, | and ^ work on the two's complement bits of the common type. x << n and x >> n have the type of x,
+// >> keeps the sign of signed values and a negative shift count is an error.
+
+func (i IntegerValue) isSigned() bool {
+	if t, ok := i.Type.(ast.IntegerType); ok {
+		return t.IsSigned
+	}
+	return true
+}
+
+// bigValue returns the exact value. Unsigned values up to 64 bits keep their bit pattern in the int64 Value field
+func (i IntegerValue) bigValue() *big.Int {
+	if i.Big != nil {
+		return new(big.Int).Set(i.Big)
+	}
+	if i.isSigned() {
+		return big.NewInt(i.Value)
+	}
+	return new(big.Int).SetUint64(uint64(i.Value))
+}
+
+func (i IntegerValue) float() float64 {
+	if i.Big != nil {
+		value, _ := new(big.Float).SetInt(i.Big).Float64()
+		return value
+	}
+	if i.isSigned() {
+		return float64(i.Value)
+	}
+	return float64(uint64(i.Value))
+}
+
+func (i IntegerValue) String() string {
+	return i.bigValue().String()
+}
+
+func integerTypeName(size uint8, signed bool) string {
+	if size == 0 {
+		return string(ast.T_BIGINT)
+	}
+	if signed {
+		return fmt.Sprintf("i%d", size)
+	}
+	return fmt.Sprintf("u%d", size)
+}
+
+// integerBounds returns the smallest and the largest value of an integer type
+func integerBounds(size uint8, signed bool) (*big.Int, *big.Int) {
+	if signed {
+		max := new(big.Int).Lsh(big.NewInt(1), uint(size-1))
+		min := new(big.Int).Neg(max)
+		return min, max.Sub(max, big.NewInt(1))
+	}
+	max := new(big.Int).Lsh(big.NewInt(1), uint(size))
+	return big.NewInt(0), max.Sub(max, big.NewInt(1))
+}
+
+func fitsInInteger(value *big.Int, size uint8, signed bool) bool {
+	if size == 0 {
+		return true
+	}
+	min, max := integerBounds(size, signed)
+	return value.Cmp(min) >= 0 && value.Cmp(max) <= 0
+}
+
+// makeINTFromBig creates an integer of the given type, or fails if the value is out of its range
+func makeINTFromBig(value *big.Int, size uint8, signed bool) (IntegerValue, error) {
+	if !fitsInInteger(value, size, signed) {
+		return IntegerValue{}, fmt.Errorf("integer overflow. %s does not fit in %s", value, integerTypeName(size, signed))
+	}
+	if size == 0 || size > 64 {
+		integer := MakeINT(0, size, signed)
+		integer.Big = new(big.Int).Set(value)
+		return integer, nil
+	}
+	if signed {
+		return MakeINT(value.Int64(), size, signed), nil
+	}
+	return MakeINT(int64(value.Uint64()), size, signed), nil
+}
+
+// convertInteger converts an integer to another integer type. It fails if the value does not fit
+func convertInteger(value IntegerValue, size uint8, signed bool) (IntegerValue, error) {
+	if !fitsInInteger(value.bigValue(), size, signed) {
+		return IntegerValue{}, fmt.Errorf("value %s of type %s does not fit in %s", value, value.Type.IType(), integerTypeName(size, signed))
+	}
+	return makeINTFromBig(value.bigValue(), size, signed)
+}
+
+// commonIntegerType returns the type of the result of an operation on two integers
+func commonIntegerType(left IntegerValue, right IntegerValue) (uint8, bool, error) {
+	return commonIntegerSize(left.Size, left.isSigned(), right.Size, right.isSigned())
+}
+
+// commonIntegerSize returns the size and the signedness of the result of an operation on integers of these types.
+// The flow analysis uses it on the declared types, the evaluator on the values
+func commonIntegerSize(leftSize uint8, leftSigned bool, rightSize uint8, rightSigned bool) (uint8, bool, error) {
+
+	if leftSize == 0 || rightSize == 0 {
+		return 0, true, nil
+	}
+
+	if leftSigned == rightSigned {
+		if leftSize > rightSize {
+			return leftSize, leftSigned, nil
+		}
+		return rightSize, rightSigned, nil
+	}
+
+	signedSize, unsignedSize := leftSize, rightSize
+	if !leftSigned {
+		signedSize, unsignedSize = rightSize, leftSize
+	}
+
+	if signedSize > unsignedSize {
+		return signedSize, true, nil
+	}
+
+	if unsignedSize < 128 {
+		return unsignedSize * 2, true, nil
+	}
+
+	return 0, false, fmt.Errorf("no common integer type for %s and %s", integerTypeName(leftSize, leftSigned), integerTypeName(rightSize, rightSigned))
+}
+
+// integerType returns the type of the integers of a size and a signedness
+func integerType(size uint8, signed bool) ast.IntegerType {
+	return ast.IntegerType{Kind: ast.DATA_TYPE(integerTypeName(size, signed)), BitSize: size, IsSigned: signed}
+}
+
+// adaptIntegerConstant gives an integer constant the type of the value it is used with. ~ is evaluated at the
+// width of that type, so in m & ~0o022 with m : u32 the mask has 32 bits
+func adaptIntegerConstant(constant IntegerValue, expr ast.Expression, target IntegerValue, env *Environment) (IntegerValue, error) {
+	if unary, ok := expr.(ast.UnaryExpr); ok && unary.Operator.Value == "~" {
+		argument, err := adaptIntegerConstant(Evaluate(unary.Argument, env).(IntegerValue), unary.Argument, target, env)
+		if err != nil {
+			return IntegerValue{}, err
+		}
+		return bitwiseNot(argument)
+	}
+	if !fitsInInteger(constant.bigValue(), target.Size, target.isSigned()) {
+		return IntegerValue{}, fmt.Errorf("constant %s overflows %s", constant, target.Type.IType())
+	}
+	return makeINTFromBig(constant.bigValue(), target.Size, target.isSigned())
+}
+
+// evaluateAs evaluates a value stored in a place of the target type. An integer constant stored in a bigint is
+// evaluated as a bigint, so 2 ** 100 does not overflow the i32 of its literals
+func evaluateAs(expr ast.Expression, target ast.Type, env *Environment) RuntimeValue {
+	if isBigintType(target) && isNumericConstant(expr) {
+		return Evaluate(asBigintConstant(expr), env)
+	}
+	return Evaluate(expr, env)
+}
+
+// isBigintType reports if a type is bigint, bigint? or bigint!
+func isBigintType(t ast.Type) bool {
+	t = resultValueType(t)
+	if optional, ok := t.(ast.OptionalType); ok {
+		t = optional.Inner
+	}
+	integer, ok := t.(ast.IntegerType)
+	return ok && integer.BitSize == 0
+}
+
+// asBigintConstant returns a copy of a numeric constant whose integer literals are bigint
+func asBigintConstant(expr ast.Expression) ast.Expression {
+	switch e := expr.(type) {
+	case ast.NumericLiteral:
+		if e.Kind == ast.INTEGER_LITERAL {
+			e.BitSize, e.IsSigned = 0, true
+		}
+		return e
+	case ast.UnaryExpr:
+		e.Argument = asBigintConstant(e.Argument)
+		return e
+	case ast.BinaryExpr:
+		e.Left, e.Right = asBigintConstant(e.Left), asBigintConstant(e.Right)
+		return e
+	}
+	return expr
+}
+
+// integerPower computes base ^ exponent, stopping as soon as the result leaves the range of the type
+func integerPower(base *big.Int, exponent *big.Int, size uint8, signed bool) (*big.Int, error) {
+	if exponent.Sign() < 0 {
+		return nil, fmt.Errorf("negative exponent %s in integer power", exponent)
+	}
+
+	// 0, 1 and -1 never grow, every other base overflows after at most 128 multiplications.
+	// bigint powers are never out of range
+	if base.CmpAbs(big.NewInt(1)) <= 0 || size == 0 {
+		return new(big.Int).Exp(base, exponent, nil), nil
+	}
+
+	result := big.NewInt(1)
+	for i := new(big.Int); i.Cmp(exponent) < 0; i.Add(i, big.NewInt(1)) {
+		result.Mul(result, base)
+		if !fitsInInteger(result, size, signed) {
+			return nil, fmt.Errorf("integer overflow. %s ^ %s does not fit in %s", base, exponent, integerTypeName(size, signed))
+		}
+	}
+
+	return result, nil
+}
+
+// evaluateBitwiseExpr evaluates &, |, ^, << and >>
+func evaluateBitwiseExpr(left IntegerValue, right IntegerValue, operator lexer.Token) (RuntimeValue, error) {
+
+	leftValue := left.bigValue()
+	rightValue := right.bigValue()
+
+	switch operator.Value {
+	case "<<", ">>":
+		if rightValue.Sign() < 0 {
+			return nil, fmt.Errorf("negative shift count %s", rightValue)
+		}
+		// any non zero value of a fixed width integer overflows long before the count is this large
+		if !rightValue.IsInt64() || rightValue.Int64() > 1<<20 {
+			return nil, fmt.Errorf("shift count %s is too large", rightValue)
+		}
+		result := new(big.Int)
+		if operator.Value == "<<" {
+			result.Lsh(leftValue, uint(rightValue.Int64()))
+		} else {
+			// big.Int shifts keep the sign, like an arithmetic shift
+			result.Rsh(leftValue, uint(rightValue.Int64()))
+		}
+		if !fitsInInteger(result, left.Size, left.isSigned()) {
+			return nil, fmt.Errorf("integer overflow. %s %s %s does not fit in %s", leftValue, operator.Value, rightValue, left.Type.IType())
+		}
+		return makeINTFromBig(result, left.Size, left.isSigned())
+	}
+
+	size, signed, err := commonIntegerType(left, right)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := new(big.Int)
+
+	switch operator.Value {
+	case "&":
+		result.And(leftValue, rightValue)
+	case "|":
+		result.Or(leftValue, rightValue)
+	case "^":
+		result.Xor(leftValue, rightValue)
+	default:
+		return nil, fmt.Errorf(invalidOperationMsg, operator.Value)
+	}
+
+	return makeINTFromBig(result, size, signed)
+}
+
+// bitwiseNot flips every bit of the integer within its type
+func bitwiseNot(value IntegerValue) (IntegerValue, error) {
+	if value.isSigned() {
+		return makeINTFromBig(new(big.Int).Not(value.bigValue()), value.Size, true)
+	}
+	_, max := integerBounds(value.Size, false)
+	return makeINTFromBig(max.Xor(max, value.bigValue()), value.Size, false)
+}
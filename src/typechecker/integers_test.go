@@ -0,0 +1,65 @@
+package typechecker
+
+import (
+	"testing"
+	"walrus/frontend/lexer"
+)
+
+func TestBitwiseNotOfAConstantTakesTheWidthOfTheOtherOperand(t *testing.T) {
+	expectOutput(t, `let m : u32 = 0o775;
+println(m & ~0o022, " ", ~0o022 & m);
+let b : u8 = 255;
+println(b & ~1, " ", b ^ ~~3);
+let s : i16 = -1;
+println(s & ~0o022);
+`, "493 493\n254 252\n-19\n")
+
+	// alone, the constant is -19, which is not a u32
+	expectFailure(t, `let m : u32 = ~0o022;`)
+}
+
+func TestMixedIntegerAndFloatArithmeticGivesAFloat(t *testing.T) {
+	expectOutput(t, `let a : i64 = 5;
+let h : f64 = 2.5;
+println(a + h, " ", h + a, " ", typeof(a + h), " ", typeof(h + a));
+let u : u8 = 2;
+let five : f32 = 5;
+println(u - five, " ", typeof(u - five), " ", typeof(a * five));
+let big : f64 = 1e30;
+println(a + big > 1e29);
+`, "7.5 7.5 f64 f64\n-3 f32 f64\ntrue\n")
+}
+
+func TestShiftOverflowNamesTheOperator(t *testing.T) {
+	_, err := evaluateBitwiseExpr(MakeINT(1, 8, true), MakeINT(9, 8, true), lexer.Token{Kind: lexer.TOKEN_KIND("<<"), Value: "<<"})
+	if err == nil || err.Error() != "integer overflow. 1 << 9 does not fit in i8" {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestFloatLiteralsAreF64(t *testing.T) {
+	expectOutput(t, `let pi := 3.14159265358979;
+let x : f32 = 3.14;
+let y : f32 = 0.1 + 0.2;
+println(typeof(pi), " ", typeof(x), " ", typeof(x * 2.0), " ", y == 0.3f32);
+`, "f64 f32 f32 true\n")
+
+	expectFailure(t, `let x : f32 = 16777217.0;`)
+	expectFailure(t, `let x : f32 = 1e39;`)
+	expectFailure(t, `let x : f32 = 1.5;
+let y := x * 3.14159265358979;
+`)
+}
+
+func TestConstantsStoredInABigintAreBigints(t *testing.T) {
+	expectOutput(t, `let b : bigint = 2 ** 100;
+fn half(x : bigint) -> bigint { ret x / 2 ** 70; }
+struct S { pub v : bigint; }
+let s := S{v: 3 ** 50};
+b = b + 1;
+println(b, " ", half(2 ** 71), " ", s.v, " ", b * 2 ** 80 > 2 ** 180);
+`, "1267650600228229401496703205377 2 717897987691852588770249 true\n")
+
+	// without a bigint, the literals are i32
+	expectFailure(t, `let x := 2 ** 40;`)
+}
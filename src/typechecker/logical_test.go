@@ -0,0 +1,34 @@
+package typechecker
+
+import (
+	"os"
+	"testing"
+)
+
+// code/logical.wal stops with an error if && or || runs a right side that the left side decides
+func TestLogicalOperatorsSkipTheRightSide(t *testing.T) {
+	source, err := os.ReadFile("../../code/logical.wal")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectOutput(t, string(source), "ok\n")
+
+	expectFailure(t, `fn fail() -> bool { let zero := 0; ret 1 / zero == 0; }
+let skipped := false && fail();
+let evaluated := true && fail();
+`)
+}
+
+// an operand whose type is only known when the program runs is checked then, as a runtime error
+func TestNonBoolOperandIsARuntimeError(t *testing.T) {
+	expectOutput(t, `fn one() -> i32 { ret 1; }
+let g := one;
+recover {
+    let b := true && g();
+    println("not reached");
+} catch |e| {
+    println(e.message);
+}
+`, "operator && needs bool operands, got i32\n")
+}
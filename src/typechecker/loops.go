@@ -0,0 +1,171 @@
+package typechecker
+
+import (
+	"fmt"
+	"walrus/frontend/ast"
+	"walrus/frontend/parser"
+)
+
+// BreakValue is the result of a break statement. The blocks return it up to the loop it leaves
+type BreakValue struct {
+	Label string
+}
+
+func (b BreakValue) rVal() {
+	// empty function implements RuntimeValue interface
+}
+
+// ContinueValue is the result of a continue statement. The blocks return it up to the loop it continues
+type ContinueValue struct {
+	Label string
+}
+
+func (c ContinueValue) rVal() {
+	// empty function implements RuntimeValue interface
+}
+
+// isControlFlow reports if a statement result stops the enclosing blocks: ret, break or continue
+func isControlFlow(value RuntimeValue) bool {
+	switch value.(type) {
+	case ReturnValue, BreakValue, ContinueValue:
+		return true
+	default:
+		return false
+	}
+}
+
+// loopControl decides what a loop does after an iteration. It reports if the loop stops,
+// and the result of the loop statement: void after its own break, or a ret, break or continue of an enclosing loop
+func loopControl(result RuntimeValue, label string) (bool, RuntimeValue) {
+	switch r := result.(type) {
+	case ContinueValue:
+		if r.Label == "" || r.Label == label {
+			return false, nil
+		}
+		return true, result
+	case BreakValue:
+		if r.Label == "" || r.Label == label {
+			return true, MakeVOID()
+		}
+		return true, result
+	case ReturnValue:
+		return true, result
+	default:
+		return false, nil
+	}
+}
+
+// evaluateLoopElse runs the els block of a loop that ended without break
+func evaluateLoopElse(block *ast.BlockStmt, env *Environment) RuntimeValue {
+	if block == nil {
+		return MakeVOID()
+	}
+	return EvaluateBlockStmt(*block, env)
+}
+
+func EvaluateWhileLoopStmt(stmt ast.WhileLoopStmt, env *Environment) RuntimeValue {
+
+	for IsTruthy(Evaluate(stmt.Condition, env)) {
+		if stop, result := loopControl(EvaluateBlockStmt(stmt.Block, env), stmt.Label); stop {
+			return result
+		}
+	}
+
+	return evaluateLoopElse(stmt.Else, env)
+}
+
+func EvaluateForStmt(stmt ast.ForStmt, env *Environment) RuntimeValue {
+
+	// the loop variable is visible in the condition, the post expression and the body
+	scope := NewEnvironment(env, env.parser)
+
+	initial := Evaluate(stmt.Init, env)
+
+	if _, err := scope.DeclareVariable(stmt.Variable, initial, false); err != nil {
+		start, end := stmt.Init.GetPos()
+		runtimeError(env, start, end, err.Error()).Throw()
+	}
+
+	if t := typeOfValue(initial); t != nil {
+		scope.setDeclaredType(stmt.Variable, t)
+	}
+
+	for IsTruthy(Evaluate(stmt.Condition, scope)) {
+		if stop, result := loopControl(EvaluateBlockStmt(stmt.Block, scope), stmt.Label); stop {
+			return result
+		}
+		Evaluate(stmt.Post, scope)
+	}
+
+	return evaluateLoopElse(stmt.Else, env)
+}
+
+// EvaluateForeachStmt iterates over the elements of an array, a set or a range, the characters of a string or the (key, value) entries of a map.
+// The index variable is an i64
+func EvaluateForeachStmt(stmt ast.ForeachStmt, env *Environment) RuntimeValue {
+
+	next := iterate(Evaluate(stmt.Iterable, env), stmt.Iterable, env)
+
+	for index := 0; ; index++ {
+		element, ok := next()
+		if !ok {
+			break
+		}
+
+		scope := NewEnvironment(env, env.parser)
+
+		if len(stmt.Variables) > 0 {
+			values, types := destructureTuple(element, stmt.Variables, stmt.Iterable, scope)
+			declareDestructured(stmt.Variables, values, types, false, scope)
+		} else {
+			scope.DeclareVariable(stmt.Variable, element, false)
+		}
+		if stmt.IndexVariable != "" {
+			scope.DeclareVariable(stmt.IndexVariable, MakeINT(int64(index), 64, true), false)
+		}
+
+		if stmt.WhereClause != nil && !IsTruthy(Evaluate(stmt.WhereClause, scope)) {
+			continue
+		}
+
+		if stop, result := loopControl(EvaluateBlockStmt(stmt.Block, scope), stmt.Label); stop {
+			return result
+		}
+	}
+
+	return evaluateLoopElse(stmt.Else, env)
+}
+
+// iterate returns the elements of an iterable value one by one. A range computes its elements when they are needed,
+// the other values are copied before the first iteration, so changing them in the body does not change the iterations
+func iterate(iterable RuntimeValue, expr ast.Expression, env *Environment) func() (RuntimeValue, bool) {
+
+	var elements []RuntimeValue
+
+	switch value := iterable.(type) {
+	case RangeValue:
+		return value.iterator()
+	case ArrayValue:
+		elements = append(elements, value.Elements...)
+	case StringValue:
+		// a string is iterated by code point, not by byte
+		for _, char := range value.Value {
+			elements = append(elements, MakeCHAR(char))
+		}
+	case MapValue, SetValue:
+		elements = collectionItems(value)
+	default:
+		start, end := expr.GetPos()
+		runtimeError(env, start, end, fmt.Sprintf("cannot iterate over a value of type %s", valueTypeName(iterable))).AddHint("foreach works on arrays, strings, ranges, maps and sets", parser.TEXT_HINT).Throw()
+	}
+
+	position := 0
+
+	return func() (RuntimeValue, bool) {
+		if position >= len(elements) {
+			return nil, false
+		}
+		position++
+		return elements[position-1], true
+	}
+}
@@ -0,0 +1,150 @@
+package typechecker
+
+import (
+	"fmt"
+	"walrus/frontend/ast"
+	"walrus/frontend/parser"
+)
+
+// EvaluateMethodCallExpr calls a method of a built-in type, like text.trim() or typeof(x).kind().
+// object?.method() gives null without calling the method if the object is null
+func EvaluateMethodCallExpr(expr ast.MethodCallExpr, env *Environment) RuntimeValue {
+
+	object := Evaluate(expr.Object, env)
+
+	if _, isNull := object.(NullValue); isNull && expr.Optional {
+		return MakeNULL()
+	}
+
+	var args []RuntimeValue
+
+	for _, arg := range expr.Args {
+		args = append(args, Evaluate(arg, env))
+	}
+
+	if t, isType := object.(TypeValue); isType {
+		return callTypeMethod(t, expr, env)
+	}
+
+	text, isString := object.(StringValue)
+
+	if !isString {
+		runtimeError(env, expr.Method.StartPos, expr.Method.EndPos, fmt.Sprintf("a value of type %s has no method '%s'", valueTypeName(object), expr.Method.Identifier)).Throw()
+	}
+
+	method, ok := lookupStringMethod(expr.Method.Identifier)
+
+	if !ok {
+		runtimeError(env, expr.Method.StartPos, expr.Method.EndPos, fmt.Sprintf("str has no method '%s'", expr.Method.Identifier)).Throw()
+	}
+
+	if len(args) < method.required || len(args) > len(method.params) {
+		runtimeError(env, expr.StartPos, expr.EndPos, methodArgumentCountError(expr, method)).Throw()
+	}
+
+	// the arguments are converted to the parameter types like the arguments of a function
+	for i, arg := range args {
+		converted, err := convertImplicitly(arg, method.params[i], isNumericConstant(expr.Args[i]))
+		if err == nil && !matchesType(converted, method.params[i]) {
+			err = fmt.Errorf("argument %d of '%s' must be %s, got %s", i+1, expr.Method.Identifier, typeName(method.params[i]), valueTypeName(arg))
+		}
+		if err != nil {
+			start, end := expr.Args[i].GetPos()
+			runtimeError(env, start, end, err.Error()).Throw()
+		}
+		args[i] = converted
+	}
+
+	result, err := method.call(text.Value, args)
+
+	if err != nil {
+		runtimeError(env, expr.StartPos, expr.EndPos, err.Error()).Throw()
+	}
+
+	return result
+}
+
+func methodArgumentCountError(expr ast.MethodCallExpr, method stringMethod) string {
+	expected := fmt.Sprintf("%d", len(method.params))
+	if method.required != len(method.params) {
+		expected = fmt.Sprintf("%d to %d", method.required, len(method.params))
+	}
+	return fmt.Sprintf("method '%s' of str expects %s arguments but %d were provided", expr.Method.Identifier, expected, len(expr.Args))
+}
+
+// receiverType returns the type of the object of a method call, without the ? of object?.method()
+func (a *flowAnalyzer) receiverType(expr ast.MethodCallExpr) ast.Type {
+	t := a.inferType(expr.Object)
+	if optional, ok := t.(ast.OptionalType); ok && expr.Optional {
+		return optional.Inner
+	}
+	return t
+}
+
+// inferMethodType returns the type of the result of a method call, optional for object?.method()
+func (a *flowAnalyzer) inferMethodType(expr ast.MethodCallExpr) ast.Type {
+
+	var returnType ast.Type
+
+	switch a.receiverType(expr).(type) {
+	case ast.StringType:
+		if method, ok := lookupStringMethod(expr.Method.Identifier); ok {
+			returnType = method.returnType
+		}
+	case ast.TypeType:
+		if method, ok := lookupTypeMethod(expr.Method.Identifier); ok {
+			returnType = method.returnType
+		}
+	}
+
+	if returnType != nil && expr.Optional {
+		return makeOptional(returnType)
+	}
+
+	return returnType
+}
+
+// analyzeMethodCall checks the method name, the number of arguments and their types before the program runs
+func (a *flowAnalyzer) analyzeMethodCall(expr ast.MethodCallExpr) {
+
+	a.analyzeExpr(expr.Object)
+	if !expr.Optional {
+		a.checkNotNull(expr.Object)
+	}
+	a.checkHandled(expr.Object)
+
+	for _, arg := range expr.Args {
+		a.analyzeExpr(arg)
+		a.checkHandled(arg)
+	}
+
+	receiver := a.receiverType(expr)
+
+	if receiver == nil || receiver.IType() == ast.T_OPTIONAL {
+		// unknown, or reported by checkNotNull
+		return
+	}
+
+	if _, isType := receiver.(ast.TypeType); isType {
+		a.analyzeTypeMethod(expr)
+		return
+	}
+
+	if _, isString := receiver.(ast.StringType); !isString {
+		parser.MakeError(a.env.parser, expr.Method.StartPos.Line, a.env.parser.FilePath, expr.Method.StartPos, expr.Method.EndPos, fmt.Sprintf("a value of type %s has no method '%s'", typeName(receiver), expr.Method.Identifier)).Display()
+	}
+
+	method, ok := lookupStringMethod(expr.Method.Identifier)
+
+	if !ok {
+		parser.MakeError(a.env.parser, expr.Method.StartPos.Line, a.env.parser.FilePath, expr.Method.StartPos, expr.Method.EndPos, fmt.Sprintf("str has no method '%s'", expr.Method.Identifier)).AddHint("the methods of str are split, trim, starts_with, ends_with, contains, replace, to_upper, to_lower, index_of, repeat, pad_left, lines, parse_int and parse_float", parser.TEXT_HINT).Display()
+	}
+
+	if len(expr.Args) < method.required || len(expr.Args) > len(method.params) {
+		parser.MakeError(a.env.parser, expr.StartPos.Line, a.env.parser.FilePath, expr.StartPos, expr.EndPos, methodArgumentCountError(expr, method)).Display()
+	}
+
+	for i, arg := range expr.Args {
+		a.checkElementType(method.params[i], arg, a.inferType(arg))
+	}
+}
@@ -0,0 +1,389 @@
+package typechecker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"walrus/frontend/ast"
+	"walrus/frontend/lexer"
+	"walrus/frontend/parser"
+)
+
+// A program is split into modules with import:
+//
+//	import "core::os";                              // every symbol of the module
+//	import { read_file, exists } from "core::fs";   // only these symbols
+//
+// A module path is the path of a native module (see natives.go), or it is resolved from the directory
+// of the program that is run: lib::geometry is the file lib/geometry.wal, or the directory lib/geometry
+// with all the .wal files in it. A file declares the module it belongs to with mod, which is the name of
+// its directory, so lib/geometry.wal starts with mod lib;
+//
+// Each module is parsed and evaluated once, however many files import it, in a scope of its own that
+// starts with the natives the embedder declared before the program ran. The files of a directory share
+// the scope of their module, and they are evaluated in the order of their names. The symbols of a
+// module are its top-level functions, structs, traits, let and const declarations marked with export, the
+// others are private to the module. Importing binds the symbols as constants in the scope of the
+// importer, before the importer is analyzed and evaluated.
+
+// moduleLoader loads the modules imported by a program and by the modules it imports
+type moduleLoader struct {
+	// directory of the program, the module paths are resolved from it
+	root string
+	// declarations made before the program ran. Every module starts with them
+	prelude map[string]RuntimeValue
+	calls   *callStack
+	modules map[string]*module
+	// the program and the paths of the modules being loaded, in import order. Importing one of them again is a cycle
+	loading []string
+	// the symbols bound in each importer and their module. A symbol can be imported twice
+	bound map[*Environment]map[string]*module
+	// the private symbols of the modules each importer imports entirely, to explain why they cannot be used
+	hidden map[*Environment]map[string]privateSymbol
+}
+
+// module is a loaded module. symbols holds the scope of the module for each exported symbol
+type module struct {
+	path    string
+	symbols map[string]*Environment
+	private map[string]privateSymbol
+}
+
+// privateSymbol is a top-level declaration without export
+type privateSymbol struct {
+	module string
+	// fn, struct, trait, let or const
+	keyword string
+}
+
+func newModuleLoader(env *Environment) *moduleLoader {
+
+	prelude := make(map[string]RuntimeValue)
+
+	for name, value := range env.variables {
+		prelude[name] = value
+	}
+
+	return &moduleLoader{
+		root:    filepath.Dir(env.parser.FilePath),
+		prelude: prelude,
+		calls:   env.calls,
+		modules: make(map[string]*module),
+		loading: []string{filepath.Base(env.parser.FilePath)},
+		bound:   make(map[*Environment]map[string]*module),
+		hidden:  make(map[*Environment]map[string]privateSymbol),
+	}
+}
+
+// importModules loads the modules of the import statements of a program and binds their symbols in env
+func (l *moduleLoader) importModules(program ast.ProgramStmt, env *Environment) {
+	for _, stmt := range program.Imports {
+		l.bind(l.load(stmt, env), stmt, env)
+	}
+}
+
+// load returns the module of an import statement, parsing and evaluating it the first time it is imported
+func (l *moduleLoader) load(stmt ast.ImportStmt, env *Environment) *module {
+
+	path := stmt.ModuleName
+
+	for _, loading := range l.loading {
+		if loading == path {
+			chain := append(append([]string{}, l.loading...), path)
+			importError(env, stmt, fmt.Sprintf("import cycle: %s", strings.Join(chain, " -> "))).AddHint(fmt.Sprintf("\"%s\" imports itself through the modules it imports. Move the symbols they share to another module", path), parser.TEXT_HINT).Display()
+		}
+	}
+
+	if loaded, ok := l.modules[path]; ok {
+		return loaded
+	}
+
+	if native, ok := nativeModules[path]; ok {
+		l.modules[path] = loadNativeModule(native)
+		return l.modules[path]
+	}
+
+	files := l.resolve(stmt, env)
+
+	l.loading = append(l.loading, path)
+
+	loaded := &module{path: path, symbols: make(map[string]*Environment), private: make(map[string]privateSymbol)}
+
+	// the files of a module share its scope, so a file uses what the others declare without importing it
+	scope := NewEnvironment(nil, nil)
+
+	var programs []moduleFile
+	declaring := make(map[string]string)
+
+	for _, file := range files {
+		program, p := parseModuleFile(file)
+		symbols := moduleSymbols(program)
+		for _, symbol := range symbols {
+			if other, declared := declaring[symbol.name]; declared {
+				importError(env, stmt, fmt.Sprintf("'%s' is declared in both %s and %s of module \"%s\"", symbol.name, other, file, path)).Display()
+			}
+			declaring[symbol.name] = file
+		}
+		programs = append(programs, moduleFile{program, p, symbols})
+	}
+
+	// a declaration of the module replaces a native with the same name
+	for name, value := range l.prelude {
+		if _, declared := declaring[name]; !declared {
+			scope.variables[name] = value
+			scope.constants[name] = true
+		}
+	}
+
+	for _, file := range programs {
+		l.evaluateFile(file, scope)
+		for _, symbol := range file.symbols {
+			if symbol.exported {
+				loaded.symbols[symbol.name] = scope
+			} else {
+				loaded.private[symbol.name] = privateSymbol{module: loaded.path, keyword: symbol.keyword}
+			}
+		}
+	}
+
+	l.loading = l.loading[:len(l.loading)-1]
+	l.modules[path] = loaded
+
+	return loaded
+}
+
+// resolve returns the files of a module path: the file path.wal, or the .wal files of the directory path
+func (l *moduleLoader) resolve(stmt ast.ImportStmt, env *Environment) []string {
+
+	segments := strings.Split(stmt.ModuleName, "::")
+
+	for _, segment := range segments {
+		if segment == "" || strings.ContainsAny(segment, `/\.`) {
+			importError(env, stmt, fmt.Sprintf("invalid module path \"%s\"", stmt.ModuleName)).AddHint("a module path is made of names separated by ::, e.g. ", parser.TEXT_HINT).AddHint("core::os", parser.CODE_HINT).Display()
+		}
+	}
+
+	base := filepath.Join(append([]string{l.root}, segments...)...)
+
+	if info, err := os.Stat(base + ".wal"); err == nil && !info.IsDir() {
+		return []string{base + ".wal"}
+	}
+
+	entries, err := os.ReadDir(base)
+
+	if err != nil {
+		importError(env, stmt, fmt.Sprintf("module \"%s\" not found", stmt.ModuleName)).AddHint(fmt.Sprintf("there is no file %s and no directory %s", base+".wal", base), parser.TEXT_HINT).Display()
+	}
+
+	var files []string
+
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".wal" {
+			files = append(files, filepath.Join(base, entry.Name()))
+		}
+	}
+
+	if len(files) == 0 {
+		importError(env, stmt, fmt.Sprintf("module \"%s\" has no .wal files", stmt.ModuleName)).AddHint(fmt.Sprintf("the directory %s is empty", base), parser.TEXT_HINT).Display()
+	}
+
+	sort.Strings(files)
+
+	return files
+}
+
+// moduleFile is a parsed file of a module
+type moduleFile struct {
+	program ast.ProgramStmt
+	parser  *parser.Parser
+	symbols []declaredSymbol
+}
+
+// parseModuleFile parses a file of a module and checks that it declares the module of its directory
+func parseModuleFile(file string) (ast.ProgramStmt, *parser.Parser) {
+
+	p := parser.NewParser(file, false)
+	program := p.Parse()
+
+	if directory := filepath.Base(filepath.Dir(file)); program.ModuleName != "" && program.ModuleName != directory {
+		parser.MakeError(p, 1, file, program.StartPos, program.StartPos, fmt.Sprintf("%s declares mod %s, but it is in the directory %s", file, program.ModuleName, directory)).AddHint("a file declares the module of its directory, ", parser.TEXT_HINT).AddHint(fmt.Sprintf("mod %s;", directory), parser.CODE_HINT).Display()
+	}
+
+	return program, p
+}
+
+// evaluateFile evaluates a file of a module in the scope of the module. The environment of the file
+// shares the declarations of the scope, and has the parser of the file for the errors
+func (l *moduleLoader) evaluateFile(file moduleFile, scope *Environment) {
+
+	env := NewEnvironment(nil, file.parser)
+	env.variables, env.constants, env.types = scope.variables, scope.constants, scope.types
+	env.structs, env.traits = scope.structs, scope.traits
+	env.calls = l.calls
+	env.modules = l
+
+	EvaluateProgramBlock(file.program, env)
+}
+
+// declaredSymbol is a name declared by a top-level statement
+type declaredSymbol struct {
+	name     string
+	keyword  string
+	exported bool
+}
+
+func variableKeyword(isConstant bool) string {
+	if isConstant {
+		return "const"
+	}
+	return "let"
+}
+
+// moduleSymbols returns the names declared by the top-level statements of a program
+func moduleSymbols(program ast.ProgramStmt) []declaredSymbol {
+
+	var symbols []declaredSymbol
+
+	for _, node := range program.Contents {
+		switch node := node.(type) {
+		case ast.FunctionDeclStmt:
+			symbols = append(symbols, declaredSymbol{node.Name.Identifier, "fn", node.IsExported})
+		case ast.StructDeclStatement:
+			symbols = append(symbols, declaredSymbol{node.StructName, "struct", node.IsExported})
+		case ast.TraitDeclStatement:
+			symbols = append(symbols, declaredSymbol{node.TraitName, "trait", node.IsExported})
+		case ast.VariableDclStml:
+			symbols = append(symbols, declaredSymbol{node.Identifier.Identifier, variableKeyword(node.IsConstant), node.IsExported})
+		case ast.DestructuringDclStmt:
+			for _, name := range node.Names {
+				symbols = append(symbols, declaredSymbol{name.Identifier, variableKeyword(node.IsConstant), node.IsExported})
+			}
+		}
+	}
+
+	return symbols
+}
+
+// bind declares the symbols of a module in env, all of them or those listed in import { ... } from
+func (l *moduleLoader) bind(loaded *module, stmt ast.ImportStmt, env *Environment) {
+
+	names := stmt.Identifiers
+
+	if l.bound[env] == nil {
+		l.bound[env] = make(map[string]*module)
+		l.hidden[env] = make(map[string]privateSymbol)
+	}
+
+	if len(names) == 0 {
+		for name := range loaded.symbols {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for name, symbol := range loaded.private {
+			l.hidden[env][name] = symbol
+		}
+	}
+
+	for _, name := range names {
+
+		declaring, ok := loaded.symbols[name]
+
+		if symbol, isPrivate := loaded.private[name]; !ok && isPrivate {
+			importError(env, stmt, symbol.message(name)).AddHint("did you mean to export it? ", parser.TEXT_HINT).AddHint(symbol.declaration(name), parser.CODE_HINT).Display()
+		}
+
+		if !ok {
+			importError(env, stmt, fmt.Sprintf("module \"%s\" has no symbol '%s'", loaded.path, name)).AddHint(symbolsHint(loaded), parser.TEXT_HINT).Display()
+		}
+
+		// the imports are bound before the importer runs, so the names already there are natives of the prelude,
+		// which an import replaces, or imported symbols
+		if previous, imported := l.bound[env][name]; imported {
+			if previous == loaded {
+				continue
+			}
+			importError(env, stmt, fmt.Sprintf("cannot import '%s' from \"%s\", it is already imported from \"%s\"", name, loaded.path, previous.path)).Display()
+		}
+
+		l.bound[env][name] = loaded
+
+		if structValue, isStruct := declaring.structs[name]; isStruct {
+			env.structs[name] = structValue
+			continue
+		}
+
+		if trait, isTrait := declaring.traits[name]; isTrait {
+			env.traits[name] = trait
+			continue
+		}
+
+		env.variables[name] = declaring.variables[name]
+		env.constants[name] = true
+		if t, ok := declaring.types[name]; ok {
+			env.types[name] = t
+		}
+
+		// the values of the structs of a native function are usable without importing the structs by name
+		if native, ok := declaring.variables[name].(NativeFunctionValue); ok && native.Signature != nil {
+			for _, structName := range signatureStructs(native.Signature) {
+				if structValue, declared := declaring.structs[structName]; declared && !HasStruct(structName, env) {
+					env.structs[structName] = structValue
+				}
+			}
+		}
+	}
+}
+
+// symbolsHint lists the symbols of a module for an error about a missing one
+func symbolsHint(loaded *module) string {
+
+	if len(loaded.symbols) == 0 {
+		return fmt.Sprintf("module \"%s\" exports no symbols", loaded.path)
+	}
+
+	var names []string
+	for name := range loaded.symbols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return fmt.Sprintf("the symbols of \"%s\" are %s", loaded.path, strings.Join(names, ", "))
+}
+
+func (s privateSymbol) message(name string) string {
+	return fmt.Sprintf("'%s' is private to module \"%s\"", name, s.module)
+}
+
+// declaration shows how the symbol is declared to be exported
+func (s privateSymbol) declaration(name string) string {
+	return fmt.Sprintf("export %s %s", s.keyword, name)
+}
+
+// checkPrivateUse reports the use of a name that is private to a module the program imports entirely.
+// Other undeclared names are reported when the program runs
+func (a *flowAnalyzer) checkPrivateUse(name string, start lexer.Position, end lexer.Position) {
+
+	if a.env.modules == nil || a.resolve(name) != nil || a.env.HasVariable(name) || HasStruct(name, a.env) {
+		return
+	}
+	if _, declared := a.functions[name]; declared {
+		return
+	}
+	if _, declared := a.structs[name]; declared {
+		return
+	}
+	if _, isBuiltin := lookupBuiltin(name); isBuiltin {
+		return
+	}
+
+	if symbol, hidden := a.env.modules.hidden[a.env][name]; hidden {
+		parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, symbol.message(name)).AddHint("did you mean to export it? ", parser.TEXT_HINT).AddHint(symbol.declaration(name), parser.CODE_HINT).Display()
+	}
+}
+
+// importError reports an error at an import statement
+func importError(env *Environment, stmt ast.ImportStmt, msg string) *parser.ErrorMessage {
+	return parser.MakeError(env.parser, stmt.StartPos.Line, env.parser.FilePath, stmt.StartPos, stmt.EndPos, msg)
+}
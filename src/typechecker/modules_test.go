@@ -0,0 +1,99 @@
+package typechecker
+
+import "testing"
+
+func TestDirectoryModuleFilesShareTheirScope(t *testing.T) {
+	files := map[string]string{
+		"lib/geo/a.wal": `mod geo;
+fn ga() -> i32 { ret 1; }
+let count := 0;
+fn bump() { count = count + gb(); }
+`,
+		"lib/geo/b.wal": `mod geo;
+const base := ga() + 10;
+fn gb() -> i32 { ret 100; }
+export fn total() -> i32 {
+    bump();
+    bump();
+    let sum := base + count;
+    ret sum;
+}
+`,
+	}
+
+	output, failed := runProgramWith(t, `import "lib::geo";
+println(total());
+`, files)
+
+	if failed || output != "211\n" {
+		t.Errorf("got output %q, failed %v", output, failed)
+	}
+
+	// the private declarations stay private to the module
+	if _, failed := runProgramWith(t, `import "lib::geo";
+println(ga());
+`, files); !failed {
+		t.Error("a private function of the module was used")
+	}
+}
+
+func TestDirectoryModuleDeclaresANameOnce(t *testing.T) {
+	_, failed := runProgramWith(t, `import "lib::geo";`, map[string]string{
+		"lib/geo/a.wal": `mod geo;
+fn area() -> i32 { ret 1; }
+`,
+		"lib/geo/b.wal": `mod geo;
+let area := 2;
+`,
+	})
+
+	if !failed {
+		t.Error("two files of the module declared area")
+	}
+}
+
+func TestModuleExportsTraits(t *testing.T) {
+	files := map[string]string{
+		"lib/shapes.wal": `mod lib;
+export trait Shape { fn area() -> f64; }
+trait Hidden { fn name() -> str; }
+export fn describe(s : Shape) -> str { ret "shape"; }
+`,
+	}
+
+	output, failed := runProgramWith(t, `import "lib::shapes";
+fn twice(s : Shape) -> str { ret describe(s) + describe(s); }
+println(twice(3));
+`, files)
+
+	if failed || output != "shapeshape\n" {
+		t.Errorf("got output %q, failed %v", output, failed)
+	}
+
+	if _, failed := runProgramWith(t, `import "lib::shapes";
+fn name(h : Hidden) -> str { ret "hidden"; }
+`, files); !failed {
+		t.Error("a private trait of the module was used")
+	}
+}
+
+func TestTopLevelPubExports(t *testing.T) {
+	files := map[string]string{
+		"lib/shapes.wal": `pub struct Square { pub side : i32; }
+pub fn area(s : Square) -> i32 { ret s.side * s.side; }
+pub const unit := 1;
+`,
+	}
+
+	output, failed := runProgramWith(t, `import "lib::shapes";
+println(area(Square { side: 3 }), " ", unit);
+`, files)
+
+	if failed || output != "9 1\n" {
+		t.Errorf("got output %q, failed %v", output, failed)
+	}
+
+	expectOutput(t, "pub fn f() -> i32 { ret 2; }\nprintln(f());\n", "2\n")
+	expectFailure(t, "priv fn f() {}")
+	expectFailure(t, "fn f() { pub let x := 1; }")
+}
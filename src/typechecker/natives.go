@@ -0,0 +1,323 @@
+package typechecker
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+	"walrus/frontend/ast"
+	"walrus/frontend/parser"
+)
+
+// Natives are functions written in Go. An embedder declares a native for every program with
+// DeclareNativeFn, or groups natives in a module that programs import like a .wal module:
+//
+//	typechecker.RegisterNativeModule(typechecker.NativeModule{
+//		Path: "app::db",
+//		Functions: []typechecker.NativeFunction{{
+//			Signature: typechecker.NativeSignature("count", i64, typechecker.NativeParam("table", str)),
+//			Call:      count,
+//		}},
+//	})
+//
+// where i64 and str are the ast types of the result and the parameter. import "app::db"; then binds
+// count, and a native module comes before a .wal file with the same path.
+// Every function of a native module has a signature: the flow analysis checks the number and the types
+// of the arguments of its calls and knows the type of its result, and the arguments are converted to the
+// parameter types like those of a function declared in Walrus. A native that can fail returns T! and
+// gives ResultOf(value, err).
+//
+// The interpreter registers the core modules: core::os with time, env, platform and exit, core::io
+// with read_line, and core::fs with the files and directories (see fs.go).
+
+// NativeModule is a module written in Go. Path is what programs import, e.g. "core::os"
+type NativeModule struct {
+	Path      string
+	Functions []NativeFunction
+	// the structs the functions take or give, exported like the functions
+	Structs []NativeStruct
+}
+
+// NativeFunction is a function of a native module. Signature.Name is the name programs call it by
+type NativeFunction struct {
+	Signature ast.FunctionType
+	Call      FunctionCall
+}
+
+// NativeStruct is a struct of a native module. A native makes its instances with StructInstance
+type NativeStruct struct {
+	Name   string
+	Fields []ast.Property
+}
+
+// NativeField makes a public field of a native struct
+func NativeField(name string, t ast.Type) ast.Property {
+	return ast.Property{BaseStmt: ast.BaseStmt{Kind: ast.STRUCT_PROPERTY}, IsPublic: true, Name: name, Type: t}
+}
+
+// NativeSignature makes the signature of a native function
+func NativeSignature(name string, returnType ast.Type, params ...ast.FunctionParameter) ast.FunctionType {
+	return ast.FunctionType{Kind: ast.T_FUNCTION, Name: name, ReturnType: returnType, Parameters: params}
+}
+
+// NativeParam makes a parameter of a native function
+func NativeParam(name string, t ast.Type) ast.FunctionParameter {
+	return ast.FunctionParameter{
+		BaseStmt:   ast.BaseStmt{Kind: ast.FUNCTION_PARAMETER},
+		Identifier: ast.IdentifierExpr{BaseStmt: ast.BaseStmt{Kind: ast.IDENTIFIER}, Identifier: name},
+		Type:       t,
+	}
+}
+
+var nativeModules = map[string]NativeModule{
+	"core::os": coreOS(),
+	"core::io": coreIO(),
+	"core::fs": coreFS(),
+}
+
+// RegisterNativeModule makes a native module available to import. It fails if a native module has the same path
+func RegisterNativeModule(module NativeModule) error {
+
+	if _, taken := nativeModules[module.Path]; taken {
+		return fmt.Errorf("native module \"%s\" is already registered", module.Path)
+	}
+
+	names := make(map[string]bool)
+
+	for _, function := range module.Functions {
+		if function.Signature.Name == "" || function.Call == nil {
+			return fmt.Errorf("a function of native module \"%s\" has no name or no implementation", module.Path)
+		}
+		if names[function.Signature.Name] {
+			return fmt.Errorf("native module \"%s\" declares '%s' twice", module.Path, function.Signature.Name)
+		}
+		names[function.Signature.Name] = true
+	}
+
+	for _, structure := range module.Structs {
+		if structure.Name == "" {
+			return fmt.Errorf("a struct of native module \"%s\" has no name", module.Path)
+		}
+		if names[structure.Name] {
+			return fmt.Errorf("native module \"%s\" declares '%s' twice", module.Path, structure.Name)
+		}
+		names[structure.Name] = true
+	}
+
+	nativeModules[module.Path] = module
+
+	return nil
+}
+
+// loadNativeModule makes the module of a native module. Its functions are declared in an environment of their own
+func loadNativeModule(native NativeModule) *module {
+
+	env := NewEnvironment(nil, nil)
+
+	loaded := &module{path: native.Path, symbols: make(map[string]*Environment), private: make(map[string]privateSymbol)}
+
+	for _, function := range native.Functions {
+		signature := function.Signature
+		env.variables[signature.Name] = NativeFunctionValue{
+			Caller:    function.Call,
+			Type:      ast.NativeFnType{Kind: ast.T_NATIVE_FN},
+			Signature: &signature,
+		}
+		loaded.symbols[signature.Name] = env
+	}
+
+	for _, structure := range native.Structs {
+		fields := make(map[string]ast.Property)
+		for i, field := range structure.Fields {
+			// typeof(x).fields() lists the fields in the order of their positions
+			field.StartPos.Index = i
+			fields[field.Name] = field
+		}
+		env.structs[structure.Name] = StructValue{
+			Fields:  fields,
+			Methods: make(map[string]ast.FunctionType),
+			Type:    ast.StructType{Kind: ast.T_STRUCT, Name: structure.Name},
+		}
+		loaded.symbols[structure.Name] = env
+	}
+
+	return loaded
+}
+
+// callNative calls a native function. The arguments of a native with a signature are checked and converted first
+func callNative(native NativeFunctionValue, expr ast.FunctionCallExpr, args []RuntimeValue, env *Environment) RuntimeValue {
+
+	signature := native.Signature
+
+	if signature == nil {
+		return native.Caller(args...)
+	}
+
+	if len(args) != len(signature.Parameters) {
+		runtimeError(env, expr.StartPos, expr.EndPos, nativeArgumentCountError(*signature, len(args))).Throw()
+	}
+
+	for i, param := range signature.Parameters {
+		arg, err := bindArgument(param, args[i], expr.Args[i], env)
+		if err != nil {
+			start, end := expr.Args[i].GetPos()
+			runtimeError(env, start, end, err.Error()).Throw()
+		}
+		args[i] = arg
+	}
+
+	result := native.Caller(args...)
+
+	if signature.ReturnType.IType() == ast.T_VOID {
+		return MakeVOID()
+	}
+
+	if result == nil || !matchesType(result, signature.ReturnType) {
+		runtimeError(env, expr.StartPos, expr.EndPos, fmt.Sprintf("native function '%s' gave a value of type %s, but it returns %s", signature.Name, nativeResultName(result), typeName(signature.ReturnType))).Throw()
+	}
+
+	return result
+}
+
+func nativeResultName(result RuntimeValue) string {
+	if result == nil {
+		return "nil"
+	}
+	return valueTypeName(result)
+}
+
+func nativeArgumentCountError(signature ast.FunctionType, count int) string {
+	return fmt.Sprintf("function '%s' expects %d arguments but %d were provided", signature.Name, len(signature.Parameters), count)
+}
+
+// signatureStructs returns the names of the structs in the parameters and the result of a signature
+func signatureStructs(signature *ast.FunctionType) []string {
+
+	var names []string
+
+	var visit func(t ast.Type)
+	visit = func(t ast.Type) {
+		switch t := t.(type) {
+		case ast.StructType:
+			names = append(names, t.Name)
+		case ast.ResultType:
+			visit(t.Value)
+		case ast.OptionalType:
+			visit(t.Inner)
+		case ast.ArrayType:
+			visit(t.ElementType)
+		}
+	}
+
+	visit(signature.ReturnType)
+	for _, param := range signature.Parameters {
+		visit(param.Type)
+	}
+
+	return names
+}
+
+// nativeOf returns the signature of the native a call refers to, or nil. A variable or a function with the same name hides it
+func (a *flowAnalyzer) nativeOf(expr ast.FunctionCallExpr) *ast.FunctionType {
+
+	name := expr.Caller.Identifier
+
+	if _, declared := a.functions[name]; declared || a.resolve(name) != nil {
+		return nil
+	}
+
+	value, err := a.env.GetRuntimeValue(name)
+
+	if err != nil {
+		return nil
+	}
+
+	if native, ok := value.(NativeFunctionValue); ok {
+		return native.Signature
+	}
+
+	return nil
+}
+
+// analyzeNativeCall checks the arguments of a call of a native with a signature before the program runs
+func (a *flowAnalyzer) analyzeNativeCall(expr ast.FunctionCallExpr, signature *ast.FunctionType) {
+
+	if len(expr.Args) != len(signature.Parameters) {
+		parser.MakeError(a.env.parser, expr.StartPos.Line, a.env.parser.FilePath, expr.StartPos, expr.EndPos, nativeArgumentCountError(*signature, len(expr.Args))).Display()
+	}
+
+	for i, arg := range expr.Args {
+		a.checkElementType(signature.Parameters[i].Type, arg, a.inferType(arg))
+	}
+}
+
+// coreOS is core::os, the process and its environment
+func coreOS() NativeModule {
+	return NativeModule{Path: "core::os", Functions: []NativeFunction{
+		{Signature: NativeSignature("time", i64Type), Call: osTime},
+		{Signature: NativeSignature("env", makeOptional(strType), NativeParam("name", strType)), Call: osEnv},
+		{Signature: NativeSignature("platform", strType), Call: osPlatform},
+		{Signature: NativeSignature("exit", ast.VoidType{Kind: ast.T_VOID}, NativeParam("code", ast.IntegerType{Kind: ast.T_INTEGER32, BitSize: 32, IsSigned: true})), Call: osExit},
+	}}
+}
+
+// osTime returns the seconds since January 1, 1970 UTC
+func osTime(args ...RuntimeValue) RuntimeValue {
+	return MakeINT(time.Now().Unix(), 64, true)
+}
+
+// osEnv returns the value of an environment variable, or null if it is not set
+func osEnv(args ...RuntimeValue) RuntimeValue {
+	value, ok := os.LookupEnv(args[0].(StringValue).Value)
+	if !ok {
+		return MakeNULL()
+	}
+	return MakeSTRING(value)
+}
+
+// osPlatform returns the operating system, like linux, darwin or windows
+func osPlatform(args ...RuntimeValue) RuntimeValue {
+	return MakeSTRING(runtime.GOOS)
+}
+
+// osExit stops the program at once with a status code. Deferred calls do not run
+func osExit(args ...RuntimeValue) RuntimeValue {
+	os.Exit(int(args[0].(IntegerValue).Value))
+	return MakeVOID()
+}
+
+// Input is where read_line reads. An embedder can replace it before the program runs
+var Input io.Reader = os.Stdin
+
+// input reads Input by lines. It is made at the first read, so that Input can be replaced
+var input *bufio.Reader
+
+// coreIO is core::io, the standard input
+func coreIO() NativeModule {
+	return NativeModule{Path: "core::io", Functions: []NativeFunction{
+		{Signature: NativeSignature("read_line", ast.ResultType{Kind: ast.T_RESULT, Value: strType}), Call: ioReadLine},
+	}}
+}
+
+// ioReadLine reads a line of the input without its line break. At the end of the input it returns an error
+func ioReadLine(args ...RuntimeValue) RuntimeValue {
+
+	if input == nil {
+		input = bufio.NewReader(Input)
+	}
+
+	line, err := input.ReadString('\n')
+
+	if errors.Is(err, io.EOF) && line == "" {
+		return MakeERROR("end of input")
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return MakeERROR(err.Error())
+	}
+
+	return MakeSTRING(strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"))
+}
@@ -0,0 +1,154 @@
+package typechecker
+
+import (
+	"fmt"
+	"walrus/frontend/ast"
+	"walrus/frontend/lexer"
+	"walrus/frontend/parser"
+)
+
+// Types are non-null by default. Only a variable, field or parameter declared with T? can hold null,
+// and its value must be checked before it is used as a T:
+//
+//	let name : str? = find();
+//	if name != null {
+//	    // name is a str here
+//	}
+//
+// The flow analysis tracks the optional variables that are known not to be null at each point of the program.
+
+// narrow marks the variables that cannot be null when the condition has the given result
+func (a *flowAnalyzer) narrow(condition ast.Expression, result bool) {
+	switch condition := condition.(type) {
+	case ast.BinaryExpr:
+		switch condition.Operator.Kind {
+		case lexer.AND_TOKEN:
+			// both sides are true when a && b is true
+			if result {
+				a.narrow(condition.Left, true)
+				a.narrow(condition.Right, true)
+			}
+		case lexer.OR_TOKEN:
+			// both sides are false when a || b is false
+			if !result {
+				a.narrow(condition.Left, false)
+				a.narrow(condition.Right, false)
+			}
+		case lexer.NOT_EQUALS_TOKEN, lexer.EQUALS_TOKEN:
+			isNotNull := (condition.Operator.Kind == lexer.NOT_EQUALS_TOKEN) == result
+			if !isNotNull {
+				return
+			}
+			if variable := a.comparedWithNull(condition); variable != nil {
+				a.state.nonNull[variable] = true
+			}
+		}
+	case ast.UnaryExpr:
+		if condition.Operator.Value == "!" {
+			a.narrow(condition.Argument, !result)
+		}
+	}
+}
+
+// comparedWithNull returns the variable of x == null, null == x, x != null or null != x
+func (a *flowAnalyzer) comparedWithNull(comparison ast.BinaryExpr) *flowVariable {
+	operand := comparison.Left
+	if _, isNull := comparison.Left.(ast.NullLiteral); isNull {
+		operand = comparison.Right
+	} else if _, isNull := comparison.Right.(ast.NullLiteral); !isNull {
+		return nil
+	}
+
+	identifier, ok := operand.(ast.IdentifierExpr)
+	if !ok {
+		return nil
+	}
+
+	return a.resolve(identifier.Identifier)
+}
+
+// assignNullability records if a variable may be null after a value is assigned to it
+func (a *flowAnalyzer) assignNullability(variable *flowVariable, value ast.Expression) {
+	if a.state.unreachable {
+		return
+	}
+
+	if isNonNullType(a.inferType(value)) {
+		a.state.nonNull[variable] = true
+	} else {
+		delete(a.state.nonNull, variable)
+	}
+}
+
+// checkNullAssignment rejects storing null, or a value that may be null, in a place whose type does not accept null
+func (a *flowAnalyzer) checkNullAssignment(target ast.Type, value ast.Expression) {
+	if target == nil {
+		return
+	}
+
+	valueType := a.inferType(value)
+
+	// a value that may be an error is only stored in a T!
+	result, isResult := target.(ast.ResultType)
+	if _, valueIsResult := valueType.(ast.ResultType); valueIsResult && !isResult {
+		a.reportMaybeError(value, valueType)
+	}
+	if isResult {
+		target = result.Value
+	}
+
+	if _, isOptional := target.(ast.OptionalType); isOptional || valueType == nil {
+		return
+	}
+
+	start, end := value.GetPos()
+
+	switch valueType.(type) {
+	case ast.ResultType, ast.ErrorType:
+		// checked above
+	case ast.NullType:
+		parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("cannot assign null to a value of type %s", typeName(target))).AddHint("declare the type as ", parser.TEXT_HINT).AddHint(typeName(target)+"?", parser.CODE_HINT).AddHint(" to allow null", parser.TEXT_HINT).Display()
+	case ast.OptionalType:
+		a.reportMaybeNull(value, valueType)
+	}
+}
+
+// checkNotNull rejects using a value that may be null where a value is required
+func (a *flowAnalyzer) checkNotNull(expr ast.Expression) {
+	if t, isOptional := a.inferType(expr).(ast.OptionalType); isOptional {
+		a.reportMaybeNull(expr, t)
+	}
+}
+
+func (a *flowAnalyzer) reportMaybeNull(expr ast.Expression, t ast.Type) {
+	start, end := expr.GetPos()
+
+	err := parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("value of type %s may be null", typeName(t)))
+
+	if identifier, ok := expr.(ast.IdentifierExpr); ok {
+		err.AddHint("check it first with ", parser.TEXT_HINT).AddHint(fmt.Sprintf("if %s != null { ... }", identifier.Identifier), parser.CODE_HINT).AddHint(" or give a default with ??", parser.TEXT_HINT)
+	} else {
+		err.AddHint("give a default with ?? or use ?. to access its properties", parser.TEXT_HINT)
+	}
+
+	err.Display()
+}
+
+// isNonNullType reports if a value of the type is known not to be null
+func isNonNullType(t ast.Type) bool {
+	switch t.(type) {
+	case nil, ast.NullType, ast.OptionalType:
+		return false
+	default:
+		return true
+	}
+}
+
+// makeOptional returns T? for T. Optional types are returned unchanged
+func makeOptional(t ast.Type) ast.Type {
+	switch t.(type) {
+	case nil, ast.OptionalType, ast.NullType:
+		return t
+	}
+	return ast.OptionalType{Kind: ast.T_OPTIONAL, Inner: t}
+}
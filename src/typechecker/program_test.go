@@ -0,0 +1,80 @@
+package typechecker
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"walrus/frontend/parser"
+)
+
+// runProgram runs Walrus source like the interpreter does and returns what it printed.
+// failed is true when the program stopped with a static or a runtime error
+func runProgram(t *testing.T, source string) (output string, failed bool) {
+	t.Helper()
+	return runProgramWith(t, source, nil)
+}
+
+// runProgramWith runs a program next to other files, like the modules it imports. The keys are the paths
+// of the files relative to the program
+func runProgramWith(t *testing.T, source string, files map[string]string) (output string, failed bool) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for path, contents := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, path), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file := filepath.Join(dir, "main.wal")
+	if err := os.WriteFile(file, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	previous := Output
+	Output = &buffer
+	defer func() { Output = previous }()
+
+	defer func() {
+		if r := recover(); r != nil {
+			output, failed = buffer.String(), true
+		}
+	}()
+
+	p := parser.NewParser(file, false)
+	program := p.Parse()
+
+	env := NewEnvironment(nil, p)
+	env.DeclareVariable("true", MakeBOOL(true), true)
+	env.DeclareVariable("false", MakeBOOL(false), true)
+	env.DeclareVariable("null", MakeNULL(), true)
+
+	Evaluate(program, env)
+
+	return buffer.String(), false
+}
+
+// expectOutput runs a program that must succeed and compares what it printed
+func expectOutput(t *testing.T, source string, expected string) {
+	t.Helper()
+	output, failed := runProgram(t, source)
+	if failed {
+		t.Fatalf("the program failed:\n%s", source)
+	}
+	if output != expected {
+		t.Errorf("got output %q, expected %q", output, expected)
+	}
+}
+
+// expectFailure runs a program that must stop with an error
+func expectFailure(t *testing.T, source string) {
+	t.Helper()
+	if _, failed := runProgram(t, source); !failed {
+		t.Errorf("the program did not fail:\n%s", source)
+	}
+}
@@ -0,0 +1,287 @@
+package typechecker
+
+import (
+	"fmt"
+	"math/big"
+	"walrus/frontend/ast"
+	"walrus/frontend/lexer"
+	"walrus/frontend/parser"
+)
+
+// A range is a sequence of integers:
+//
+//	0..5            // 0, 1, 2, 3, 4
+//	0..=5           // 0, 1, 2, 3, 4, 5
+//	0..10 step 3    // 0, 3, 6, 9
+//	5..0 step -1    // 5, 4, 3, 2, 1
+//
+// A range is lazy: it holds only its bounds, so 0..1_000_000_000 does not allocate anything.
+// A range whose start is after its end is empty. Ranges are used in foreach, as switch cases,
+// and to slice arrays and strings: arr[1..3] is a new array with the elements 1 and 2.
+//
+// The bounds have a common integer type like the operands of +, a constant bound takes
+// the type of the other bound. The step is any non-zero integer.
+
+// EvaluateRangeExpr evaluates the bounds and the step of start..end step n
+func EvaluateRangeExpr(expr ast.RangeExpr, env *Environment) RuntimeValue {
+
+	start := rangeBound(expr.Start, "bounds", env)
+	end := rangeBound(expr.End, "bounds", env)
+
+	common, err := commonBranchType(start.Type, end.Type, isNumericConstant(expr.Start), isNumericConstant(expr.End))
+
+	if err != nil || common == nil {
+		runtimeError(env, expr.StartPos, expr.EndPos, fmt.Sprintf("the bounds of a range have different types %s and %s", typeName(start.Type), typeName(end.Type))).AddHint("convert one of them with 'as'", parser.TEXT_HINT).Throw()
+	}
+
+	start = convertBound(start, common, expr.Start, env)
+	end = convertBound(end, common, expr.End, env)
+
+	step := MakeINT(1, 64, true)
+
+	if expr.Step != nil {
+		step = rangeBound(expr.Step, "step", env)
+		if step.bigValue().Sign() == 0 {
+			start, end := expr.Step.GetPos()
+			runtimeError(env, start, end, "the step of a range cannot be 0").AddHint("count down with a negative step, e.g. ", parser.TEXT_HINT).AddHint("10..0 step -1", parser.CODE_HINT).Throw()
+		}
+	}
+
+	return MakeRANGE(start, end, step, expr.Inclusive)
+}
+
+// rangeBound evaluates a bound or the step of a range, which must be an integer
+func rangeBound(expr ast.Expression, what string, env *Environment) IntegerValue {
+
+	value := Evaluate(expr, env)
+
+	integer, ok := value.(IntegerValue)
+
+	if !ok {
+		start, end := expr.GetPos()
+		runtimeError(env, start, end, fmt.Sprintf("the %s of a range must be integers, got %s", what, valueTypeName(value))).Throw()
+	}
+
+	return integer
+}
+
+func convertBound(bound IntegerValue, t ast.Type, expr ast.Expression, env *Environment) IntegerValue {
+
+	converted, err := convertImplicitly(bound, t, isNumericConstant(expr))
+
+	if err != nil {
+		start, end := expr.GetPos()
+		runtimeError(env, start, end, err.Error()).Throw()
+	}
+
+	return converted.(IntegerValue)
+}
+
+// length returns the number of elements of the range
+func (r RangeValue) length() *big.Int {
+
+	step := r.Step.bigValue()
+
+	// distance from the start to the last value that may be an element
+	span := new(big.Int).Sub(r.End.bigValue(), r.Start.bigValue())
+	if step.Sign() < 0 {
+		span.Neg(span)
+		step.Neg(step)
+	}
+	if !r.Inclusive {
+		span.Sub(span, big.NewInt(1))
+	}
+
+	if span.Sign() < 0 {
+		return big.NewInt(0)
+	}
+
+	span.Quo(span, step)
+
+	return span.Add(span, big.NewInt(1))
+}
+
+// at returns the element at a position that is less than the length
+func (r RangeValue) at(position *big.Int) IntegerValue {
+
+	value := new(big.Int).Mul(position, r.Step.bigValue())
+	value.Add(value, r.Start.bigValue())
+
+	// every element is between the bounds, so it fits in the element type
+	element, _ := makeINTFromBig(value, r.Start.Size, r.Start.isSigned())
+
+	return element
+}
+
+// contains reports if the integer is one of the elements of the range
+func (r RangeValue) contains(value IntegerValue) bool {
+
+	step := r.Step.bigValue()
+
+	offset := new(big.Int).Sub(value.bigValue(), r.Start.bigValue())
+	if step.Sign() < 0 {
+		offset.Neg(offset)
+		step.Neg(step)
+	}
+
+	if offset.Sign() < 0 {
+		return false
+	}
+
+	position, remainder := new(big.Int).QuoRem(offset, step, new(big.Int))
+
+	return remainder.Sign() == 0 && position.Cmp(r.length()) < 0
+}
+
+// iterator returns the elements one by one, without storing them
+func (r RangeValue) iterator() func() (RuntimeValue, bool) {
+
+	remaining := r.length()
+	position := big.NewInt(0)
+
+	return func() (RuntimeValue, bool) {
+		if position.Cmp(remaining) >= 0 {
+			return nil, false
+		}
+		element := r.at(position)
+		position.Add(position, big.NewInt(1))
+		return element, true
+	}
+}
+
+func (r RangeValue) String() string {
+
+	operator := ".."
+	if r.Inclusive {
+		operator = "..="
+	}
+
+	text := r.Start.String() + operator + r.End.String()
+
+	if step := r.Step.bigValue(); step.Cmp(big.NewInt(1)) != 0 {
+		text += " step " + step.String()
+	}
+
+	return text
+}
+
+// sliceValue returns the elements of an array, or the bytes of a string, at the positions of the range.
+// The slice of an array is a new array, changing it does not change the original. See sliceString for strings
+func sliceValue(value RuntimeValue, r RangeValue, expr ast.ArrayIndexAccess, env *Environment) RuntimeValue {
+
+	var length int
+	var what string
+
+	switch v := value.(type) {
+	case ArrayValue:
+		length, what = len(v.Elements), "an array"
+	case StringValue:
+		length, what = len(v.Value), "a string"
+	default:
+		start, end := expr.Array.GetPos()
+		runtimeError(env, start, end, fmt.Sprintf("cannot slice a value of type %s", valueTypeName(value))).AddHint("arrays and strings can be sliced", parser.TEXT_HINT).Throw()
+	}
+
+	checkSliceBounds(r, length, what, expr, env)
+
+	if text, isString := value.(StringValue); isString {
+		return sliceString(text, r, expr, env)
+	}
+
+	array := value.(ArrayValue)
+	elements := []RuntimeValue{}
+
+	next := r.iterator()
+	for position, ok := next(); ok; position, ok = next() {
+		elements = append(elements, array.Elements[position.(IntegerValue).bigValue().Int64()])
+	}
+
+	return MakeARRAY(elements, array.ElementType)
+}
+
+// checkSliceBounds checks that every position of the range is in the value. An empty range must start in the value or right after it
+func checkSliceBounds(r RangeValue, length int, what string, expr ast.ArrayIndexAccess, env *Environment) {
+
+	limit := big.NewInt(int64(length))
+
+	count := r.length()
+
+	first := r.Start.bigValue()
+	last := first
+
+	if count.Sign() > 0 {
+		last = r.at(new(big.Int).Sub(count, big.NewInt(1))).bigValue()
+		// the positions must be less than the length
+		limit.Sub(limit, big.NewInt(1))
+	}
+
+	for _, position := range []*big.Int{first, last} {
+		if position.Sign() < 0 || position.Cmp(limit) > 0 {
+			start, end := expr.Index.GetPos()
+			runtimeError(env, start, end, fmt.Sprintf("range %s is out of bounds for %s of length %d", r, what, length)).Throw()
+		}
+	}
+}
+
+// matchesCase reports if a switch case matches the discriminant. A range case matches the integers it contains,
+// other cases match a value equal to the discriminant
+func matchesCase(discriminant RuntimeValue, test ast.Expression, env *Environment) bool {
+
+	value := Evaluate(test, env)
+
+	if r, isRange := value.(RangeValue); isRange {
+		integer, isInteger := discriminant.(IntegerValue)
+		return isInteger && r.contains(integer)
+	}
+
+	result, err := evaluateComparisonExpr(discriminant, value, lexer.Token{Kind: lexer.EQUALS_TOKEN, Value: "=="})
+
+	if err != nil {
+		start, end := test.GetPos()
+		runtimeError(env, start, end, err.Error()).Throw()
+	}
+
+	return result.(BooleanValue).Value
+}
+
+// inferRangeType returns range[T], where T is the common type of the bounds, or nil if it is not known
+func (a *flowAnalyzer) inferRangeType(expr ast.RangeExpr) ast.Type {
+
+	common, err := commonBranchType(a.inferType(expr.Start), a.inferType(expr.End), isNumericConstant(expr.Start), isNumericConstant(expr.End))
+
+	if err != nil || categoryOf(common) != integerCategory {
+		return nil
+	}
+
+	return ast.RangeType{Kind: ast.T_RANGE, Element: common}
+}
+
+// analyzeRange rejects bounds and steps that are not integers before the program runs
+func (a *flowAnalyzer) analyzeRange(expr ast.RangeExpr) {
+
+	parts := []ast.Expression{expr.Start, expr.End}
+	if expr.Step != nil {
+		parts = append(parts, expr.Step)
+	}
+
+	for _, part := range parts {
+		a.analyzeExpr(part)
+		a.checkNotNull(part)
+
+		if t := a.inferType(part); t != nil && categoryOf(t) != integerCategory {
+			start, end := part.GetPos()
+			parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("a range needs integers, got %s", typeName(t))).AddHint("convert the value with ", parser.TEXT_HINT).AddHint("as i64", parser.CODE_HINT).Display()
+		}
+	}
+}
+
+// checkRangeCase rejects a range case in a switch on a value that is not an integer
+func (a *flowAnalyzer) checkRangeCase(discriminant ast.Type, test ast.Expression) {
+
+	if _, isRange := test.(ast.RangeExpr); !isRange || discriminant == nil || categoryOf(discriminant) == integerCategory {
+		return
+	}
+
+	start, end := test.GetPos()
+	parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("a range case matches integers, but the switch value is %s", typeName(discriminant))).Display()
+}
@@ -0,0 +1,189 @@
+package typechecker
+
+import (
+	"fmt"
+	"math/big"
+	"walrus/frontend/ast"
+	"walrus/frontend/lexer"
+	"walrus/frontend/parser"
+	"walrus/helpers"
+)
+
+// reference is a place that holds a value: a variable, a struct field, an array element or the value of a map key.
+// The object and the index of the place are evaluated once, so a[next()] += 1 calls next only once.
+type reference struct {
+	get func() RuntimeValue
+	// set stores the value converted to the type of the place and returns the stored value
+	set func(value RuntimeValue, isConstant bool) (RuntimeValue, error)
+}
+
+func resolveReference(target ast.Expression, env *Environment) reference {
+
+	switch target := target.(type) {
+	case ast.IdentifierExpr:
+		return variableReference(target, env)
+	case ast.StructPropertyExpr:
+		if target.Optional {
+			start, end := target.GetPos()
+			runtimeError(env, start, end, "cannot assign to a property accessed with ?.").AddHint("check the object with if obj != null first", parser.TEXT_HINT).Throw()
+		}
+		return fieldReference(target, env)
+	case ast.ArrayIndexAccess:
+		value := Evaluate(target.Array, env)
+		if m, isMap := value.(MapValue); isMap {
+			return mapReference(m, target, env)
+		}
+		indexValue := Evaluate(target.Index, env)
+		if _, isRange := indexValue.(RangeValue); isRange {
+			start, end := target.GetPos()
+			runtimeError(env, start, end, "cannot assign to a slice").AddHint("a slice is a copy. assign to the elements of the array instead", parser.TEXT_HINT).Throw()
+		}
+		array, index := evaluateArrayElement(value, indexValue, target, env)
+		return reference{
+			get: func() RuntimeValue {
+				return array.Elements[index]
+			},
+			set: func(value RuntimeValue, isConstant bool) (RuntimeValue, error) {
+				return storeElement(array, index, value, isConstant)
+			},
+		}
+	default:
+		start, end := target.GetPos()
+		runtimeError(env, start, end, "invalid left-hand side in assignment expression").AddHint("only variables, struct fields and array elements can be assigned", parser.TEXT_HINT).Throw()
+		return reference{}
+	}
+}
+
+func variableReference(identifier ast.IdentifierExpr, env *Environment) reference {
+
+	//if assigne is any of "false", "true", "null";
+	if helpers.ContainsIn([]string{"false", "true", "null"}, identifier.Identifier) {
+		runtimeError(env, identifier.StartPos, identifier.EndPos, fmt.Sprintf("cannot assign to built-in constant %v", identifier.Identifier)).Throw()
+	}
+
+	current := EvaluateIdenitifierExpr(identifier, env)
+
+	return reference{
+		get: func() RuntimeValue {
+			return current
+		},
+		set: func(value RuntimeValue, isConstant bool) (RuntimeValue, error) {
+			// a numeric constant takes the type of the variable it is assigned to
+			if isConstant {
+				target := env.getDeclaredType(identifier.Identifier)
+				if target == nil {
+					target = typeOfValue(current)
+				}
+				converted, err := convertImplicitly(value, target, true)
+				if err != nil {
+					return nil, err
+				}
+				value = converted
+			}
+			return env.AssignVariable(identifier.Identifier, value)
+		},
+	}
+}
+
+func fieldReference(expr ast.StructPropertyExpr, env *Environment) reference {
+
+	object := Evaluate(expr.Object, env)
+
+	instance, ok := object.(StructInstance)
+
+	if _, isNull := object.(NullValue); isNull {
+		start, end := expr.Object.GetPos()
+		runtimeError(env, start, end, fmt.Sprintf("cannot access property '%s' of null", expr.Property.Identifier)).Throw()
+	} else if !ok {
+		start, end := expr.Object.GetPos()
+		runtimeError(env, start, end, fmt.Sprintf("cannot access property '%s' of a value of type %s", expr.Property.Identifier, valueTypeName(object))).Throw()
+	}
+
+	name := expr.Property.Identifier
+
+	structValue, err := env.GetStructType(instance.StructName)
+
+	if err != nil {
+		runtimeError(env, expr.StartPos, expr.EndPos, err.Error()).Throw()
+	}
+
+	property, declared := structValue.(StructValue).Fields[name]
+
+	if !declared {
+		runtimeError(env, expr.Property.StartPos, expr.Property.EndPos, fmt.Sprintf("property '%s' is not defined in struct '%s'", name, instance.StructName)).Throw()
+	}
+
+	return reference{
+		get: func() RuntimeValue {
+			return instance.Fields[name]
+		},
+		set: func(value RuntimeValue, isConstant bool) (RuntimeValue, error) {
+			if property.ReadOnly {
+				return nil, fmt.Errorf("property '%s' of struct '%s' is read-only", name, instance.StructName)
+			}
+
+			converted, err := convertImplicitly(value, property.Type, isConstant)
+			if err != nil {
+				return nil, err
+			}
+
+			if !matchesType(converted, property.Type) {
+				return nil, fmt.Errorf("cannot assign a value of type %s to property '%s' of type %s", valueTypeName(converted), name, typeName(property.Type))
+			}
+
+			// the fields are shared by every copy of the instance
+			instance.Fields[name] = converted
+
+			return converted, nil
+		},
+	}
+}
+
+// evaluateIncrement applies ++ or -- to a variable, a struct field or an array element.
+// It returns the old and the new value. The value keeps its type.
+func evaluateIncrement(target ast.Expression, operator lexer.Token, env *Environment) (RuntimeValue, RuntimeValue) {
+
+	place := resolveReference(target, env)
+
+	current := place.get()
+
+	var updated RuntimeValue
+	var err error
+
+	switch value := current.(type) {
+	case IntegerValue:
+		result := value.bigValue()
+		if operator.Value == "++" {
+			result.Add(result, big.NewInt(1))
+		} else {
+			result.Sub(result, big.NewInt(1))
+		}
+		updated, err = makeINTFromBig(result, value.Size, value.isSigned())
+	case FloatValue:
+		if operator.Value == "++" {
+			updated = MakeFLOAT(value.Value+1, value.Size)
+		} else {
+			updated = MakeFLOAT(value.Value-1, value.Size)
+		}
+	default:
+		err = fmt.Errorf("operator %s needs a number, got %s", operator.Value, valueTypeName(current))
+	}
+
+	if err == nil {
+		updated, err = place.set(updated, false)
+	}
+
+	if err != nil {
+		start, end := target.GetPos()
+		runtimeError(env, start, end, err.Error()).Throw()
+	}
+
+	return current, updated
+}
+
+func EvaluatePostfixExpr(expr ast.PostfixExpr, env *Environment) RuntimeValue {
+
+	old, _ := evaluateIncrement(expr.Argument, expr.Operator, env)
+
+	return old
+}
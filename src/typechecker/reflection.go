@@ -0,0 +1,225 @@
+package typechecker
+
+import (
+	"fmt"
+	"sort"
+	"walrus/frontend/ast"
+	"walrus/frontend/lexer"
+	"walrus/frontend/parser"
+)
+
+// typeof(x) gives the type of the value of x as a value of type 'type':
+//
+//	let t := typeof(hero);
+//	println(t);                   // Hero
+//	t == typeof(other)            // true if other is a Hero too
+//	t.kind()                      // "struct"
+//	foreach (name, field) in t.fields() { ... }   // the public fields and their types
+//
+// typeof looks at the value, not at the declared type: a variable of type i32? that holds 5 gives i32,
+// and one that holds null gives null. Two types are equal if they have the same name.
+//
+// The methods of a type are name, kind, fields, element, key and elements. The element of an array,
+// a set, a range, a map or an optional type is the type of what it holds, and null for the other kinds.
+
+var (
+	typeType = ast.TypeType{Kind: ast.T_TYPE}
+	// the result of fields(), the name and the type of each field
+	fieldListType = ast.ArrayType{Kind: ast.T_ARRAY, ElementType: ast.TupleType{Kind: ast.T_TUPLE, Elements: []ast.Type{strType, typeType}}}
+)
+
+// EvaluateTypeofExpr evaluates typeof(expr)
+func EvaluateTypeofExpr(expr ast.TypeofExpr, env *Environment) RuntimeValue {
+	return MakeTYPE(runtimeTypeOf(Evaluate(expr.Expression, env)))
+}
+
+// runtimeTypeOf returns the type of any value, including the values typeOfValue knows no type for
+func runtimeTypeOf(value RuntimeValue) ast.Type {
+	if t := typeOfValue(value); t != nil {
+		return t
+	}
+	switch v := value.(type) {
+	case NullValue:
+		return v.Type
+	case VoidValue:
+		return v.Type
+	case NativeFunctionValue:
+		return v.Type
+	case StructValue:
+		return v.Type
+	}
+	return ast.VoidType{Kind: ast.T_VOID}
+}
+
+// checkTypeComparison rejects comparing a type with a value of another type, like typeof(x) == "i32",
+// which compares the type with a string
+func (a *flowAnalyzer) checkTypeComparison(expr ast.BinaryExpr) {
+
+	switch expr.Operator.Kind {
+	case lexer.EQUALS_TOKEN, lexer.NOT_EQUALS_TOKEN, lexer.LESS_TOKEN, lexer.LESS_EQUALS_TOKEN, lexer.GREATER_TOKEN, lexer.GREATER_EQUALS_TOKEN:
+	default:
+		return
+	}
+
+	left, right := a.inferType(expr.Left), a.inferType(expr.Right)
+
+	if left == nil || right == nil || (left.IType() == ast.T_TYPE) == (right.IType() == ast.T_TYPE) || left.IType() == ast.T_NULL || right.IType() == ast.T_NULL {
+		return
+	}
+
+	parser.MakeError(a.env.parser, expr.StartPos.Line, a.env.parser.FilePath, expr.StartPos, expr.EndPos, fmt.Sprintf("cannot compare a value of type '%s' with a value of type '%s'", typeName(left), typeName(right))).AddHint("compare the name of the type, e.g. ", parser.TEXT_HINT).AddHint("typeof(x).name() == \"i32\"", parser.CODE_HINT).Display()
+}
+
+// kindOf returns the name of the kind of a type, e.g. "integer" for every integer type
+func kindOf(t ast.Type) string {
+	switch t.(type) {
+	case ast.IntegerType:
+		return "integer"
+	case ast.FloatType:
+		return "float"
+	case ast.BoolType:
+		return "bool"
+	case ast.StringType:
+		return "str"
+	case ast.CharType:
+		return "chr"
+	case ast.FunctionType, ast.NativeFnType:
+		return "function"
+	}
+	return string(t.IType())
+}
+
+// typeMethod is a method of a type value. The methods of a type take no arguments
+type typeMethod struct {
+	returnType ast.Type
+	call       func(t ast.Type, env *Environment) RuntimeValue
+}
+
+func lookupTypeMethod(name string) (typeMethod, bool) {
+	switch name {
+	case "name":
+		return typeMethod{returnType: strType, call: typeNameMethod}, true
+	case "kind":
+		return typeMethod{returnType: strType, call: typeKind}, true
+	case "fields":
+		return typeMethod{returnType: fieldListType, call: typeFields}, true
+	case "element":
+		return typeMethod{returnType: makeOptional(typeType), call: typeElement}, true
+	case "key":
+		return typeMethod{returnType: makeOptional(typeType), call: typeKey}, true
+	case "elements":
+		return typeMethod{returnType: ast.ArrayType{Kind: ast.T_ARRAY, ElementType: typeType}, call: typeElements}, true
+	}
+	return typeMethod{}, false
+}
+
+func typeNameMethod(t ast.Type, env *Environment) RuntimeValue {
+	return MakeSTRING(typeName(t))
+}
+
+func typeKind(t ast.Type, env *Environment) RuntimeValue {
+	return MakeSTRING(kindOf(t))
+}
+
+// typeFields returns the public fields of a struct in the order they are declared, and nothing for other types
+func typeFields(t ast.Type, env *Environment) RuntimeValue {
+
+	fields := []RuntimeValue{}
+
+	structType, isStruct := t.(ast.StructType)
+
+	if !isStruct {
+		return MakeARRAY(fields, fieldListType.ElementType)
+	}
+
+	declaration, err := env.GetStructType(structType.Name)
+
+	if err != nil {
+		return MakeARRAY(fields, fieldListType.ElementType)
+	}
+
+	var properties []ast.Property
+
+	for _, property := range declaration.(StructValue).Fields {
+		if property.IsPublic {
+			properties = append(properties, property)
+		}
+	}
+
+	sort.Slice(properties, func(i, j int) bool {
+		return properties[i].StartPos.Index < properties[j].StartPos.Index
+	})
+
+	for _, property := range properties {
+		fields = append(fields, MakeTUPLE([]RuntimeValue{MakeSTRING(property.Name), MakeTYPE(property.Type)}))
+	}
+
+	return MakeARRAY(fields, fieldListType.ElementType)
+}
+
+// typeElement returns the type of the elements of an array, a set or a range, of the values of a map,
+// or the inner type of T? and T!
+func typeElement(t ast.Type, env *Environment) RuntimeValue {
+	switch t := t.(type) {
+	case ast.ArrayType:
+		return MakeTYPE(t.ElementType)
+	case ast.SetType:
+		return MakeTYPE(t.Element)
+	case ast.RangeType:
+		return MakeTYPE(t.Element)
+	case ast.OptionalType:
+		return MakeTYPE(t.Inner)
+	case ast.ResultType:
+		return MakeTYPE(t.Value)
+	case ast.MapType:
+		return MakeTYPE(t.Value)
+	}
+	return MakeNULL()
+}
+
+// typeKey returns the type of the keys of a map
+func typeKey(t ast.Type, env *Environment) RuntimeValue {
+	if mapType, isMap := t.(ast.MapType); isMap {
+		return MakeTYPE(mapType.Key)
+	}
+	return MakeNULL()
+}
+
+// typeElements returns the types of the elements of a tuple
+func typeElements(t ast.Type, env *Environment) RuntimeValue {
+	elements := []RuntimeValue{}
+	if tuple, isTuple := t.(ast.TupleType); isTuple {
+		for _, element := range tuple.Elements {
+			elements = append(elements, MakeTYPE(element))
+		}
+	}
+	return MakeARRAY(elements, typeType)
+}
+
+// callTypeMethod calls a method of a type value, like typeof(x).kind()
+func callTypeMethod(t TypeValue, expr ast.MethodCallExpr, env *Environment) RuntimeValue {
+
+	method, ok := lookupTypeMethod(expr.Method.Identifier)
+
+	if !ok {
+		runtimeError(env, expr.Method.StartPos, expr.Method.EndPos, fmt.Sprintf("type has no method '%s'", expr.Method.Identifier)).Throw()
+	}
+
+	if len(expr.Args) != 0 {
+		runtimeError(env, expr.StartPos, expr.EndPos, fmt.Sprintf("method '%s' of type expects 0 arguments but %d were provided", expr.Method.Identifier, len(expr.Args))).Throw()
+	}
+
+	return method.call(t.Of, env)
+}
+
+// analyzeTypeMethod checks a method call on a type value before the program runs
+func (a *flowAnalyzer) analyzeTypeMethod(expr ast.MethodCallExpr) {
+
+	if _, ok := lookupTypeMethod(expr.Method.Identifier); !ok {
+		parser.MakeError(a.env.parser, expr.Method.StartPos.Line, a.env.parser.FilePath, expr.Method.StartPos, expr.Method.EndPos, fmt.Sprintf("type has no method '%s'", expr.Method.Identifier)).AddHint("the methods of type are name, kind, fields, element, key and elements", parser.TEXT_HINT).Display()
+	}
+
+	if len(expr.Args) != 0 {
+		parser.MakeError(a.env.parser, expr.StartPos.Line, a.env.parser.FilePath, expr.StartPos, expr.EndPos, fmt.Sprintf("method '%s' of type expects 0 arguments but %d were provided", expr.Method.Identifier, len(expr.Args))).Display()
+	}
+}
@@ -0,0 +1,19 @@
+package typechecker
+
+import "testing"
+
+func TestTypesAreComparedOnlyWithTypes(t *testing.T) {
+	expectFailure(t, `println(typeof(1) == "i32");`)
+	expectFailure(t, `let n := 5;
+if "i32" != typeof(n) { println("no"); }
+`)
+
+	expectOutput(t, `println(typeof(1) == typeof(2), " ", typeof(1).name() == "i32", " ", typeof(1) != null);`, "true true true\n")
+}
+
+func TestBoolIsNamedLikeInTheSource(t *testing.T) {
+	expectOutput(t, `struct Flag { pub on : bool; }
+println(typeof(true), " ", typeof(false).name());
+foreach (name, field) in typeof(Flag{on: true}).fields() { println(name, " ", field.name()); }
+`, "bool bool\non bool\n")
+}
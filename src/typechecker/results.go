@@ -0,0 +1,212 @@
+package typechecker
+
+import (
+	"fmt"
+	"walrus/frontend/ast"
+	"walrus/frontend/parser"
+)
+
+// A function that can fail returns T!, a value of type T or an error:
+//
+//	fn parse(text : str) -> i32! {
+//	    if text == "" {
+//	        ret error("empty text");
+//	    }
+//	    ret 42;
+//	}
+//
+// The error must be handled before the value is used. try returns it from the enclosing function,
+// catch replaces it with a fallback value:
+//
+//	let a := try parse(text);
+//	let b := parse(text) catch 0;
+//	let c := parse(text) catch |e| fallback(e.message);
+//
+// The flow analysis rejects a T! that is ignored or used as a T. Native functions are not typed,
+// so an error they return and nobody handles stops the program when it is evaluated.
+
+// propagatedError carries the error of a try expression up to the function call that returns it
+type propagatedError struct {
+	err ErrorValue
+}
+
+// returnPropagatedError makes the result of a function the error propagated by try. Other panics are not recovered
+func returnPropagatedError(result *RuntimeValue) {
+	if r := recover(); r != nil {
+		propagated, ok := r.(propagatedError)
+		if !ok {
+			panic(r)
+		}
+		*result = propagated.err
+	}
+}
+
+// evaluateReturnValue evaluates the value of a ret statement. An error propagated with try is the returned value
+func evaluateReturnValue(expr ast.Expression, env *Environment) (result RuntimeValue) {
+	defer returnPropagatedError(&result)
+	return Evaluate(expr, env)
+}
+
+func EvaluateTryExpr(expr ast.TryExpr, env *Environment) RuntimeValue {
+
+	value := Evaluate(expr.Expression, env)
+
+	if err, isError := value.(ErrorValue); isError {
+		panic(propagatedError{err: err})
+	}
+
+	return value
+}
+
+// EvaluateCatchExpr evaluates the fallback only if the value is an error.
+// The fallback is converted to the type of the value, like the branches of a conditional expression
+func EvaluateCatchExpr(expr ast.CatchExpr, env *Environment) RuntimeValue {
+
+	value := Evaluate(expr.Expression, env)
+
+	err, isError := value.(ErrorValue)
+	if !isError {
+		return value
+	}
+
+	scope := NewEnvironment(env, env.parser)
+	if expr.ErrorName != "" {
+		scope.DeclareVariable(expr.ErrorName, err, true)
+	}
+
+	fallback := Evaluate(expr.Fallback, scope)
+
+	return convertToCommonType(fallback, expr.Fallback, resultValueType(env.getExpressionType(expr.Expression)), expr.Expression, scope)
+}
+
+// errorProperty returns a property of an error. An error only has a message
+func errorProperty(err ErrorValue, expr ast.StructPropertyExpr, env *Environment) RuntimeValue {
+	if expr.Property.Identifier != "message" {
+		runtimeError(env, expr.Property.StartPos, expr.Property.EndPos, fmt.Sprintf("error has no property '%s'", expr.Property.Identifier)).AddHint("the text of an error is ", parser.TEXT_HINT).AddHint("e.message", parser.CODE_HINT).Throw()
+	}
+	return MakeSTRING(err.Message)
+}
+
+// checkUnhandledError stops the program when a statement evaluates to an error that nobody handles
+func checkUnhandledError(stmt ast.Node, value RuntimeValue, env *Environment) {
+
+	err, isError := value.(ErrorValue)
+	if !isError {
+		return
+	}
+
+	if _, isExpr := stmt.(ast.Expression); !isExpr {
+		return
+	}
+
+	start, end := stmt.GetPos()
+	runtimeError(env, start, end, fmt.Sprintf("unhandled error: %s", err.Message)).AddHint("handle it with ", parser.TEXT_HINT).AddHint("try", parser.CODE_HINT).AddHint(" or ", parser.TEXT_HINT).AddHint("catch", parser.CODE_HINT).Throw()
+}
+
+// resultValueType returns T for T!. Other types are returned unchanged
+func resultValueType(t ast.Type) ast.Type {
+	if result, ok := t.(ast.ResultType); ok {
+		return result.Value
+	}
+	return t
+}
+
+// isVoidResult reports if the type is void!, the type of a function that returns nothing or an error
+func isVoidResult(t ast.Type) bool {
+	result, ok := t.(ast.ResultType)
+	return ok && result.Value.IType() == ast.T_VOID
+}
+
+// isFallibleType reports if a value of the type may be an error
+func isFallibleType(t ast.Type) bool {
+	switch t.(type) {
+	case ast.ResultType, ast.ErrorType:
+		return true
+	default:
+		return false
+	}
+}
+
+// analyzeTry checks that try is used in a function that can return the error, on a value that may be an error
+func (a *flowAnalyzer) analyzeTry(expr ast.TryExpr) {
+	a.analyzeExpr(expr.Expression)
+
+	if a.function == nil {
+		parser.MakeError(a.env.parser, expr.StartPos.Line, a.env.parser.FilePath, expr.StartPos, expr.EndPos, "try can only be used inside a function").AddHint("handle the error here with ", parser.TEXT_HINT).AddHint("catch", parser.CODE_HINT).Display()
+	}
+
+	if _, isResult := a.function.ReturnType.(ast.ResultType); !isResult {
+		returnType := typeName(a.function.ReturnType)
+		parser.MakeError(a.env.parser, expr.StartPos.Line, a.env.parser.FilePath, expr.StartPos, expr.EndPos, fmt.Sprintf("try returns the error from '%s', but it returns %s", a.function.Name.Identifier, returnType)).AddHint("declare the return type as ", parser.TEXT_HINT).AddHint(returnType+"!", parser.CODE_HINT).AddHint(" or handle the error with catch", parser.TEXT_HINT).Display()
+	}
+
+	a.checkFallible(expr.Expression, "try")
+}
+
+// analyzeCatch analyzes the fallback like the right side of ??, because it is evaluated only for an error
+func (a *flowAnalyzer) analyzeCatch(expr ast.CatchExpr) {
+	a.analyzeExpr(expr.Expression)
+	a.checkFallible(expr.Expression, "catch")
+	a.env.setExpressionType(expr.Expression, a.inferType(expr.Expression))
+
+	before := a.state.copy()
+
+	a.pushScope()
+	if expr.ErrorName != "" {
+		a.declareAssigned(expr.ErrorName)
+		a.resolve(expr.ErrorName).declType = ast.ErrorType{Kind: ast.T_ERROR}
+	}
+	a.analyzeExpr(expr.Fallback)
+	a.popScope()
+
+	a.state = before
+
+	if _, err := a.inferCatchType(expr); err != nil {
+		start, end := expr.Fallback.GetPos()
+		parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, err.Error()).Display()
+	}
+}
+
+func (a *flowAnalyzer) inferCatchType(expr ast.CatchExpr) (ast.Type, error) {
+	return commonBranchType(resultValueType(a.inferType(expr.Expression)), a.inferType(expr.Fallback), isNumericConstant(expr.Expression), isNumericConstant(expr.Fallback))
+}
+
+// checkFallible rejects try and catch on a value that is known never to be an error
+func (a *flowAnalyzer) checkFallible(expr ast.Expression, operator string) {
+	t := a.inferType(expr)
+
+	if t == nil || isFallibleType(t) {
+		return
+	}
+
+	start, end := expr.GetPos()
+	parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("%s needs a value that may be an error, got %s", operator, typeName(t))).AddHint("only the results of functions returning T! can fail", parser.TEXT_HINT).Display()
+}
+
+// checkIgnoredResult rejects an expression statement whose result may be an error
+func (a *flowAnalyzer) checkIgnoredResult(expr ast.Expression) {
+	if _, isAssignment := expr.(ast.AssignmentExpr); isAssignment {
+		return
+	}
+
+	t := a.inferType(expr)
+
+	if !isFallibleType(t) {
+		return
+	}
+
+	start, end := expr.GetPos()
+	parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("result of type %s may be an error and cannot be ignored", typeName(t))).AddHint("propagate the error with ", parser.TEXT_HINT).AddHint("try", parser.CODE_HINT).AddHint(" or handle it with ", parser.TEXT_HINT).AddHint("catch", parser.CODE_HINT).Display()
+}
+
+// checkHandled rejects using a T! where a T is required
+func (a *flowAnalyzer) checkHandled(expr ast.Expression) {
+	if t, isResult := a.inferType(expr).(ast.ResultType); isResult {
+		a.reportMaybeError(expr, t)
+	}
+}
+
+func (a *flowAnalyzer) reportMaybeError(expr ast.Expression, t ast.Type) {
+	start, end := expr.GetPos()
+	parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("value of type %s may be an error", typeName(t))).AddHint("handle the error first with ", parser.TEXT_HINT).AddHint("try", parser.CODE_HINT).AddHint(" or ", parser.TEXT_HINT).AddHint("catch", parser.CODE_HINT).Display()
+}
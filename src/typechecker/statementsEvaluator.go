@@ -8,11 +8,34 @@ import (
 )
 
 func EvaluateProgramBlock(block ast.ProgramStmt, env *Environment) RuntimeValue {
+
+	// the imported symbols are bound before the analysis, which uses their types
+	if env.modules == nil {
+		env.modules = newModuleLoader(env)
+	}
+	env.modules.importModules(block, env)
+
+	AnalyzeFlow(block, env)
+
+	var current ast.Node
+
+	defer func() {
+		if r := recover(); r != nil {
+			start, end := current.GetPos()
+			if err := asRuntimeError(r, env, start, end); err != nil {
+				reportUncaught(err)
+			}
+			panic(r)
+		}
+	}()
+
 	for _, stmt := range block.Contents {
+		current = stmt
 		rVal := Evaluate(stmt, env)
 		if _, ok := rVal.(ReturnValue); ok {
 			return rVal
 		}
+		checkUnhandledError(stmt, rVal, env)
 	}
 	return MakeVOID()
 }
@@ -21,53 +44,59 @@ func EvaluateVariableDeclarationStmt(stmt ast.VariableDclStml, env *Environment)
 
 	var value RuntimeValue
 
-	if stmt.Value != nil {
-		value = Evaluate(stmt.Value, env)
+	if literal, ok := stmt.Value.(ast.ArrayLiterals); ok {
+		// the elements of an array literal take the declared element type
+		var elementType ast.Type
+		if arrayType, ok := stmt.ExplicitType.(ast.ArrayType); ok {
+			elementType = arrayType.ElementType
+		}
+		value = EvaluateArrayLiteral(literal, elementType, env)
+	} else if literal, ok := stmt.Value.(ast.TupleExpr); ok {
+		tupleType, _ := stmt.ExplicitType.(ast.TupleType)
+		value = EvaluateTupleExpr(literal, tupleType.Elements, env)
+	} else if stmt.Value != nil {
+		value = evaluateAs(stmt.Value, stmt.ExplicitType, env)
 	}
 
-	if stmt.ExplicitType != nil {
+	explicitType := stmt.ExplicitType
 
-		// size checking for the integer and float types
-		var explicitSize uint8 = 0
+	if explicitType == nil && stmt.Value == nil {
+		// let x; takes the type inferred from the first assignment
+		explicitType = env.getInferredType(stmt.StartPos.Index)
+	}
 
-		switch t := stmt.ExplicitType.(type) {
-		case ast.IntegerType:
-			explicitSize = t.BitSize
+	// a variable typed with a trait holds any value, like a parameter
+	isTrait := isTraitType(explicitType, env)
 
-		case ast.FloatType:
-			explicitSize = t.BitSize
-		}
+	if explicitType != nil {
 
-		switch v := value.(type) {
-		case IntegerValue:
-			//modify the Size field of the value. Update the original value
-			v.Size = explicitSize // is it reference or copy? It is a copy. So, the original value is not updated
-			t := v.Type.(ast.IntegerType)
-			t.BitSize = explicitSize
-			v.Type = t
-			//update the Original value
-			value = v
-		case FloatValue:
-			v.Size = explicitSize
-			t := v.Type.(ast.FloatType)
-			t.BitSize = explicitSize
-			v.Type = t
-			value = v
+		// numeric constants are converted to the declared type if they fit in it, other numbers only if no data is lost
+		if value != nil && !isTrait {
+			converted, err := convertImplicitly(value, explicitType, isNumericConstant(stmt.Value))
+			if err != nil {
+				start, end := stmt.Value.GetPos()
+				runtimeError(env, start, end, err.Error()).Throw()
+			}
+			value = converted
 		}
 
 		if value == nil {
-			value = MakeDefaultRuntimeValue(stmt.ExplicitType)
+			value = MakeDefaultRuntimeValue(explicitType)
+		} else if !isTrait {
+			//check user defined types with the value type
+			start, end := stmt.Identifier.GetPos()
+			checkTypes(env, explicitType, value, start, end)
 		}
-
-		//check user defined types with the value type
-		start, end := stmt.Identifier.GetPos()
-		checkTypes(env, stmt.ExplicitType, value, start, end)
 	}
 
 	val, err := env.DeclareVariable(stmt.Identifier.Identifier, value, stmt.IsConstant)
 
 	if err != nil {
-		parser.MakeError(env.parser, stmt.StartPos.Line, env.parser.FilePath, stmt.Identifier.StartPos, stmt.Identifier.EndPos, err.Error()).Display()
+		runtimeError(env, stmt.Identifier.StartPos, stmt.Identifier.EndPos, err.Error()).Throw()
+	}
+
+	if explicitType != nil && !isTrait {
+		env.setDeclaredType(stmt.Identifier.Identifier, explicitType)
 	}
 
 	return val
@@ -76,12 +105,24 @@ func EvaluateVariableDeclarationStmt(stmt ast.VariableDclStml, env *Environment)
 func checkTypes(env *Environment, explicitType ast.Type, value RuntimeValue, startPos lexer.Position, endPos lexer.Position) {
 
 	switch t := explicitType.(type) {
+	case ast.OptionalType:
+		if _, isNull := value.(NullValue); !isNull {
+			checkTypes(env, t.Inner, value, startPos, endPos)
+		}
+	case ast.ResultType:
+		if _, isError := value.(ErrorValue); !isError {
+			checkTypes(env, t.Value, value, startPos, endPos)
+		}
 	case ast.IntegerType:
 		checkIntegerType(env, t, value, startPos, endPos)
 	case ast.FloatType:
 		checkFloatType(env, t, value, startPos, endPos)
 	case ast.StructType:
 		checkStructType(env, t, value, startPos, endPos)
+	case ast.ArrayType, ast.TupleType, ast.MapType, ast.SetType, ast.RangeType:
+		if valueTypeName(value) != typeName(t) {
+			runtimeError(env, startPos, endPos, fmt.Sprintf("cannot assign value of type '%s' to '%s'", valueTypeName(value), typeName(t))).Throw()
+		}
 	default:
 		checkGeneralType(env, t, value, startPos, endPos)
 	}
@@ -89,21 +130,21 @@ func checkTypes(env *Environment, explicitType ast.Type, value RuntimeValue, sta
 
 func checkIntegerType(env *Environment, explicitType ast.IntegerType, value RuntimeValue, startPos lexer.Position, endPos lexer.Position) {
 	if IsINT(value) {
-		if explicitType.BitSize != value.(IntegerValue).Size {
-			displayTypeMismatchError(env.parser, explicitType, value, startPos, endPos, fmt.Sprintf("integer of size %d", explicitType.BitSize))
+		if explicitType.IType() != value.(IntegerValue).Type.IType() {
+			displayTypeMismatchError(env, explicitType, value, startPos, endPos, fmt.Sprintf("integer of size %d", explicitType.BitSize))
 		}
 	} else {
-		displayTypeMismatchError(env.parser, explicitType, value, startPos, endPos, "")
+		displayTypeMismatchError(env, explicitType, value, startPos, endPos, "")
 	}
 }
 
 func checkFloatType(env *Environment, explicitType ast.FloatType, value RuntimeValue, startPos lexer.Position, endPos lexer.Position) {
 	if IsFLOAT(value) {
 		if explicitType.BitSize != value.(FloatValue).Size {
-			displayTypeMismatchError(env.parser, explicitType, value, startPos, endPos, fmt.Sprintf("float of size %d", explicitType.BitSize))
+			displayTypeMismatchError(env, explicitType, value, startPos, endPos, fmt.Sprintf("float of size %d", explicitType.BitSize))
 		}
 	} else {
-		displayTypeMismatchError(env.parser, explicitType, value, startPos, endPos, "")
+		displayTypeMismatchError(env, explicitType, value, startPos, endPos, "")
 	}
 }
 
@@ -112,26 +153,26 @@ func checkStructType(env *Environment, explicitType ast.StructType, value Runtim
 	got := string(GetRuntimeType(value))
 
 	if !HasStruct(expected, env) {
-		parser.MakeError(env.parser, startPos.Line, env.parser.FilePath, startPos, endPos, fmt.Sprintf("failed to validate types. struct '%s' is not defined", expected)).Display()
+		runtimeError(env, startPos, endPos, fmt.Sprintf("failed to validate types. struct '%s' is not defined", expected)).Throw()
 	} else if !HasStruct(got, env) {
-		parser.MakeError(env.parser, startPos.Line, env.parser.FilePath, startPos, endPos, fmt.Sprintf("failed to validate types. struct '%s' is not defined", got)).Display()
+		runtimeError(env, startPos, endPos, fmt.Sprintf("failed to validate types. struct '%s' is not defined", got)).Throw()
 	} else if expected != got {
-		displayTypeMismatchError(env.parser, explicitType, value, startPos, endPos, "")
+		displayTypeMismatchError(env, explicitType, value, startPos, endPos, "")
 	}
 }
 
 func checkGeneralType(env *Environment, explicitType ast.Type, value RuntimeValue, startPos lexer.Position, endPos lexer.Position) {
 	if GetRuntimeType(value) != explicitType.IType() {
-		displayTypeMismatchError(env.parser, explicitType, value, startPos, endPos, "")
+		displayTypeMismatchError(env, explicitType, value, startPos, endPos, "")
 	}
 }
 
-func displayTypeMismatchError(p *parser.Parser, explicitType ast.Type, value RuntimeValue, startPos lexer.Position, endPos lexer.Position, additionalInfo string) {
+func displayTypeMismatchError(env *Environment, explicitType ast.Type, value RuntimeValue, startPos lexer.Position, endPos lexer.Position, additionalInfo string) {
 	msg := strFormatter(explicitType, value)
 	if additionalInfo != "" {
 		msg += fmt.Sprintf(" to %s", additionalInfo)
 	}
-	parser.MakeError(p, startPos.Line, p.FilePath, startPos, endPos, msg).Display()
+	runtimeError(env, startPos, endPos, msg).Throw()
 }
 
 func strFormatter(expected ast.Type, got RuntimeValue) string {
@@ -153,23 +194,27 @@ func strFormatter(expected ast.Type, got RuntimeValue) string {
 
 
 func EvaluateBlockStmt(block ast.BlockStmt, env *Environment) RuntimeValue {
+
+	// variables declared inside the block are not visible outside of it
+	scope := NewEnvironment(env, env.parser)
+
 	for _, stmt := range block.Items {
 		switch stmt := stmt.(type) {
 		case ast.ReturnStmt:
 			// Evaluate the return expression and return its value immediately
-			return Evaluate(stmt, env)
+			return Evaluate(stmt, scope)
 		default:
-			rVal := Evaluate(stmt, env)
-			//check if the runtime value is a return value
-			if _, ok := rVal.(ReturnValue); ok {
+			rVal := Evaluate(stmt, scope)
+			//check if the runtime value is a return value, or leaves a loop
+			if isControlFlow(rVal) {
 				return rVal
 			}
+			checkUnhandledError(stmt, rVal, scope)
 		}
 	}
 
-	return ReturnValue{
-		Value: MakeVOID(),
-	}
+	// reaching the end of a block does not return from the enclosing function
+	return MakeVOID()
 }
 
 func EvaluateControlFlowStmt(astNode ast.IfStmt, env *Environment) RuntimeValue {
@@ -200,8 +245,9 @@ func EvaluateFunctionDeclarationStmt(stmt ast.FunctionDeclStmt, env *Environment
 	}
 
 	funcEnv := createFunctionEnvironment(stmt, env)
-	processFunctionBody(stmt.Block, funcEnv)
-	checkFunctionReturnType(stmt, funcEnv)
+	if processFunctionBody(stmt.Block, funcEnv) {
+		checkFunctionReturnType(stmt, funcEnv)
+	}
 
 	return MakeVOID()
 }
@@ -211,7 +257,7 @@ func declareFunction(stmt ast.FunctionDeclStmt, env *Environment) error {
 }
 
 func handleFunctionDeclarationError(stmt ast.FunctionDeclStmt, env *Environment, err error) {
-	parser.MakeError(env.parser, stmt.StartPos.Line, env.parser.FilePath, stmt.Name.StartPos, stmt.Name.EndPos, err.Error()).Display()
+	runtimeError(env, stmt.Name.StartPos, stmt.Name.EndPos, err.Error()).Throw()
 }
 
 func createFunctionEnvironment(stmt ast.FunctionDeclStmt, env *Environment) *Environment {
@@ -224,36 +270,63 @@ func createFunctionEnvironment(stmt ast.FunctionDeclStmt, env *Environment) *Env
 	return funcEnv
 }
 
-func processFunctionBody(body ast.BlockStmt, funcEnv *Environment) {
+// processFunctionBody evaluates the declarations of a function body with the default values of the parameters.
+// It reports false if one of them fails or propagates an error with try, because the return statement is not reached
+func processFunctionBody(body ast.BlockStmt, funcEnv *Environment) bool {
 	var returnStmt *ast.ReturnStmt
 
-	for _, stmt := range body.Items {
-		switch stmt := stmt.(type) {
-		case ast.VariableDclStml:
-			val := Evaluate(stmt.Value, funcEnv)
-			funcEnv.DeclareVariable(stmt.Identifier.Identifier, val, stmt.IsConstant)
-		case ast.ReturnStmt:
-			returnStmt = &stmt
+	completed := evaluatesWithDefaults(func() {
+		for _, stmt := range body.Items {
+			switch stmt := stmt.(type) {
+			case ast.VariableDclStml:
+				EvaluateVariableDeclarationStmt(stmt, funcEnv)
+			case ast.DestructuringDclStmt:
+				EvaluateDestructuringDclStmt(stmt, funcEnv)
+			case ast.ReturnStmt:
+				returnStmt = &stmt
+			}
 		}
-	}
+	})
 
 	if returnStmt != nil && returnStmt.Kind == ast.NODE_TYPE(ast.T_VOID) {
-		parser.MakeError(funcEnv.parser, returnStmt.StartPos.Line, funcEnv.parser.FilePath, returnStmt.StartPos, returnStmt.EndPos, "void function must not have a return statement with a value").Display()
+		runtimeError(funcEnv, returnStmt.StartPos, returnStmt.EndPos, "void function must not have a return statement with a value").Throw()
 	}
+
+	return completed
+}
+
+// evaluatesWithDefaults runs a part of a function body evaluated with the default values of the parameters.
+// A runtime error there, like a division by a parameter that is 0, says nothing about the real calls, so it only reports false
+func evaluatesWithDefaults(evaluate func()) (completed bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch r.(type) {
+			case propagatedError, *RuntimeError:
+				completed = false
+			default:
+				panic(r)
+			}
+		}
+	}()
+
+	evaluate()
+
+	return true
 }
 
 func checkFunctionReturnType(stmt ast.FunctionDeclStmt, funcEnv *Environment) {
-	if stmt.ReturnType.IType() != ast.T_VOID {
+	if stmt.ReturnType.IType() != ast.T_VOID && !isVoidResult(stmt.ReturnType) {
 		lastStmt := stmt.Block.Items[len(stmt.Block.Items)-1]
 		if returnStmt, ok := lastStmt.(ast.ReturnStmt); ok {
-			returnVal := Evaluate(returnStmt.Expression, funcEnv)
-			if GetRuntimeType(returnVal) != stmt.ReturnType.IType() {
-				expectedType := fmt.Sprintf("%s", stmt.ReturnType)
-				returnType := GetRuntimeType(returnVal)
-				parser.MakeError(funcEnv.parser, returnStmt.StartPos.Line, funcEnv.parser.FilePath, returnStmt.StartPos, returnStmt.EndPos, fmt.Sprintf("cannot return value of type '%s' from function with return type '%s'", returnType, expectedType)).Display()
+			var returnVal RuntimeValue
+			if !evaluatesWithDefaults(func() { returnVal = evaluateReturnValue(returnStmt.Expression, funcEnv) }) {
+				return
+			}
+			if _, err := convertReturnValue(returnVal, returnStmt.Expression, stmt.ReturnType, funcEnv); err != nil {
+				runtimeError(funcEnv, returnStmt.StartPos, returnStmt.EndPos, err.Error()).Throw()
 			}
 		} else {
-			parser.MakeError(funcEnv.parser, stmt.StartPos.Line, funcEnv.parser.FilePath, stmt.Name.StartPos, stmt.Name.EndPos, "function must have a return value at the end").Display()
+			runtimeError(funcEnv, stmt.Name.StartPos, stmt.Name.EndPos, "function must have a return value at the end").Throw()
 		}
 	}
 }
@@ -263,48 +336,195 @@ func EvaluateFunctionCallExpr(expr ast.FunctionCallExpr, env *Environment) Runti
 
 	var args []RuntimeValue
 
-	for _, arg := range expr.Args {
-		args = append(args, Evaluate(arg, env))
+	parameters := calleeParameters(expr, env)
+
+	for i, arg := range expr.Args {
+		var paramType ast.Type
+		if i < len(parameters) {
+			paramType = parameters[i].Type
+		}
+		args = append(args, evaluateAs(arg, paramType, env))
+	}
+
+	if builtin, ok := lookupBuiltin(expr.Caller.Identifier); ok && !env.HasVariable(expr.Caller.Identifier) {
+		return builtin.call(expr, args, env)
 	}
 
 	fn := Evaluate(expr.Caller, env)
 
+	return callFunction(fn, args, expr, env)
+}
+
+// calleeParameters returns the parameters of the called function, or nil if it is a builtin or not a function
+func calleeParameters(expr ast.FunctionCallExpr, env *Environment) []ast.FunctionParameter {
+
+	if _, ok := lookupBuiltin(expr.Caller.Identifier); ok && !env.HasVariable(expr.Caller.Identifier) {
+		return nil
+	}
+
+	value, err := env.GetRuntimeValue(expr.Caller.Identifier)
+	if err != nil {
+		return nil
+	}
+
+	switch fn := value.(type) {
+	case FunctionValue:
+		return fn.Parameters
+	case NativeFunctionValue:
+		if fn.Signature != nil {
+			return fn.Signature.Parameters
+		}
+	}
+
+	return nil
+}
+
+// callFunction calls a function with arguments that are already evaluated.
+// expr is the call in the source, used for the errors and the stack trace
+func callFunction(fn RuntimeValue, args []RuntimeValue, expr ast.FunctionCallExpr, env *Environment) RuntimeValue {
+
+	env.calls.push(callFrame{function: expr.Caller.Identifier, parser: env.parser, start: expr.Caller.StartPos, end: expr.EndPos})
+
+	defer func() {
+		r := recover()
+		if r != nil {
+			// the stack is copied before the call is removed from it
+			if err := asRuntimeError(r, env, expr.StartPos, expr.EndPos); err != nil {
+				r = err
+			}
+		}
+		env.calls.pop()
+		if r != nil {
+			panic(r)
+		}
+	}()
+
 	if !IsFunction(fn) {
-		parser.MakeError(env.parser, expr.StartPos.Line, env.parser.FilePath, expr.StartPos, expr.EndPos, fmt.Sprintf("could not call. %s not a function", expr.Caller.Identifier)).Display()
+		runtimeError(env, expr.StartPos, expr.EndPos, fmt.Sprintf("could not call. %s not a function", expr.Caller.Identifier)).Throw()
 	}
 
 	if GetRuntimeType(fn) == ast.T_NATIVE_FN {
-		return fn.(NativeFunctionValue).Caller(args...)
+		return callNative(fn.(NativeFunctionValue), expr, args, env)
 	}
 
 	function := fn.(FunctionValue)
-	scope := NewEnvironment(function.DeclarationEnv, env.parser)
+	// the body reports its errors in the file that declares the function, which may be another module
+	scope := NewEnvironment(function.DeclarationEnv, function.DeclarationEnv.parser)
+	scope.deferred = &deferredCalls{}
+	scope.returnType = function.ReturnType
 
 	params := function.Parameters
 
 	// check if the number of arguments match the number of parameters
 	if len(args) != len(params) {
-		parser.MakeError(env.parser, expr.StartPos.Line, env.parser.FilePath, expr.StartPos, expr.EndPos, fmt.Sprintf("function '%s' expects %d arguments but %d were provided", function.Name, len(params), len(args))).Display()
+		runtimeError(env, expr.StartPos, expr.EndPos, fmt.Sprintf("function '%s' expects %d arguments but %d were provided", function.Name, len(params), len(args))).Throw()
 	}
 
 	// check and set the arguments to the function parameters
 	for i := 0; i < len(params); i++ {
-		scope.DeclareVariable(params[i].Identifier.Identifier, args[i], false)
+		arg, err := bindArgument(params[i], args[i], expr.Args[i], scope)
+		if err != nil {
+			start, end := expr.Args[i].GetPos()
+			runtimeError(env, start, end, err.Error()).Throw()
+		}
+		scope.DeclareVariable(params[i].Identifier.Identifier, arg, false)
+		if !isTraitType(params[i].Type, scope) {
+			scope.setDeclaredType(params[i].Identifier.Identifier, params[i].Type)
+		}
 	}
 
-	for _, stmt := range function.Body.Items {
+	return evaluateFunctionBody(function.Body, scope)
+}
+
+// evaluateFunctionBody runs the statements of a function and returns its result.
+// An error propagated with try inside the body is the result of the function
+func evaluateFunctionBody(body ast.BlockStmt, scope *Environment) (result RuntimeValue) {
+
+	// the deferred calls run last, also while a runtime error leaves the function
+	defer runDeferredCalls(scope)
+	defer returnPropagatedError(&result)
+
+	for _, stmt := range body.Items {
 		rVal := Evaluate(stmt, scope)
 		if _, ok := rVal.(ReturnValue); ok {
 			return rVal.(ReturnValue).Value
 		}
+		checkUnhandledError(stmt, rVal, scope)
 	}
 
 	return MakeVOID()
 }
 
+// bindArgument converts an argument to the type of its parameter. null is only accepted by optional parameters.
+// Parameters typed with a trait accept any value.
+func bindArgument(param ast.FunctionParameter, arg RuntimeValue, argExpr ast.Expression, env *Environment) (RuntimeValue, error) {
+
+	if isTraitType(param.Type, env) {
+		return arg, nil
+	}
+
+	converted, err := convertImplicitly(arg, param.Type, isNumericConstant(argExpr))
+	if err != nil {
+		return nil, err
+	}
+
+	if !matchesType(converted, param.Type) {
+		return nil, fmt.Errorf("parameter '%s' is of type %s, but got %s", param.Identifier.Identifier, typeName(param.Type), valueTypeName(converted))
+	}
+
+	return converted, nil
+}
+
+// isTraitType reports if a type names a trait. The implementations of a trait are not checked yet,
+// so a value of a trait type can be anything
+func isTraitType(t ast.Type, env *Environment) bool {
+	structType, ok := t.(ast.StructType)
+	return ok && HasTrait(structType.Name, env)
+}
+
+// convertReturnValue converts the value of a ret to the return type of the function, like an argument to the
+// type of its parameter. A function returning T! may return an error, and one returning T? may return null
+func convertReturnValue(value RuntimeValue, expr ast.Expression, returnType ast.Type, env *Environment) (RuntimeValue, error) {
+
+	if returnType == nil || returnType.IType() == ast.T_VOID || isTraitType(resultValueType(returnType), env) {
+		return value, nil
+	}
+
+	if _, isError := value.(ErrorValue); isError && isFallibleType(returnType) {
+		return value, nil
+	}
+
+	converted, err := convertImplicitly(value, returnType, isNumericConstant(expr))
+	if err == nil && !matchesType(converted, returnType) {
+		err = fmt.Errorf("cannot return value of type '%s' from function with return type '%s'", valueTypeName(value), typeName(returnType))
+	}
+
+	return converted, err
+}
+
 func EvaluateReturnStmt(stmt ast.ReturnStmt, env *Environment) RuntimeValue {
 	expr := stmt.Expression
-	val := Evaluate(expr, env)
+
+	var val RuntimeValue
+
+	// the elements of ret a, b; take the types of the declared tuple
+	if tuple, ok := expr.(ast.TupleExpr); ok && env.functionScope() != nil {
+		returnType, _ := resultValueType(env.functionScope().returnType).(ast.TupleType)
+		val = EvaluateTupleExpr(tuple, returnType.Elements, env)
+	} else if scope := env.functionScope(); scope != nil {
+		val = evaluateAs(expr, scope.returnType, env)
+	} else {
+		val = Evaluate(expr, env)
+	}
+
+	// every ret is checked, not only the last one of the body that is checked at the declaration
+	if scope := env.functionScope(); scope != nil {
+		converted, err := convertReturnValue(val, expr, scope.returnType, env)
+		if err != nil {
+			runtimeError(env, stmt.StartPos, stmt.EndPos, err.Error()).Throw()
+		}
+		val = converted
+	}
 
 	return ReturnValue{
 		Value: val,
@@ -325,17 +545,38 @@ func EvaluateStructDeclarationStmt(stmt ast.StructDeclStatement, env *Environmen
 	return MakeVOID()
 }
 
+func EvaluateTraitDeclarationStmt(stmt ast.TraitDeclStatement, env *Environment) RuntimeValue {
+
+	env.traits[stmt.TraitName] = stmt
+
+	return MakeVOID()
+}
+
 func EvaluateStructLiteral(stmt ast.StructLiteral, env *Environment) RuntimeValue {
 
 	//check if the struct is defined
 	if !HasStruct(stmt.StructName, env) {
-		parser.MakeError(env.parser, stmt.StartPos.Line, env.parser.FilePath, stmt.StartPos, stmt.EndPos, fmt.Sprintf("cannot evaluate struct literal. struct '%s' is not defined", stmt.StructName)).Display()
+		runtimeError(env, stmt.StartPos, stmt.EndPos, fmt.Sprintf("cannot evaluate struct literal. struct '%s' is not defined", stmt.StructName)).Throw()
 	}
 
 	properties := make(map[string]RuntimeValue)
 
+	structValue, _ := env.GetStructType(stmt.StructName)
+	fields := structValue.(StructValue).Fields
+
 	for name, value := range stmt.Properties {
-		properties[name] = Evaluate(value, env)
+		field, ok := fields[name]
+		properties[name] = evaluateAs(value, field.Type, env)
+
+		// numbers are converted to the type of the field like in an assignment
+		if ok {
+			converted, err := convertImplicitly(properties[name], field.Type, isNumericConstant(value))
+			if err != nil {
+				start, end := value.GetPos()
+				runtimeError(env, start, end, err.Error()).Throw()
+			}
+			properties[name] = converted
+		}
 	}
 
 	return StructInstance{
@@ -346,25 +587,45 @@ func EvaluateStructLiteral(stmt ast.StructLiteral, env *Environment) RuntimeValu
 
 func EvaluateStructPropertyExpr(expr ast.StructPropertyExpr, env *Environment) RuntimeValue {
 
-	obj := Evaluate(expr.Object, env).(StructInstance)
+	object := Evaluate(expr.Object, env)
 
 	propname := expr.Property.Identifier
 
+	if err, isError := object.(ErrorValue); isError {
+		return errorProperty(err, expr, env)
+	}
+
+	if _, isNull := object.(NullValue); isNull {
+		// a?.b is null when a is null
+		if expr.Optional {
+			return object
+		}
+		start, end := expr.Object.GetPos()
+		runtimeError(env, start, end, fmt.Sprintf("cannot access property '%s' of null", propname)).AddHint("check it with if obj != null or use ", parser.TEXT_HINT).AddHint("obj?."+propname, parser.CODE_HINT).Throw()
+	}
+
+	obj, ok := object.(StructInstance)
+
+	if !ok {
+		start, end := expr.Object.GetPos()
+		runtimeError(env, start, end, fmt.Sprintf("cannot access property '%s' of a value of type %s", propname, valueTypeName(object))).Throw()
+	}
+
 	if obj.Fields[propname] == nil {
-		parser.MakeError(env.parser, expr.StartPos.Line, env.parser.FilePath, expr.Property.StartPos, expr.Property.EndPos, fmt.Sprintf("property '%s' is not defined in struct '%s'", propname, obj.StructName)).Display()
+		runtimeError(env, expr.Property.StartPos, expr.Property.EndPos, fmt.Sprintf("property '%s' is not defined in struct '%s'", propname, obj.StructName)).Throw()
 	}
 
 	structValue, err := env.GetStructType(obj.StructName)
 
 	if err != nil {
-		parser.MakeError(env.parser, expr.StartPos.Line, env.parser.FilePath, expr.StartPos, expr.EndPos, err.Error()).Display()
+		runtimeError(env, expr.StartPos, expr.EndPos, err.Error()).Throw()
 	}
 
 	// check if the property is public
 	if structValue.(StructValue).Fields[propname].IsPublic {
 		return obj.Fields[propname]
 	} else {
-		parser.MakeError(env.parser, expr.StartPos.Line, env.parser.FilePath, expr.Property.StartPos, expr.Property.EndPos, fmt.Sprintf("property '%s' is private in struct '%s'", propname, obj.StructName)).Display()
+		runtimeError(env, expr.Property.StartPos, expr.Property.EndPos, fmt.Sprintf("property '%s' is private in struct '%s'", propname, obj.StructName)).Throw()
 		return nil
 	}
 }
@@ -0,0 +1,229 @@
+package typechecker
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"walrus/frontend/ast"
+	"walrus/frontend/parser"
+)
+
+// A str is UTF-8 text and a chr is a Unicode code point:
+//
+//	let name := "café";
+//	len(name);          // 5, the number of bytes
+//	char_count(name);   // 4, the number of characters
+//	foreach c in name { ... }   // 'c', 'a', 'f', 'é'
+//
+// Slices of a string use byte positions like len, so name[0..3] is "caf". A slice that would cut
+// a character in two is a runtime error.
+//
+// The methods of str are called with method syntax, name.to_upper(), and are checked by the
+// flow analysis like function calls. index_of gives a byte position like len, pad_left counts
+// characters. parse_int and parse_float return an error the program can handle with try or catch.
+
+// sliceString returns the bytes of the string between the bounds of a range with step 1.
+// The bounds are already checked against the length of the string
+func sliceString(text StringValue, r RangeValue, expr ast.ArrayIndexAccess, env *Environment) RuntimeValue {
+
+	start, end := expr.Index.GetPos()
+
+	if r.Step.bigValue().Cmp(big.NewInt(1)) != 0 {
+		runtimeError(env, start, end, fmt.Sprintf("range %s cannot slice a string, a string slice needs step 1", r)).AddHint("a character can take several bytes", parser.TEXT_HINT).Throw()
+	}
+
+	first := int(r.Start.bigValue().Int64())
+	last := first + int(r.length().Int64())
+
+	for _, position := range []int{first, last} {
+		if position < len(text.Value) && !utf8.RuneStart(text.Value[position]) {
+			runtimeError(env, start, end, fmt.Sprintf("range %s cuts a character of the string at byte %d", r, position)).AddHint("len counts bytes, use char_count for the number of characters", parser.TEXT_HINT).Throw()
+		}
+	}
+
+	return MakeSTRING(text.Value[first:last])
+}
+
+// builtinCharCount returns the number of code points of a string, len returns its number of bytes
+func builtinCharCount(expr ast.FunctionCallExpr, args []RuntimeValue, env *Environment) RuntimeValue {
+
+	if len(args) != 1 {
+		runtimeError(env, expr.StartPos, expr.EndPos, fmt.Sprintf("function 'char_count' expects 1 argument but %d were provided", len(args))).Throw()
+	}
+
+	text, ok := args[0].(StringValue)
+
+	if !ok {
+		start, end := expr.Args[0].GetPos()
+		runtimeError(env, start, end, fmt.Sprintf("char_count needs a str, got %s", valueTypeName(args[0]))).Throw()
+	}
+
+	return MakeINT(int64(utf8.RuneCountInString(text.Value)), 64, true)
+}
+
+var (
+	strType  = ast.StringType{Kind: ast.T_STRING}
+	boolType = ast.BoolType{Kind: ast.T_BOOLEAN}
+	chrType  = ast.CharType{Kind: ast.T_CHARACTER}
+	i64Type  = ast.IntegerType{Kind: ast.T_INTEGER64, BitSize: 64, IsSigned: true}
+	f64Type  = ast.FloatType{Kind: ast.T_FLOAT64, BitSize: 64}
+)
+
+// stringMethod is a method of str. params are the types of the arguments after the receiver
+type stringMethod struct {
+	params []ast.Type
+	// the arguments after required can be left out
+	required   int
+	returnType ast.Type
+	// call returns an error for arguments that the method cannot use
+	call func(text string, args []RuntimeValue) (RuntimeValue, error)
+}
+
+func lookupStringMethod(name string) (stringMethod, bool) {
+	switch name {
+	case "split":
+		return stringMethod{params: []ast.Type{strType}, required: 1, returnType: ast.ArrayType{Kind: ast.T_ARRAY, ElementType: strType}, call: stringSplit}, true
+	case "trim":
+		return stringMethod{returnType: strType, call: stringTrim}, true
+	case "starts_with":
+		return stringMethod{params: []ast.Type{strType}, required: 1, returnType: boolType, call: stringStartsWith}, true
+	case "ends_with":
+		return stringMethod{params: []ast.Type{strType}, required: 1, returnType: boolType, call: stringEndsWith}, true
+	case "contains":
+		return stringMethod{params: []ast.Type{strType}, required: 1, returnType: boolType, call: stringContains}, true
+	case "replace":
+		return stringMethod{params: []ast.Type{strType, strType}, required: 2, returnType: strType, call: stringReplace}, true
+	case "to_upper":
+		return stringMethod{returnType: strType, call: stringToUpper}, true
+	case "to_lower":
+		return stringMethod{returnType: strType, call: stringToLower}, true
+	case "index_of":
+		return stringMethod{params: []ast.Type{strType}, required: 1, returnType: makeOptional(i64Type), call: stringIndexOf}, true
+	case "repeat":
+		return stringMethod{params: []ast.Type{i64Type}, required: 1, returnType: strType, call: stringRepeat}, true
+	case "pad_left":
+		return stringMethod{params: []ast.Type{i64Type, chrType}, required: 1, returnType: strType, call: stringPadLeft}, true
+	case "lines":
+		return stringMethod{returnType: ast.ArrayType{Kind: ast.T_ARRAY, ElementType: strType}, call: stringLines}, true
+	case "parse_int":
+		return stringMethod{returnType: ast.ResultType{Kind: ast.T_RESULT, Value: i64Type}, call: stringParseInt}, true
+	case "parse_float":
+		return stringMethod{returnType: ast.ResultType{Kind: ast.T_RESULT, Value: f64Type}, call: stringParseFloat}, true
+	}
+	return stringMethod{}, false
+}
+
+// makeStringArray makes a []str of the parts
+func makeStringArray(parts []string) ArrayValue {
+	elements := make([]RuntimeValue, len(parts))
+	for i, part := range parts {
+		elements[i] = MakeSTRING(part)
+	}
+	return MakeARRAY(elements, strType)
+}
+
+// stringSplit splits around each separator. An empty separator splits the characters, and an empty text is one
+// empty part like the parts between two separators
+func stringSplit(text string, args []RuntimeValue) (RuntimeValue, error) {
+	return makeStringArray(strings.Split(text, args[0].(StringValue).Value)), nil
+}
+
+// stringTrim removes the white space at both ends
+func stringTrim(text string, args []RuntimeValue) (RuntimeValue, error) {
+	return MakeSTRING(strings.TrimSpace(text)), nil
+}
+
+func stringStartsWith(text string, args []RuntimeValue) (RuntimeValue, error) {
+	return MakeBOOL(strings.HasPrefix(text, args[0].(StringValue).Value)), nil
+}
+
+func stringEndsWith(text string, args []RuntimeValue) (RuntimeValue, error) {
+	return MakeBOOL(strings.HasSuffix(text, args[0].(StringValue).Value)), nil
+}
+
+func stringContains(text string, args []RuntimeValue) (RuntimeValue, error) {
+	return MakeBOOL(strings.Contains(text, args[0].(StringValue).Value)), nil
+}
+
+// stringReplace replaces every occurrence
+func stringReplace(text string, args []RuntimeValue) (RuntimeValue, error) {
+	return MakeSTRING(strings.ReplaceAll(text, args[0].(StringValue).Value, args[1].(StringValue).Value)), nil
+}
+
+func stringToUpper(text string, args []RuntimeValue) (RuntimeValue, error) {
+	return MakeSTRING(strings.ToUpper(text)), nil
+}
+
+func stringToLower(text string, args []RuntimeValue) (RuntimeValue, error) {
+	return MakeSTRING(strings.ToLower(text)), nil
+}
+
+// stringIndexOf returns the byte position of the first occurrence, or null
+func stringIndexOf(text string, args []RuntimeValue) (RuntimeValue, error) {
+	index := strings.Index(text, args[0].(StringValue).Value)
+	if index < 0 {
+		return MakeNULL(), nil
+	}
+	return MakeINT(int64(index), 64, true), nil
+}
+
+func stringRepeat(text string, args []RuntimeValue) (RuntimeValue, error) {
+	count := args[0].(IntegerValue).Value
+	if count < 0 {
+		return nil, fmt.Errorf("repeat count cannot be negative, got %d", count)
+	}
+	if count > 0 && len(text) > 0 && int64(len(text))*count/count != int64(len(text)) {
+		return nil, fmt.Errorf("repeat count %d is too large", count)
+	}
+	return MakeSTRING(strings.Repeat(text, int(count))), nil
+}
+
+// stringPadLeft adds the fill character, a space by default, in front of the text until it has width characters
+func stringPadLeft(text string, args []RuntimeValue) (RuntimeValue, error) {
+	width := args[0].(IntegerValue).Value
+	fill := ' '
+	if len(args) > 1 {
+		fill = args[1].(CharacterValue).Value
+	}
+	missing := width - int64(utf8.RuneCountInString(text))
+	if missing <= 0 {
+		return MakeSTRING(text), nil
+	}
+	return MakeSTRING(strings.Repeat(string(fill), int(missing)) + text), nil
+}
+
+// stringLines splits the text at \n and \r\n. A final line break does not start another line
+func stringLines(text string, args []RuntimeValue) (RuntimeValue, error) {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return makeStringArray(nil), nil
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return makeStringArray(lines), nil
+}
+
+// stringParseInt parses a decimal i64 with an optional sign
+func stringParseInt(text string, args []RuntimeValue) (RuntimeValue, error) {
+	value, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return MakeERROR(fmt.Sprintf("\"%s\" does not fit in i64", text)), nil
+		}
+		return MakeERROR(fmt.Sprintf("cannot parse \"%s\" as i64", text)), nil
+	}
+	return MakeINT(value, 64, true), nil
+}
+
+func stringParseFloat(text string, args []RuntimeValue) (RuntimeValue, error) {
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return MakeERROR(fmt.Sprintf("cannot parse \"%s\" as f64", text)), nil
+	}
+	return MakeFLOAT(value, 64), nil
+}
@@ -0,0 +1,157 @@
+package typechecker
+
+import (
+	"strings"
+	"testing"
+)
+
+func callStringMethod(t *testing.T, name string, text string, args ...RuntimeValue) RuntimeValue {
+	t.Helper()
+	method, ok := lookupStringMethod(name)
+	if !ok {
+		t.Fatalf("str has no method %s", name)
+	}
+	result, err := method.call(text, args)
+	if err != nil {
+		t.Fatalf("%s failed: %v", name, err)
+	}
+	return result
+}
+
+func stringsOf(t *testing.T, value RuntimeValue) []string {
+	t.Helper()
+	var parts []string
+	for _, element := range value.(ArrayValue).Elements {
+		parts = append(parts, element.(StringValue).Value)
+	}
+	return parts
+}
+
+func TestStringSplit(t *testing.T) {
+	cases := []struct {
+		text      string
+		separator string
+		expected  []string
+	}{
+		// an empty string is one empty part, like "a,,b" has an empty part between the commas
+		{"", ",", []string{""}},
+		{"a,,b", ",", []string{"a", "", "b"}},
+		{"a,b,", ",", []string{"a", "b", ""}},
+		{"no separator", ",", []string{"no separator"}},
+		// an empty separator splits the characters, not the bytes
+		{"héé", "", []string{"h", "é", "é"}},
+		{"", "", nil},
+		{"日本::語", "::", []string{"日本", "語"}},
+	}
+
+	for _, c := range cases {
+		parts := stringsOf(t, callStringMethod(t, "split", c.text, MakeSTRING(c.separator)))
+		if strings.Join(parts, "|") != strings.Join(c.expected, "|") || len(parts) != len(c.expected) {
+			t.Errorf("%q.split(%q) gave %q, expected %q", c.text, c.separator, parts, c.expected)
+		}
+	}
+}
+
+func TestStringPadLeft(t *testing.T) {
+	cases := []struct {
+		text     string
+		width    int64
+		fill     RuntimeValue
+		expected string
+	}{
+		{"7", 3, MakeCHAR('0'), "007"},
+		{"", 2, nil, "  "},
+		// the width counts characters, not bytes
+		{"é", 3, MakeCHAR('.'), "..é"},
+		{"ab", 3, MakeCHAR('→'), "→ab"},
+		{"longer", 3, nil, "longer"},
+		{"x", -1, nil, "x"},
+	}
+
+	for _, c := range cases {
+		args := []RuntimeValue{MakeINT(c.width, 64, true)}
+		if c.fill != nil {
+			args = append(args, c.fill)
+		}
+		if padded := callStringMethod(t, "pad_left", c.text, args...).(StringValue).Value; padded != c.expected {
+			t.Errorf("%q.pad_left(%d) gave %q, expected %q", c.text, c.width, padded, c.expected)
+		}
+	}
+}
+
+func TestStringIndexOf(t *testing.T) {
+	cases := []struct {
+		text     string
+		search   string
+		expected int64
+	}{
+		{"hello", "l", 2},
+		{"hello", "", 0},
+		// the position is in bytes, like len and the slices: é takes 2 bytes
+		{"café au lait", "au", 6},
+		{"日本語", "語", 6},
+	}
+
+	for _, c := range cases {
+		index := callStringMethod(t, "index_of", c.text, MakeSTRING(c.search))
+		if index.(IntegerValue).Value != c.expected {
+			t.Errorf("%q.index_of(%q) gave %d, expected %d", c.text, c.search, index.(IntegerValue).Value, c.expected)
+		}
+	}
+
+	for _, text := range []string{"", "hello"} {
+		if _, isNull := callStringMethod(t, "index_of", text, MakeSTRING("z")).(NullValue); !isNull {
+			t.Errorf("%q.index_of(\"z\") is not null", text)
+		}
+	}
+}
+
+func TestStringParseInt(t *testing.T) {
+	for text, expected := range map[string]int64{"42": 42, "-7": -7, "+3": 3, "9223372036854775807": 9223372036854775807} {
+		if value := callStringMethod(t, "parse_int", text); value.(IntegerValue).Value != expected {
+			t.Errorf("%q.parse_int() gave %v, expected %d", text, value, expected)
+		}
+	}
+
+	errors := map[string]string{
+		"":                    `cannot parse "" as i64`,
+		"12a":                 `cannot parse "12a" as i64`,
+		" 1":                  `cannot parse " 1" as i64`,
+		"1.5":                 `cannot parse "1.5" as i64`,
+		"٣":                   `cannot parse "٣" as i64`,
+		"9223372036854775808": `"9223372036854775808" does not fit in i64`,
+	}
+
+	for text, message := range errors {
+		err, isError := callStringMethod(t, "parse_int", text).(ErrorValue)
+		if !isError || err.Message != message {
+			t.Errorf("%q.parse_int() gave %v, expected the error %q", text, err, message)
+		}
+	}
+}
+
+func TestStringParseFloat(t *testing.T) {
+	if value := callStringMethod(t, "parse_float", "2.5e3"); value.(FloatValue).Value != 2500 {
+		t.Errorf("\"2.5e3\".parse_float() gave %v", value)
+	}
+
+	for _, text := range []string{"", "abc", "1,5", "½"} {
+		err, isError := callStringMethod(t, "parse_float", text).(ErrorValue)
+		if !isError || err.Message != `cannot parse "`+text+`" as f64` {
+			t.Errorf("%q.parse_float() gave %v, expected an error", text, err)
+		}
+	}
+}
+
+func TestStringMethodsFromAProgram(t *testing.T) {
+	expectOutput(t, `let parts := "".split(",");
+println(len(parts), " ", len(parts[0]));
+println("日本".pad_left(4, '*'));
+println("naïve".index_of("v") ?? -1, " ", "naïve".index_of("x") ?? -1);
+println("x1".parse_int() catch -1, " ", "-12".parse_int() catch 0);
+println("1e400".parse_float() catch 0.5);
+`, "1 0\n**日本\n4 -1\n-1 -12\n0.5\n")
+
+	// the error of parse_int must be handled
+	expectFailure(t, `let n : i64 = "5".parse_int();`)
+}
@@ -0,0 +1,388 @@
+package typechecker
+
+import (
+	"fmt"
+	"walrus/frontend/ast"
+	"walrus/frontend/parser"
+)
+
+// A tuple is a fixed number of values of possibly different types. A function returns several values as a tuple:
+//
+//	fn stat(path : str) -> (str, i64) {
+//	    ret path, 120;
+//	}
+//
+// and let takes them apart by position, or takes the fields of a struct by name:
+//
+//	let (name, size) := stat(path);
+//	let (_, size) := stat(path);         // _ discards a value
+//	let { name, score } := hero;
+//
+// foreach (k, v) in pairs destructures each element the same way. The flow analysis checks the number
+// of names against the number of values, and the types of the values against the declared tuple types.
+
+// EvaluateTupleExpr evaluates the elements of a tuple. Numeric elements are converted to the declared types if there are any
+func EvaluateTupleExpr(expr ast.TupleExpr, types []ast.Type, env *Environment) RuntimeValue {
+
+	elements := make([]RuntimeValue, len(expr.Elements))
+
+	for i, elementExpr := range expr.Elements {
+		elements[i] = Evaluate(elementExpr, env)
+	}
+
+	if types == nil {
+		return MakeTUPLE(elements)
+	}
+
+	if len(types) != len(elements) {
+		runtimeError(env, expr.StartPos, expr.EndPos, fmt.Sprintf("expected %d values, got %d", len(types), len(elements))).Throw()
+	}
+
+	for i, elementExpr := range expr.Elements {
+		converted, err := convertImplicitly(elements[i], types[i], isNumericConstant(elementExpr))
+
+		if err == nil && !matchesType(converted, types[i]) {
+			err = fmt.Errorf("value %d of the tuple is of type %s, but got %s", i+1, typeName(types[i]), valueTypeName(converted))
+		}
+
+		if err != nil {
+			start, end := elementExpr.GetPos()
+			runtimeError(env, start, end, err.Error()).Throw()
+		}
+
+		elements[i] = converted
+	}
+
+	return TupleValue{Elements: elements, Type: ast.TupleType{Kind: ast.T_TUPLE, Elements: types}}
+}
+
+// convertTuple converts the elements of a tuple to the element types of the target, like separate assignments
+func convertTuple(tuple TupleValue, target ast.TupleType) (RuntimeValue, error) {
+
+	if len(tuple.Elements) != len(target.Elements) {
+		return nil, fmt.Errorf("cannot assign a tuple of %d values to %s", len(tuple.Elements), typeName(target))
+	}
+
+	elements := make([]RuntimeValue, len(tuple.Elements))
+
+	for i, element := range tuple.Elements {
+		converted, err := convertImplicitly(element, target.Elements[i], false)
+		if err != nil {
+			return nil, err
+		}
+		if !matchesType(converted, target.Elements[i]) {
+			return nil, fmt.Errorf("cannot assign value of type '%s' to '%s'", valueTypeName(tuple), typeName(target))
+		}
+		elements[i] = converted
+	}
+
+	return TupleValue{Elements: elements, Type: target}, nil
+}
+
+func EvaluateDestructuringDclStmt(stmt ast.DestructuringDclStmt, env *Environment) RuntimeValue {
+
+	value := Evaluate(stmt.Value, env)
+
+	var values []RuntimeValue
+	var types []ast.Type
+
+	if stmt.IsStruct {
+		values, types = destructureStruct(value, stmt.Names, stmt.Value, env)
+	} else {
+		values, types = destructureTuple(value, stmt.Names, stmt.Value, env)
+	}
+
+	declareDestructured(stmt.Names, values, types, stmt.IsConstant, env)
+
+	return MakeVOID()
+}
+
+// destructureTuple returns the elements of a tuple and their types, one for each name
+func destructureTuple(value RuntimeValue, names []ast.IdentifierExpr, expr ast.Expression, env *Environment) ([]RuntimeValue, []ast.Type) {
+
+	tuple, ok := value.(TupleValue)
+
+	start, end := expr.GetPos()
+
+	if !ok {
+		runtimeError(env, start, end, fmt.Sprintf("cannot destructure a value of type %s into %d names", valueTypeName(value), len(names))).AddHint("only a tuple can be destructured with ", parser.TEXT_HINT).AddHint("(a, b)", parser.CODE_HINT).Throw()
+	}
+
+	if len(tuple.Elements) != len(names) {
+		runtimeError(env, start, end, fmt.Sprintf("cannot destructure %d values into %d names", len(tuple.Elements), len(names))).Throw()
+	}
+
+	return tuple.Elements, tuple.Type.(ast.TupleType).Elements
+}
+
+// destructureStruct returns the fields of a struct instance and their declared types, one for each name
+func destructureStruct(value RuntimeValue, names []ast.IdentifierExpr, expr ast.Expression, env *Environment) ([]RuntimeValue, []ast.Type) {
+
+	instance, ok := value.(StructInstance)
+
+	if !ok {
+		start, end := expr.GetPos()
+		runtimeError(env, start, end, fmt.Sprintf("cannot destructure the fields of a value of type %s", valueTypeName(value))).AddHint("only a struct can be destructured with ", parser.TEXT_HINT).AddHint("{ a, b }", parser.CODE_HINT).Throw()
+	}
+
+	structValue, err := env.GetStructType(instance.StructName)
+	if err != nil {
+		start, end := expr.GetPos()
+		runtimeError(env, start, end, err.Error()).Throw()
+	}
+
+	fields := structValue.(StructValue).Fields
+
+	values := make([]RuntimeValue, len(names))
+	types := make([]ast.Type, len(names))
+
+	for i, name := range names {
+		field, ok := fields[name.Identifier]
+		if !ok {
+			runtimeError(env, name.StartPos, name.EndPos, fmt.Sprintf("property '%s' is not defined in struct '%s'", name.Identifier, instance.StructName)).Throw()
+		}
+		if !field.IsPublic {
+			runtimeError(env, name.StartPos, name.EndPos, fmt.Sprintf("property '%s' is private in struct '%s'", name.Identifier, instance.StructName)).Throw()
+		}
+		values[i] = instance.Fields[name.Identifier]
+		types[i] = field.Type
+	}
+
+	return values, types
+}
+
+// declareDestructured declares a variable for each name except _, with the type of its value
+func declareDestructured(names []ast.IdentifierExpr, values []RuntimeValue, types []ast.Type, isConstant bool, env *Environment) {
+	for i, name := range names {
+		if name.Identifier == "_" {
+			continue
+		}
+
+		if _, err := env.DeclareVariable(name.Identifier, values[i], isConstant); err != nil {
+			runtimeError(env, name.StartPos, name.EndPos, err.Error()).Throw()
+		}
+
+		if types[i] != nil && !isTraitType(types[i], env) {
+			env.setDeclaredType(name.Identifier, types[i])
+		}
+	}
+}
+
+// inferTupleType returns the type of a tuple expression, or nil if the type of an element is not known
+func (a *flowAnalyzer) inferTupleType(expr ast.TupleExpr) ast.Type {
+	types := make([]ast.Type, len(expr.Elements))
+
+	for i, element := range expr.Elements {
+		if types[i] = a.inferType(element); types[i] == nil {
+			return nil
+		}
+	}
+
+	return ast.TupleType{Kind: ast.T_TUPLE, Elements: types}
+}
+
+// tupleElementTypes returns the types of the elements of a tuple value, nil for the ones that are not known.
+// It reports false if the value is not known to be a tuple
+func (a *flowAnalyzer) tupleElementTypes(expr ast.Expression) ([]ast.Type, bool) {
+	if tuple, ok := expr.(ast.TupleExpr); ok {
+		types := make([]ast.Type, len(tuple.Elements))
+		for i, element := range tuple.Elements {
+			types[i] = a.inferType(element)
+		}
+		return types, true
+	}
+
+	if tupleType, ok := a.inferType(expr).(ast.TupleType); ok {
+		return tupleType.Elements, true
+	}
+
+	return nil, false
+}
+
+func (a *flowAnalyzer) analyzeDestructuring(stmt ast.DestructuringDclStmt) {
+	a.analyzeExpr(stmt.Value)
+	a.checkNotNull(stmt.Value)
+	a.checkHandled(stmt.Value)
+
+	var types []ast.Type
+
+	if stmt.IsStruct {
+		types = a.structPatternTypes(stmt.Names, a.inferType(stmt.Value), stmt.Value)
+	} else {
+		elements, isTuple := a.tupleElementTypes(stmt.Value)
+		if !isTuple {
+			elements = nil
+			if t := a.inferType(stmt.Value); t != nil {
+				start, end := stmt.Value.GetPos()
+				parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("cannot destructure a value of type %s into %d names", typeName(t), len(stmt.Names))).AddHint("only a tuple can be destructured with ", parser.TEXT_HINT).AddHint("(a, b)", parser.CODE_HINT).Display()
+			}
+		}
+		types = a.tuplePatternTypes(stmt.Names, elements, isTuple, stmt.Value)
+	}
+
+	a.declareDestructured(stmt, types)
+}
+
+// tuplePatternTypes checks that there is a name for each element of the tuple and returns the type of each name
+func (a *flowAnalyzer) tuplePatternTypes(names []ast.IdentifierExpr, elements []ast.Type, isTuple bool, value ast.Expression) []ast.Type {
+	if isTuple && len(elements) != len(names) {
+		start, end := value.GetPos()
+		parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("cannot destructure %d values into %d names", len(elements), len(names))).AddHint("use _ to discard a value. e.g. ", parser.TEXT_HINT).AddHint("let (_, size) := stat(path);", parser.CODE_HINT).Display()
+	}
+
+	types := make([]ast.Type, len(names))
+	if isTuple {
+		copy(types, elements)
+	}
+	return types
+}
+
+// structPatternTypes checks that each name is a field of the struct and returns the declared type of each field
+func (a *flowAnalyzer) structPatternTypes(names []ast.IdentifierExpr, t ast.Type, value ast.Expression) []ast.Type {
+	types := make([]ast.Type, len(names))
+
+	if t == nil {
+		return types
+	}
+
+	structType, ok := t.(ast.StructType)
+	if !ok {
+		start, end := value.GetPos()
+		parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("cannot destructure the fields of a value of type %s", typeName(t))).AddHint("only a struct can be destructured with ", parser.TEXT_HINT).AddHint("{ a, b }", parser.CODE_HINT).Display()
+	}
+
+	var fields map[string]ast.Property
+	if declaration, ok := a.structs[structType.Name]; ok {
+		fields = declaration.Properties
+	} else if structValue, err := a.env.GetStructType(structType.Name); err == nil {
+		fields = structValue.(StructValue).Fields
+	} else {
+		return types
+	}
+
+	for i, name := range names {
+		field, ok := fields[name.Identifier]
+		if !ok {
+			parser.MakeError(a.env.parser, name.StartPos.Line, a.env.parser.FilePath, name.StartPos, name.EndPos, fmt.Sprintf("property '%s' is not defined in struct '%s'", name.Identifier, structType.Name)).Display()
+		}
+		types[i] = field.Type
+	}
+
+	return types
+}
+
+// declareDestructured declares the names of a destructuring let as assigned variables. _ is not declared
+func (a *flowAnalyzer) declareDestructured(stmt ast.DestructuringDclStmt, types []ast.Type) {
+	for i, name := range stmt.Names {
+		if name.Identifier == "_" {
+			continue
+		}
+
+		variable := &flowVariable{
+			name: name.Identifier,
+			declaration: ast.VariableDclStml{
+				BaseStmt:   stmt.BaseStmt,
+				IsConstant: stmt.IsConstant,
+				Identifier: name,
+			},
+			declType: types[i],
+		}
+
+		a.scopes[len(a.scopes)-1][name.Identifier] = variable
+		a.state.assigned[variable] = true
+		if isNonNullType(types[i]) {
+			a.state.nonNull[variable] = true
+		}
+	}
+}
+
+// declareForeachVariables declares the names of foreach (k, v) in pairs, for elements of the given type
+func (a *flowAnalyzer) declareForeachVariables(stmt ast.ForeachStmt, elementType ast.Type) {
+	elements, isTuple := []ast.Type(nil), false
+
+	if tupleType, ok := elementType.(ast.TupleType); ok {
+		elements, isTuple = tupleType.Elements, true
+	} else if elementType != nil {
+		start, end := stmt.Iterable.GetPos()
+		parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("cannot destructure elements of type %s into %d names", typeName(elementType), len(stmt.Variables))).AddHint("only tuples can be destructured with ", parser.TEXT_HINT).AddHint("foreach (a, b) in pairs", parser.CODE_HINT).Display()
+	}
+
+	types := a.tuplePatternTypes(stmt.Variables, elements, isTuple, stmt.Iterable)
+
+	a.declareDestructured(ast.DestructuringDclStmt{BaseStmt: stmt.BaseStmt, Names: stmt.Variables}, types)
+}
+
+// checkTupleAssignment checks the number and the types of the values stored in a tuple of the target type
+func (a *flowAnalyzer) checkTupleAssignment(target ast.TupleType, value ast.Expression) {
+	elements, isTuple := a.tupleElementTypes(value)
+
+	start, end := value.GetPos()
+
+	if !isTuple {
+		if t := a.inferType(value); t != nil && !isFallibleType(t) && t.IType() != ast.T_NULL {
+			parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("expected %s, got %s", typeName(target), typeName(t))).Display()
+		}
+		return
+	}
+
+	if len(elements) != len(target.Elements) {
+		parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("expected %d values for %s, got %d", len(target.Elements), typeName(target), len(elements))).Display()
+	}
+
+	tuple, isLiteral := value.(ast.TupleExpr)
+
+	for i, elementType := range target.Elements {
+		if !isLiteral {
+			if elements[i] != nil && typeName(elements[i]) != typeName(elementType) {
+				parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("expected %s, got %s", typeName(target), typeName(ast.TupleType{Kind: ast.T_TUPLE, Elements: elements}))).Display()
+			}
+			continue
+		}
+
+		a.checkNullAssignment(elementType, tuple.Elements[i])
+		a.checkElementType(elementType, tuple.Elements[i], elements[i])
+	}
+}
+
+// checkElementType rejects a value of a literal whose type cannot be stored in the declared element type
+func (a *flowAnalyzer) checkElementType(target ast.Type, value ast.Expression, valueType ast.Type) {
+	if valueType == nil {
+		return
+	}
+
+	if result, ok := target.(ast.ResultType); ok {
+		if isFallibleType(valueType) {
+			return
+		}
+		target = result.Value
+	}
+	if optional, ok := target.(ast.OptionalType); ok {
+		if valueType.IType() == ast.T_NULL || valueType.IType() == ast.T_OPTIONAL {
+			return
+		}
+		target = optional.Inner
+	}
+
+	switch {
+	case isFallibleType(valueType), valueType.IType() == ast.T_NULL, valueType.IType() == ast.T_OPTIONAL:
+		// reported by checkNullAssignment
+		return
+	case typeName(valueType) == typeName(target):
+		return
+	case isNumericConstant(value) && categoryOf(valueType) != "" && (categoryOf(target) == integerCategory || categoryOf(target) == floatCategory):
+		// a constant is converted if it fits, which is checked when it is evaluated
+		if !(categoryOf(valueType) == floatCategory && categoryOf(target) == integerCategory) {
+			return
+		}
+	case isLosslessConversion(valueType, target):
+		return
+	}
+
+	if structType, ok := target.(ast.StructType); ok && a.isTrait(structType.Name) {
+		// a trait accepts the structs that implement it
+		return
+	}
+
+	start, end := value.GetPos()
+	parser.MakeError(a.env.parser, start.Line, a.env.parser.FilePath, start, end, fmt.Sprintf("cannot assign value of type '%s' to '%s'", typeName(valueType), typeName(target))).Display()
+}
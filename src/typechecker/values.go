@@ -2,6 +2,7 @@ package typechecker
 
 import (
 	"fmt"
+	"math/big"
 	"walrus/frontend/ast"
 )
 
@@ -11,8 +12,10 @@ type RuntimeValue interface {
 
 type IntegerValue struct {
 	Value int64
-	Size  uint8
-	Type  ast.Type
+	// exact value of i128, u128 and bigint integers. nil for the smaller types
+	Big  *big.Int
+	Size uint8
+	Type ast.Type
 }
 
 func (i IntegerValue) rVal() {
@@ -47,8 +50,9 @@ func (s StringValue) rVal() {
 	// empty function implements RuntimeValue interface
 }
 
+// CharacterValue is a Unicode code point
 type CharacterValue struct {
-	Value byte
+	Value rune
 	Type  ast.Type
 }
 
@@ -64,6 +68,16 @@ func (n NullValue) rVal() {
 	// empty function implements RuntimeValue interface
 }
 
+// ErrorValue is a recoverable failure. It is returned by functions of type T! and handled with try or catch
+type ErrorValue struct {
+	Message string
+	Type    ast.Type
+}
+
+func (e ErrorValue) rVal() {
+	// empty function implements RuntimeValue interface
+}
+
 type VoidValue struct {
 	Type ast.Type
 }
@@ -112,11 +126,77 @@ func (s StructInstance) rVal() {
 	// empty function implements RuntimeValue interface
 }
 
+// ArrayValue shares its elements when it is copied, like the fields of a StructInstance
+type ArrayValue struct {
+	Elements    []RuntimeValue
+	ElementType ast.Type
+	Type        ast.Type
+}
+
+func (a ArrayValue) rVal() {
+	// empty function implements RuntimeValue interface
+}
+
+// TupleValue is a fixed number of values, like the values of ret a, b;
+type TupleValue struct {
+	Elements []RuntimeValue
+	Type     ast.Type
+}
+
+func (t TupleValue) rVal() {
+	// empty function implements RuntimeValue interface
+}
+
+// MapValue shares its entries when it is copied, like an ArrayValue. Type is a MapType
+type MapValue struct {
+	Entries *collectionEntries
+	Type    ast.Type
+}
+
+func (m MapValue) rVal() {
+	// empty function implements RuntimeValue interface
+}
+
+// SetValue shares its elements when it is copied. The elements are the keys of the entries. Type is a SetType
+type SetValue struct {
+	Entries *collectionEntries
+	Type    ast.Type
+}
+
+func (s SetValue) rVal() {
+	// empty function implements RuntimeValue interface
+}
+
+// RangeValue holds only its bounds, the elements are computed when they are used. Type is a RangeType
+type RangeValue struct {
+	Start     IntegerValue
+	End       IntegerValue
+	Step      IntegerValue
+	Inclusive bool
+	Type      ast.Type
+}
+
+func (r RangeValue) rVal() {
+	// empty function implements RuntimeValue interface
+}
+
+// TypeValue is the result of typeof(x). Of is the type it describes, Type is a TypeType
+type TypeValue struct {
+	Of   ast.Type
+	Type ast.Type
+}
+
+func (t TypeValue) rVal() {
+	// empty function implements RuntimeValue interface
+}
+
 type FunctionCall = func(...RuntimeValue) RuntimeValue
 
 type NativeFunctionValue struct {
 	Caller FunctionCall
 	Type   ast.Type
+	// parameters and return type of a function of a native module. The calls of a native without one are not checked
+	Signature *ast.FunctionType
 }
 
 func (n NativeFunctionValue) rVal() {
@@ -125,18 +205,18 @@ func (n NativeFunctionValue) rVal() {
 
 func MakeINT(value int64, size uint8, signed bool) IntegerValue {
 
-	initial := "i"
-
-	if !signed {
-		initial = "u"
-	}
-
-	return IntegerValue{Value: value, Size: size, Type: ast.IntegerType{
-		Kind:     ast.DATA_TYPE((initial + fmt.Sprintf("%d", size))),
+	integer := IntegerValue{Value: value, Size: size, Type: ast.IntegerType{
+		Kind:     ast.DATA_TYPE(integerTypeName(size, signed)),
 		BitSize:  size,
 		IsSigned: signed,
 	},
 	}
+
+	if size == 0 || size > 64 {
+		integer.Big = big.NewInt(value)
+	}
+
+	return integer
 }
 
 func MakeFLOAT(value float64, size uint8) FloatValue {
@@ -162,13 +242,76 @@ func MakeSTRING(value string) StringValue {
 	}
 }
 
-func MakeCHAR(value byte) CharacterValue {
+func MakeCHAR(value rune) CharacterValue {
 	return CharacterValue{Value: value, Type: ast.CharType{
 		Kind: ast.T_CHARACTER,
 	},
 	}
 }
 
+func MakeARRAY(elements []RuntimeValue, elementType ast.Type) ArrayValue {
+	return ArrayValue{Elements: elements, ElementType: elementType, Type: ast.ArrayType{
+		Kind:        ast.T_ARRAY,
+		ElementType: elementType,
+	},
+	}
+}
+
+// MakeTUPLE makes a tuple of the given values. Its type is made of the types of the elements
+func MakeTUPLE(elements []RuntimeValue) TupleValue {
+	types := make([]ast.Type, len(elements))
+	for i, element := range elements {
+		switch v := element.(type) {
+		case NullValue:
+			types[i] = v.Type
+		case VoidValue:
+			types[i] = v.Type
+		case NativeFunctionValue:
+			types[i] = v.Type
+		default:
+			types[i] = typeOfValue(element)
+		}
+	}
+	return TupleValue{Elements: elements, Type: ast.TupleType{
+		Kind:     ast.T_TUPLE,
+		Elements: types,
+	},
+	}
+}
+
+func MakeMAP(keyType ast.Type, valueType ast.Type) MapValue {
+	return MapValue{Entries: newCollectionEntries(), Type: ast.MapType{
+		Kind:  ast.T_MAP,
+		Key:   keyType,
+		Value: valueType,
+	},
+	}
+}
+
+func MakeSET(elementType ast.Type) SetValue {
+	return SetValue{Entries: newCollectionEntries(), Type: ast.SetType{
+		Kind:    ast.T_SET,
+		Element: elementType,
+	},
+	}
+}
+
+// MakeRANGE makes the range start..end, or start..=end. start and end have the element type
+func MakeRANGE(start IntegerValue, end IntegerValue, step IntegerValue, inclusive bool) RangeValue {
+	return RangeValue{Start: start, End: end, Step: step, Inclusive: inclusive, Type: ast.RangeType{
+		Kind:    ast.T_RANGE,
+		Element: start.Type,
+	},
+	}
+}
+
+func MakeTYPE(of ast.Type) TypeValue {
+	return TypeValue{Of: of, Type: ast.TypeType{
+		Kind: ast.T_TYPE,
+	},
+	}
+}
+
 func MakeNULL() NullValue {
 	return NullValue{Type: ast.NullType{
 		Kind: ast.T_NULL,
@@ -176,6 +319,23 @@ func MakeNULL() NullValue {
 	}
 }
 
+func MakeERROR(message string) ErrorValue {
+	return ErrorValue{
+		Message: message,
+		Type: ast.ErrorType{
+			Kind: ast.T_ERROR,
+		},
+	}
+}
+
+// ResultOf converts the result of a Go function to a value of type T!, so native functions can return errors
+func ResultOf(value RuntimeValue, err error) RuntimeValue {
+	if err != nil {
+		return MakeERROR(err.Error())
+	}
+	return value
+}
+
 func MakeVOID() VoidValue {
 	return VoidValue{Type: ast.VoidType{
 		Kind: ast.T_VOID,
@@ -215,6 +375,28 @@ func MakeDefaultRuntimeValue(t ast.Type) RuntimeValue {
 			Methods: make(map[string]ast.FunctionType),
 			Type:    t,
 		}
+	case ast.ArrayType:
+		return MakeARRAY([]RuntimeValue{}, t.ElementType)
+	case ast.OptionalType:
+		return MakeNULL()
+	case ast.ResultType:
+		return MakeDefaultRuntimeValue(t.Value)
+	case ast.MapType:
+		return MakeMAP(t.Key, t.Value)
+	case ast.SetType:
+		return MakeSET(t.Element)
+	case ast.RangeType:
+		// the empty range 0..0
+		empty := MakeDefaultRuntimeValue(t.Element).(IntegerValue)
+		return MakeRANGE(empty, empty, MakeINT(1, 64, true), false)
+	case ast.TypeType:
+		return MakeTYPE(ast.VoidType{Kind: ast.T_VOID})
+	case ast.TupleType:
+		elements := make([]RuntimeValue, len(t.Elements))
+		for i, element := range t.Elements {
+			elements[i] = MakeDefaultRuntimeValue(element)
+		}
+		return TupleValue{Elements: elements, Type: t}
 	default:
 		panic(fmt.Sprintf("unsupported type %T", t))
 	}
@@ -227,7 +409,7 @@ func IsTruthy(value RuntimeValue) bool {
 
 	switch value := value.(type) {
 	case IntegerValue:
-		return value.Value != 0
+		return value.bigValue().Sign() != 0
 	case FloatValue:
 		return value.Value != 0
 	case BooleanValue: